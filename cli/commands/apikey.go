@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"fmt"
+	"mini-backup/pkg/audit"
+	"mini-backup/pkg/auth"
+	"mini-backup/pkg/utils"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NewAPIKeyCommand crée la commande "apikey" qui gère les clés d'API du serveur, via l'API
+// (--server, rôle admin requis) ou directement dans le fichier de clés local.
+func NewAPIKeyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apikey",
+		Short: "Create, list and revoke the API keys of the server",
+	}
+	cmd.AddCommand(newAPIKeyCreateCommand(), newAPIKeyListCommand(), newAPIKeyRevokeCommand())
+	return cmd
+}
+
+func newAPIKeyCreateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create an API key and print its token (shown only once)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			role, _ := cmd.Flags().GetString("role")
+			expires, _ := cmd.Flags().GetString("expires")
+
+			var key auth.Key
+			var token string
+			var err error
+			if client := newAPIClient(cmd); client != nil {
+				var resp struct {
+					Key   auth.Key `json:"key"`
+					Token string   `json:"token"`
+				}
+				err = client.do(http.MethodPost, "/api/keys", map[string]string{"name": args[0], "role": role, "expires": expires}, &resp)
+				key, token = resp.Key, resp.Token
+			} else {
+				var store *auth.Store
+				var ttl time.Duration
+				if ttl, err = auth.ParseTTL(expires); err == nil {
+					if store, err = localKeyStore(); err == nil {
+						key, token, err = store.Create(args[0], auth.Role(role), ttl)
+						recordLocalAudit(audit.ActionKeyCreate, args[0], map[string]string{"role": role}, err)
+					}
+				}
+			}
+			if err != nil {
+				fmt.Printf("Failed to create API key: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("API key %s (%s) created with role %s.\n", key.Name, key.ID, key.Role)
+			if key.ExpiresAt != nil {
+				fmt.Printf("Expires: %s\n", key.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+			}
+			fmt.Printf("Token (store it now, it cannot be shown again):\n%s\n", token)
+		},
+	}
+	cmd.Flags().String("role", string(auth.RoleViewer), "Role of the key ("+strings.Join(auth.Roles, ", ")+")")
+	cmd.Flags().String("expires", "", "Validity of the key, e.g. 90d or 12h (default: never expires)")
+	return cmd
+}
+
+func newAPIKeyListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the API keys",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var keys []auth.Key
+			var err error
+			if client := newAPIClient(cmd); client != nil {
+				var resp struct {
+					Keys []auth.Key `json:"keys"`
+				}
+				err = client.do(http.MethodGet, "/api/keys", nil, &resp)
+				keys = resp.Keys
+			} else {
+				var store *auth.Store
+				if store, err = localKeyStore(); err == nil {
+					keys, err = store.List()
+				}
+			}
+			if err != nil {
+				fmt.Printf("Failed to list API keys: %v\n", err)
+				os.Exit(1)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tROLE\tCREATED\tEXPIRES")
+			for _, key := range keys {
+				expires := "never"
+				if key.ExpiresAt != nil {
+					expires = key.ExpiresAt.Local().Format("2006-01-02 15:04:05")
+					if key.Expired() {
+						expires += " (expired)"
+					}
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Role, key.CreatedAt.Local().Format("2006-01-02 15:04:05"), expires)
+			}
+			w.Flush()
+		},
+	}
+}
+
+func newAPIKeyRevokeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke <id|name>",
+		Short: "Revoke an API key",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if client := newAPIClient(cmd); client != nil {
+				err = client.do(http.MethodDelete, "/api/keys/"+url.PathEscape(args[0]), nil, nil)
+			} else {
+				var store *auth.Store
+				if store, err = localKeyStore(); err == nil {
+					_, err = store.Revoke(args[0])
+					recordLocalAudit(audit.ActionKeyRevoke, args[0], nil, err)
+				}
+			}
+			if err != nil {
+				fmt.Printf("Failed to revoke API key: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("API key %s revoked.\n", args[0])
+		},
+	}
+}
+
+// localKeyStore ouvre le fichier de clés du serveur local (auth.keys_file ou <data_dir>/api_keys.json).
+func localKeyStore() (*auth.Store, error) {
+	var config utils.AuthConfig
+	if serverConfig, err := utils.GetConfigServer(); err == nil {
+		config = serverConfig.Auth
+	}
+	return auth.OpenStore(config.KeysPath(utils.DataDir()))
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"fmt"
+	"mini-backup/pkg/audit"
+	"mini-backup/pkg/utils"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NewAuditCommand crée la commande "audit" qui consulte et vérifie le journal d'audit,
+// via l'API (--server, rôle admin requis) ou directement dans le répertoire des données local.
+func NewAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show the audit log of restores, downloads, configuration changes and key operations",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			query := audit.Query{}
+			query.Actor, _ = cmd.Flags().GetString("actor")
+			query.Action, _ = cmd.Flags().GetString("action")
+			query.Target, _ = cmd.Flags().GetString("target")
+			query.Result, _ = cmd.Flags().GetString("result")
+			query.Page, _ = cmd.Flags().GetInt("page")
+			query.PerPage, _ = cmd.Flags().GetInt("per-page")
+			since, _ := cmd.Flags().GetString("since")
+
+			var page audit.Page
+			var err error
+			if since != "" {
+				query.Since, err = time.Parse(time.RFC3339, since)
+				if err != nil {
+					fmt.Printf("Invalid --since (expected RFC 3339): %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if client := newAPIClient(cmd); client != nil {
+				params := url.Values{}
+				for key, value := range map[string]string{"actor": query.Actor, "action": query.Action, "target": query.Target, "result": query.Result, "since": since} {
+					if value != "" {
+						params.Set(key, value)
+					}
+				}
+				params.Set("page", strconv.Itoa(query.Page))
+				params.Set("per_page", strconv.Itoa(query.PerPage))
+				err = client.do(http.MethodGet, "/api/audit?"+params.Encode(), nil, &page)
+			} else {
+				var log *audit.Log
+				if log, err = localAuditLog(); err == nil {
+					page, err = log.List(query)
+				}
+			}
+			if err != nil {
+				fmt.Printf("Failed to read audit log: %v\n", err)
+				os.Exit(1)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SEQ\tTIME\tACTOR\tSOURCE\tIP\tACTION\tTARGET\tRESULT\tERROR")
+			for _, entry := range page.Entries {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.Seq, entry.Time.Local().Format("2006-01-02 15:04:05"),
+					entry.Actor, entry.Source, entry.IP, entry.Action, entry.Target, entry.Result, entry.Error)
+			}
+			w.Flush()
+			fmt.Printf("Page %d/%d (%d entries)\n", page.Page, page.Pages, page.Total)
+		},
+	}
+	cmd.Flags().String("actor", "", "Only show the actions of this API key, user or system user")
+	cmd.Flags().String("action", "", "Only show this action (restore, download, backup.run, config, key...)")
+	cmd.Flags().String("target", "", "Only show the actions whose target contains this text")
+	cmd.Flags().String("result", "", "Only show this result (success, failure, denied)")
+	cmd.Flags().String("since", "", "Only show the actions after this date (RFC 3339)")
+	cmd.Flags().Int("page", 1, "Page to show")
+	cmd.Flags().Int("per-page", audit.DefaultPerPage, "Number of entries per page")
+	cmd.AddCommand(newAuditVerifyCommand())
+	return cmd
+}
+
+// newAuditVerifyCommand crée la commande "audit verify" qui vérifie la chaîne des empreintes.
+func newAuditVerifyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify",
+		Short: "Check that no audit entry was modified, inserted or removed",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var result audit.Verification
+			var err error
+			if client := newAPIClient(cmd); client != nil {
+				err = client.do(http.MethodGet, "/api/audit/verify", nil, &result)
+			} else {
+				var log *audit.Log
+				if log, err = localAuditLog(); err == nil {
+					result, err = log.Verify()
+				}
+			}
+			if err != nil {
+				fmt.Printf("Failed to verify audit log: %v\n", err)
+				os.Exit(1)
+			}
+			if !result.Valid {
+				fmt.Printf("Audit log is NOT intact: %s (line %d, %d valid entries before it)\n", result.Error, result.BrokenAt, result.Entries)
+				os.Exit(2)
+			}
+			fmt.Printf("Audit log is intact: %d entries, last hash %s\n", result.Entries, result.LastHash)
+		},
+	}
+}
+
+// localAuditLog ouvre le journal d'audit local (audit.file ou <data_dir>/audit.log) ; les
+// entrées sont aussi envoyées au syslog configuré.
+func localAuditLog() (*audit.Log, error) {
+	var config utils.AuditConfig
+	if serverConfig, err := utils.GetConfigServer(); err == nil {
+		config = serverConfig.Audit
+	}
+	log, err := audit.Open(config.Path(utils.DataDir()))
+	if err != nil {
+		return nil, err
+	}
+	if config.Syslog != nil {
+		if forwarder, err := audit.NewSyslogForwarder(*config.Syslog); err == nil {
+			log.SetForwarder(forwarder)
+		} else {
+			fmt.Printf("Audit syslog unavailable: %v\n", err)
+		}
+	}
+	return log, nil
+}
+
+// recordLocalAudit enregistre une action exécutée localement par la CLI, au nom de
+// l'utilisateur système. Les actions distantes sont enregistrées par le serveur.
+func recordLocalAudit(action, target string, details map[string]string, actionErr error) {
+	entry := audit.Entry{Actor: os.Getenv("USER"), Method: "local", Source: audit.SourceCLI, Action: action, Target: target, Result: audit.ResultSuccess, Details: details}
+	if current, err := user.Current(); err == nil {
+		entry.Actor = current.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		if entry.Details == nil {
+			entry.Details = map[string]string{}
+		}
+		entry.Details["host"] = host
+	}
+	if actionErr != nil {
+		entry.Result, entry.Error = audit.ResultFailure, actionErr.Error()
+	}
+	log, err := localAuditLog()
+	if err == nil {
+		_, err = log.Append(entry)
+	}
+	if err != nil {
+		fmt.Printf("Failed to record audit entry: %v\n", err)
+	}
+}
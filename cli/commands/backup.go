@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"mini-backup/pkg/audit"
+	"mini-backup/pkg/backup"
+	"mini-backup/pkg/jobs"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NewBackupCommand retourne la commande "backup" et ses sous-commandes.
+func NewBackupCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Run backups",
+	}
+	cmd.AddCommand(newBackupRunCommand())
+	return cmd
+}
+
+// newBackupRunCommand crée la commande "backup run" qui lance un backup immédiatement,
+// localement ou sur le serveur distant indiqué par --server.
+func newBackupRunCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run <name>",
+		Short: "Run a backup now",
+		Long: `Run a backup immediately and report its outcome. Without --server (or ` + ServerEnv + `),
+the backup runs in this process with the local configuration; otherwise the server runs it.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			glacier, _ := cmd.Flags().GetBool("glacier")
+
+			start := time.Now()
+			var err error
+			if client := newAPIClient(cmd); client != nil {
+				fmt.Printf("Running backup %s on %s...\n", name, client.baseURL)
+				err = client.do(http.MethodPost, "/api/backups/"+url.PathEscape(name)+"/run?wait=true", map[string]bool{"glacier": glacier}, nil)
+			} else {
+				fmt.Printf("Running backup %s locally...\n", name)
+				recordLocalRuns()
+				_, err = backup.RunBackup(name, glacier, jobs.TriggerCLI)
+				recordLocalAudit(audit.ActionBackupRun, name, nil, err)
+			}
+			duration := time.Since(start).Round(time.Millisecond)
+			if err != nil {
+				fmt.Printf("Backup %s failed after %s: %v\n", name, duration, err)
+				os.Exit(1)
+			}
+			fmt.Printf("Backup %s completed successfully in %s\n", name, duration)
+		},
+	}
+	cmd.Flags().Bool("glacier", false, "Upload the backup with the Glacier storage class")
+	return cmd
+}
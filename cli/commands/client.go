@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ServerEnv désigne le serveur distant utilisé lorsque --server n'est pas précisé.
+const ServerEnv = "MINI_BACKUP_SERVER"
+
+// TokenEnv contient la clé d'API envoyée au serveur distant lorsque --token n'est pas précisé.
+const TokenEnv = "MINI_BACKUP_TOKEN"
+
+// Variables des fichiers TLS utilisés vers un serveur HTTPS lorsque les flags ne sont pas précisés :
+// autorité qui a signé le certificat du serveur, certificat et clé du client (mTLS).
+const (
+	CACertEnv     = "MINI_BACKUP_CA_CERT"
+	ClientCertEnv = "MINI_BACKUP_CLIENT_CERT"
+	ClientKeyEnv  = "MINI_BACKUP_CLIENT_KEY"
+)
+
+// apiClient appelle l'API d'un serveur mini-backup distant.
+type apiClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// newAPIClient retourne un client vers le serveur indiqué par --server (ou MINI_BACKUP_SERVER),
+// ou nil si la commande doit s'exécuter localement.
+func newAPIClient(cmd *cobra.Command) *apiClient {
+	server, _ := cmd.Flags().GetString("server")
+	if server == "" {
+		server = os.Getenv(ServerEnv)
+	}
+	if server == "" {
+		return nil
+	}
+	if !strings.Contains(server, "://") {
+		server = "http://" + server
+	}
+	token, _ := cmd.Flags().GetString("token")
+	if token == "" {
+		token = os.Getenv(TokenEnv)
+	}
+	tlsConfig, err := clientTLSConfig(cmd)
+	if err != nil {
+		fmt.Printf("Invalid TLS settings: %v\n", err)
+		os.Exit(1)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &apiClient{
+		baseURL: strings.TrimRight(server, "/"),
+		token:   token,
+		// Les backups lancés à distance peuvent durer longtemps
+		http: &http.Client{Timeout: 24 * time.Hour, Transport: transport},
+	}
+}
+
+// clientTLSConfig charge l'autorité du serveur (--ca-cert) et le certificat client (--client-cert,
+// --client-key) pour les serveurs qui exigent le mTLS. Sans --ca-cert, les autorités du système
+// sont utilisées.
+func clientTLSConfig(cmd *cobra.Command) (*tls.Config, error) {
+	setting := func(flag, env string) string {
+		if value, _ := cmd.Flags().GetString(flag); value != "" {
+			return value
+		}
+		return os.Getenv(env)
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile := setting("ca-cert", CACertEnv); caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificate found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+	certFile, keyFile := setting("client-cert", ClientCertEnv), setting("client-key", ClientKeyEnv)
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("--client-cert and --client-key must be set together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// do envoie la requête et décode la réponse JSON dans out. Les réponses en erreur
+// sont converties en erreur avec le message retourné par le serveur.
+func (c *apiClient) do(method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return responseError(resp.StatusCode, data)
+	}
+	// Une réponse en texte brut (journal d'exécution) est retournée telle quelle
+	if raw, ok := out.(*[]byte); ok {
+		*raw = data
+		return nil
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+// responseError convertit une réponse en erreur avec le message retourné par le serveur.
+func responseError(status int, data []byte) error {
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+		return fmt.Errorf("%s (HTTP %d)", apiErr.Error, status)
+	}
+	return fmt.Errorf("HTTP %d: %s", status, strings.TrimSpace(string(data)))
+}
+
+// stream lit le flux Server-Sent Events de path et appelle fn avec les données de chaque message,
+// jusqu'à la fin du flux. Le délai maximal des requêtes ne s'applique pas à un flux.
+func (c *apiClient) stream(path string, fn func(data []byte)) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	client := *c.http
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(resp.Body)
+		return responseError(resp.StatusCode, data)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// Fin du message
+			if len(data) > 0 {
+				fn(data)
+				data = nil
+			}
+		case strings.HasPrefix(line, "data:"):
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")...)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"fmt"
+	"mini-backup/pkg/utils"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCommand retourne la commande "config" et ses sous-commandes.
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the backup configuration",
+	}
+	cmd.AddCommand(newConfigValidateCommand())
+	cmd.AddCommand(newConfigPathCommand())
+	return cmd
+}
+
+// newConfigPathCommand crée la commande "config path" qui affiche où la configuration est lue.
+func newConfigPathCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Show where the backup configuration is loaded from",
+		Long: `Show how the configuration location was resolved (--config-dir flag, then the
+` + utils.ConfigDirEnv + ` environment variable, then the default directory), the files in
+load order and the backups defined more than once (the last definition wins).`,
+		Run: func(cmd *cobra.Command, args []string) {
+			location, err := utils.GetConfigLocation()
+			fmt.Printf("Source:  %s\n", location.Source)
+			fmt.Printf("Entries: %s\n", strings.Join(location.Entries, ", "))
+			fmt.Printf("Dir:     %s\n", location.Dir)
+			if err != nil {
+				fmt.Printf("Failed to resolve configuration files: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("Files:")
+			for _, file := range location.Files {
+				fmt.Printf("  %s\n", file)
+			}
+
+			report := utils.ValidateConfig()
+			report.SortIssues()
+			conflicts := []utils.ValidationIssue{}
+			for _, issue := range report.Issues {
+				if strings.HasPrefix(issue.Message, "overrides ") {
+					conflicts = append(conflicts, issue)
+				}
+			}
+			if len(conflicts) > 0 {
+				fmt.Println("Conflicts:")
+				for _, issue := range conflicts {
+					fmt.Printf("  %s\n", issue)
+				}
+			}
+		},
+	}
+}
+
+// newConfigValidateCommand crée la commande "config validate".
+func newConfigValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [file...]",
+		Short: "Validate the backup configuration files",
+		Long: `Validate the backup configuration. Without arguments, every configuration file of the
+config directory is checked. Files given as arguments are validated together, so a backup may
+extend a template or use defaults defined in another of these files.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, _ := cmd.Flags().GetString("dir")
+
+			reports := []*utils.ValidationReport{}
+			switch {
+			case len(args) > 0:
+				reports = append(reports, utils.ValidateConfigFiles(args))
+			case dir != "":
+				reports = append(reports, utils.ValidateConfigDir(dir))
+			default:
+				reports = append(reports, utils.ValidateConfig())
+			}
+
+			failed := false
+			for _, report := range reports {
+				report.SortIssues()
+				fmt.Print(report.FormatIssues())
+				if report.HasErrors() {
+					failed = true
+				}
+			}
+			if failed {
+				fmt.Println("Configuration is invalid.")
+				os.Exit(1)
+			}
+			fmt.Println("Configuration is valid.")
+		},
+	}
+	cmd.Flags().String("dir", "", "Configuration directory to validate (defaults to the resolved configuration, see config path)")
+	return cmd
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"fmt"
+	"mini-backup/pkg/history"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/utils"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NewHistoryCommand crée la commande "history" qui affiche l'historique des exécutions,
+// depuis le serveur distant (--server) ou depuis le répertoire des données local.
+func NewHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the history of backup and restore runs",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			query := history.Query{}
+			query.Backup, _ = cmd.Flags().GetString("backup")
+			query.Kind, _ = cmd.Flags().GetString("kind")
+			query.Status, _ = cmd.Flags().GetString("status")
+			query.Page, _ = cmd.Flags().GetInt("page")
+			query.PerPage, _ = cmd.Flags().GetInt("per-page")
+
+			var page history.Page
+			var err error
+			if client := newAPIClient(cmd); client != nil {
+				params := url.Values{}
+				for key, value := range map[string]string{"backup": query.Backup, "kind": query.Kind, "status": query.Status} {
+					if value != "" {
+						params.Set(key, value)
+					}
+				}
+				params.Set("page", strconv.Itoa(query.Page))
+				params.Set("per_page", strconv.Itoa(query.PerPage))
+				err = client.do(http.MethodGet, "/api/runs?"+params.Encode(), nil, &page)
+			} else {
+				var store *history.BoltStore
+				store, err = history.OpenBoltStore(history.DefaultPath(utils.DataDir()), historyRetention())
+				if err == nil {
+					page, err = store.List(query)
+					store.Close()
+				}
+			}
+			if err != nil {
+				fmt.Printf("Failed to read run history: %v\n", err)
+				os.Exit(1)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tKIND\tBACKUP\tTYPE\tSTATUS\tSTARTED\tDURATION\tBYTES\tDESTINATIONS\tERROR")
+			for _, run := range page.Runs {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", run.ID, run.Kind, run.Backup, run.Type, run.Status,
+					run.StartedAt.Local().Format("2006-01-02 15:04:05"), (time.Duration(run.DurationMs) * time.Millisecond).String(),
+					run.Bytes, strings.Join(run.Destinations, ","), run.Error)
+			}
+			w.Flush()
+			fmt.Printf("Page %d/%d (%d runs)\n", page.Page, page.Pages, page.Total)
+		},
+	}
+	cmd.Flags().String("backup", "", "Only show the runs of this backup")
+	cmd.Flags().String("kind", "", "Only show runs of this kind (backup, restore)")
+	cmd.Flags().String("status", "", "Only show runs with this status (running, succeeded, failed, cancelled, skipped, interrupted)")
+	cmd.Flags().Int("page", 1, "Page to show")
+	cmd.Flags().Int("per-page", history.DefaultPerPage, "Number of runs per page")
+	cmd.AddCommand(newHistoryLogsCommand())
+	return cmd
+}
+
+// newHistoryLogsCommand crée la commande "history logs" qui affiche le journal d'une exécution.
+func newHistoryLogsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs <run-id>",
+		Short: "Show the log of a run, including the output of external tools",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			tail, _ := cmd.Flags().GetInt("tail")
+
+			var data []byte
+			var err error
+			if client := newAPIClient(cmd); client != nil {
+				err = client.do(http.MethodGet, fmt.Sprintf("/api/runs/%s/logs?tail=%d", url.PathEscape(args[0]), tail), nil, &data)
+			} else {
+				data, err = history.ReadLog(utils.DataDir(), args[0], tail)
+			}
+			if err != nil {
+				fmt.Printf("Failed to read run log: %v\n", err)
+				os.Exit(1)
+			}
+			os.Stdout.Write(data)
+		},
+	}
+	cmd.Flags().Int("tail", 0, "Only show the last lines of the log")
+	return cmd
+}
+
+// recordLocalRuns enregistre dans l'historique local les jobs exécutés par la CLI.
+func recordLocalRuns() {
+	if _, err := history.Open(utils.DataDir(), historyRetention(), jobs.Default, false); err != nil {
+		fmt.Printf("Run history unavailable: %v\n", err)
+	}
+}
+
+// historyRetention retourne la conservation de l'historique configurée dans server.yaml.
+func historyRetention() time.Duration {
+	var settings utils.ServerSettings
+	if serverConfig, err := utils.GetConfigServer(); err == nil {
+		settings = serverConfig.Server
+	}
+	return settings.HistoryRetention()
+}
@@ -0,0 +1,345 @@
+package commands
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mini-backup/pkg/utils"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// NewInitCommand crée la commande "init" qui génère la configuration initiale sans accès réseau.
+func NewInitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create the initial configuration",
+		Long: `Create server.yaml, config.yaml and .env interactively: remote storage, AES key and a first
+backup of each supported type. With --examples, the example files embedded in the binary are
+written as-is. Existing files are never overwritten without confirmation.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, _ := cmd.Flags().GetString("dir")
+			if dir == "" {
+				dir = utils.GetConfigDir()
+			}
+			envPath, _ := cmd.Flags().GetString("env")
+			examplesOnly, _ := cmd.Flags().GetBool("examples")
+
+			p := &prompter{in: bufio.NewReader(cmd.InOrStdin()), out: cmd.OutOrStdout()}
+			var err error
+			if examplesOnly {
+				err = writeExamples(p, dir)
+			} else {
+				err = runInitWizard(p, dir, envPath)
+			}
+			if err != nil {
+				fmt.Printf("Initialization failed: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().String("dir", "", "Configuration directory to initialize (defaults to the resolved configuration directory)")
+	cmd.Flags().String("env", ".env", "Path of the .env file receiving the secrets")
+	cmd.Flags().Bool("examples", false, "Write the embedded example files without asking questions")
+	return cmd
+}
+
+// writeExamples écrit les fichiers d'exemple embarqués.
+func writeExamples(p *prompter, dir string) error {
+	for _, name := range []string{"server.yaml", "config.yaml"} {
+		path := filepath.Join(dir, name)
+		if !p.confirmOverwrite(path) {
+			continue
+		}
+		if err := utils.WriteExampleFile(name, path); err != nil {
+			return err
+		}
+		fmt.Fprintf(p.out, "Written %s\n", path)
+	}
+	return nil
+}
+
+// runInitWizard pose les questions puis écrit server.yaml, .env et config.yaml.
+// config.yaml n'est écrit que s'il passe la validation.
+func runInitWizard(p *prompter, dir, envPath string) error {
+	env := map[string]string{}
+	envOrder := []string{}
+	setEnv := func(key, value string) string {
+		if _, exists := env[key]; !exists {
+			envOrder = append(envOrder, key)
+		}
+		env[key] = value
+		return "${{" + key + "}}"
+	}
+
+	fmt.Fprintln(p.out, "Remote storage (S3 compatible)")
+	storageName := p.ask("Storage name", "main")
+	storage := map[string]any{
+		"endpoint":    p.ask("Endpoint", "http://localhost:9000"),
+		"bucket_name": p.ask("Bucket", "backup"),
+		"region":      p.ask("Region", "fr-par"),
+		"pathStyle":   p.askBool("Path-style addressing (MinIO)", true),
+		"access_key":  setEnv("ACCESS_KEY", p.ask("Access key", "")),
+		"secret_key":  setEnv("SECRET_KEY", p.ask("Secret key", "")),
+	}
+	server := map[string]any{
+		"server": map[string]any{
+			"port":  p.ask("API port", "8080"),
+			"debug": false,
+			"log":   "./logs/server.log",
+		},
+		"rstorage": map[string]any{storageName: storage},
+	}
+
+	fmt.Fprintln(p.out, "\nEncryption")
+	if p.askBool("Generate a new AES-256 key", true) {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return fmt.Errorf("failed to generate AES key: %w", err)
+		}
+		setEnv("AES_KEY", hex.EncodeToString(key))
+	} else if key := p.ask("AES key (hex, 16, 24 or 32 bytes)", ""); key != "" {
+		decoded, err := hex.DecodeString(key)
+		if err != nil || (len(decoded) != 16 && len(decoded) != 24 && len(decoded) != 32) {
+			return fmt.Errorf("invalid AES key: expected 16, 24 or 32 bytes encoded in hexadecimal")
+		}
+		setEnv("AES_KEY", key)
+	}
+
+	backups := map[string]any{}
+	for _, backupType := range utils.SupportedBackupTypes {
+		fmt.Fprintln(p.out)
+		if !p.askBool(fmt.Sprintf("Add a %s backup", backupType), backupType == "folder") {
+			continue
+		}
+		name := p.ask("Backup name", backupType)
+		backups[name] = promptBackup(p, name, backupType)
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("at least one backup is required")
+	}
+
+	serverData, err := marshalConfig(server)
+	if err != nil {
+		return err
+	}
+	configData, err := marshalConfig(map[string]any{"backups": backups})
+	if err != nil {
+		return err
+	}
+
+	// server.yaml est écrit en premier : la validation vérifie les références aux stockages
+	fmt.Fprintln(p.out)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	serverPath := filepath.Join(dir, "server.yaml")
+	if p.confirmOverwrite(serverPath) {
+		if err := os.WriteFile(serverPath, serverData, 0640); err != nil {
+			return err
+		}
+		fmt.Fprintf(p.out, "Written %s\n", serverPath)
+	}
+	if len(env) > 0 && p.confirmOverwrite(envPath) {
+		var content strings.Builder
+		for _, key := range envOrder {
+			fmt.Fprintf(&content, "%s=%s\n", key, env[key])
+		}
+		if err := os.WriteFile(envPath, []byte(content.String()), 0600); err != nil {
+			return err
+		}
+		fmt.Fprintf(p.out, "Written %s\n", envPath)
+	}
+
+	configPath := filepath.Join(dir, "config.yaml")
+	utils.SetConfigDirFlag(dir)
+	report := utils.ValidateConfigData(configPath, configData)
+	report.SortIssues()
+	fmt.Fprint(p.out, report.FormatIssues())
+	if err := report.Err(); err != nil {
+		return err
+	}
+	if p.confirmOverwrite(configPath) {
+		if err := os.WriteFile(configPath, configData, 0640); err != nil {
+			return err
+		}
+		fmt.Fprintf(p.out, "Written %s\n", configPath)
+	}
+	fmt.Fprintln(p.out, "The API requires an API key: create one with `backup-cli apikey create admin --role admin`")
+	return nil
+}
+
+// promptBackup pose les questions propres à chaque type de backup.
+// Les identifiants des sources sont écrits dans config.yaml, comme dans les exemples.
+func promptBackup(p *prompter, name, backupType string) map[string]any {
+	backup := map[string]any{"type": backupType}
+
+	switch backupType {
+	case "mysql":
+		mysql := map[string]any{
+			"host":     p.ask("MySQL host", "localhost"),
+			"port":     p.ask("MySQL port", "3306"),
+			"user":     p.ask("MySQL user", "root"),
+			"password": p.ask("MySQL password", ""),
+		}
+		if databases := p.askList("Databases (comma separated, empty for all)", ""); len(databases) > 0 {
+			mysql["databases"] = databases
+		} else {
+			mysql["all"] = true
+		}
+		backup["mysql"] = mysql
+	case "mongo":
+		backup["mongo"] = map[string]any{
+			"host":     p.ask("MongoDB host", "localhost"),
+			"port":     p.ask("MongoDB port", "27017"),
+			"user":     p.ask("MongoDB user", "root"),
+			"password": p.ask("MongoDB password", ""),
+			"ssl":      p.askBool("Use TLS", false),
+		}
+	case "folder":
+		backup["folder"] = p.askList("Folders (comma separated)", "/var/www")
+	case "s3":
+		s3 := map[string]any{
+			"endpoint":   p.ask("Source S3 endpoint", "http://localhost:9000"),
+			"region":     p.ask("Source S3 region", "fr-par"),
+			"pathStyle":  p.askBool("Path-style addressing", true),
+			"ACCESS_KEY": p.ask("Source S3 access key", ""),
+			"SECRET_KEY": p.ask("Source S3 secret key", ""),
+		}
+		if buckets := p.askList("Buckets (comma separated, empty for all)", ""); len(buckets) > 0 {
+			s3["bucket"] = buckets
+		} else {
+			s3["all"] = true
+		}
+		backup["s3"] = s3
+	case "sqlite":
+		backup["sqlite"] = map[string]any{"paths": p.askList("Database files (comma separated)", "/var/lib/app/app.db")}
+	case "kubernetes":
+		kubernetes := map[string]any{
+			"kubeconfig": p.ask("Kubeconfig path", filepath.Join(os.Getenv("HOME"), ".kube", "config")),
+			"volumes":    map[string]any{"enabled": p.askBool("Back up persistent volumes", true)},
+		}
+		if p.askBool("Back up the cluster state", true) {
+			kubernetes["cluster"] = map[string]any{"backup": "auto"}
+		}
+		backup["kubernetes"] = kubernetes
+	}
+
+	backup["path"] = map[string]any{
+		"local": p.ask("Local working directory", "./backups"),
+		"s3":    p.ask("Remote prefix", "backup/"+name),
+	}
+	backup["schedule"] = map[string]any{"standard": p.ask("Schedule (cron)", "0 2 * * *")}
+	days, err := strconv.Atoi(p.ask("Retention (days)", "14"))
+	if err != nil || days <= 0 {
+		days = 14
+	}
+	backup["retention"] = map[string]any{"standard": map[string]any{"days": days}}
+	return backup
+}
+
+// marshalConfig sérialise la configuration avec l'indentation des fichiers d'exemple,
+// en plaçant la clé `type` en tête de chaque backup.
+func marshalConfig(value any) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	if backups := mappingChild(&node, "backups"); backups != nil {
+		for i := 1; i < len(backups.Content); i += 2 {
+			moveKeyFirst(backups.Content[i], "type")
+		}
+	}
+	var buf strings.Builder
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	encoder.Close()
+	return []byte(buf.String()), nil
+}
+
+func mappingChild(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func moveKeyFirst(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			pair := []*yaml.Node{node.Content[i], node.Content[i+1]}
+			rest := append(append([]*yaml.Node{}, node.Content[:i]...), node.Content[i+2:]...)
+			node.Content = append(pair, rest...)
+			return
+		}
+	}
+}
+
+// prompter lit les réponses de l'utilisateur ; une réponse vide retourne la valeur par défaut.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *prompter) ask(label, def string) string {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", label)
+	}
+	line, _ := p.in.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+func (p *prompter) askBool(label string, def bool) bool {
+	choices := "y/N"
+	if def {
+		choices = "Y/n"
+	}
+	answer := strings.ToLower(p.ask(fmt.Sprintf("%s (%s)", label, choices), ""))
+	switch answer {
+	case "y", "yes", "o", "oui":
+		return true
+	case "n", "no", "non":
+		return false
+	default:
+		return def
+	}
+}
+
+func (p *prompter) askList(label, def string) []string {
+	values := []string{}
+	for _, value := range strings.Split(p.ask(label, def), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// confirmOverwrite demande confirmation avant de remplacer un fichier existant.
+func (p *prompter) confirmOverwrite(path string) bool {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return true
+	}
+	if p.askBool(fmt.Sprintf("%s already exists, overwrite it", path), false) {
+		return true
+	}
+	fmt.Fprintf(p.out, "Kept %s\n", path)
+	return false
+}
@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/notify"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NewJobsCommand retourne la commande "jobs" qui suit les backups et restaurations
+// d'un serveur distant (--server ou MINI_BACKUP_SERVER).
+func NewJobsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "List, inspect, watch and cancel the jobs of a remote server",
+	}
+	cmd.AddCommand(newJobsListCommand(), newJobsShowCommand(), newJobsWatchCommand(), newJobsCancelCommand())
+	return cmd
+}
+
+func newJobsListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the jobs, newest first",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			client := requireAPIClient(cmd)
+			query := url.Values{}
+			for _, flag := range []string{"state", "kind", "name"} {
+				if value, _ := cmd.Flags().GetString(flag); value != "" {
+					query.Set(flag, value)
+				}
+			}
+			var resp struct {
+				Jobs []jobs.Job `json:"jobs"`
+			}
+			if err := client.do(http.MethodGet, "/api/jobs?"+query.Encode(), nil, &resp); err != nil {
+				fmt.Printf("Failed to list jobs: %v\n", err)
+				os.Exit(1)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tKIND\tNAME\tSTATE\tSTEP\tBYTES\tCREATED\tDURATION")
+			for _, job := range resp.Jobs {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", job.ID, job.Kind, job.Name, job.State, job.Step,
+					job.BytesTransferred, job.CreatedAt.Local().Format("2006-01-02 15:04:05"), job.Duration().Round(time.Second))
+			}
+			w.Flush()
+		},
+	}
+	cmd.Flags().String("state", "", "Only list jobs in this state (queued, running, succeeded, failed, cancelled)")
+	cmd.Flags().String("kind", "", "Only list jobs of this kind (backup, restore)")
+	cmd.Flags().String("name", "", "Only list jobs of this backup")
+	return cmd
+}
+
+func newJobsShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show the state and progress of a job",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var job jobs.Job
+			if err := requireAPIClient(cmd).do(http.MethodGet, "/api/jobs/"+url.PathEscape(args[0]), nil, &job); err != nil {
+				fmt.Printf("Failed to get job: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("ID:       %s\n", job.ID)
+			fmt.Printf("Kind:     %s\n", job.Kind)
+			fmt.Printf("Name:     %s\n", job.Name)
+			fmt.Printf("Trigger:  %s\n", job.Trigger)
+			fmt.Printf("State:    %s\n", job.State)
+			if job.Step != "" {
+				fmt.Printf("Step:     %s\n", job.Step)
+			}
+			fmt.Printf("Steps:    %s\n", strings.Join(job.Steps, " > "))
+			fmt.Printf("Bytes:    %d\n", job.BytesTransferred)
+			fmt.Printf("Duration: %s\n", job.Duration().Round(time.Millisecond))
+			if job.Error != "" {
+				fmt.Printf("Error:    %s\n", job.Error)
+			}
+		},
+	}
+}
+
+func newJobsWatchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch [id]",
+		Short: "Follow the state, progress and logs of a job, or of every job, as they happen",
+		Long: `Follow a job live: state changes, steps, transfer progress and rate, and its log lines.
+Without an id, every job of the server is followed until interrupted. With an id, the command
+exits when the job finishes, with an error code if it failed or was cancelled.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			query := url.Values{}
+			if len(args) == 1 {
+				query.Set("run_id", args[0])
+			}
+			if name, _ := cmd.Flags().GetString("name"); name != "" {
+				query.Set("job", name)
+			}
+			if showLogs, _ := cmd.Flags().GetBool("logs"); !showLogs {
+				query.Set("types", "job.queued,job.started,job.succeeded,job.failed,job.cancelled,job.skipped,job.progress,backup,restore")
+			}
+
+			var final jobs.Job
+			steps, lastLines := map[string]string{}, map[string]string{}
+			err := requireAPIClient(cmd).stream("/api/events?"+query.Encode(), func(data []byte) {
+				var event struct {
+					events.Event
+					Dropped int64 `json:"dropped"`
+				}
+				if json.Unmarshal(data, &event) != nil {
+					return
+				}
+				if event.Run != nil {
+					final = *event.Run
+				}
+				if event.Dropped > 0 {
+					fmt.Printf("%s (%d events dropped by the server, the client is too slow)\n", event.Time.Local().Format("15:04:05"), event.Dropped)
+				} else if line := describeEvent(event.Event, steps); line != "" && (event.Type != events.JobProgress || line != lastLines[event.RunID]) {
+					lastLines[event.RunID] = line
+					fmt.Printf("%s %s %s\n", event.Time.Local().Format("15:04:05"), event.RunID, line)
+				}
+			})
+			if err != nil {
+				fmt.Printf("Failed to watch jobs: %v\n", err)
+				os.Exit(1)
+			}
+			if len(args) == 1 && (final.State == jobs.StateFailed || final.State == jobs.StateCancelled) {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().String("name", "", "Only follow the jobs of this backup")
+	cmd.Flags().Bool("logs", true, "Show the log lines of the jobs")
+	return cmd
+}
+
+// describeEvent retourne la ligne affichée pour un événement ; steps retient la dernière étape
+// affichée de chaque job pour ne signaler que les changements d'étape.
+func describeEvent(event events.Event, steps map[string]string) string {
+	switch event.Type {
+	case events.JobLog:
+		return "| " + event.Line
+	case events.JobProgress:
+		run := event.Run
+		if run == nil {
+			return ""
+		}
+		if progress := run.Progress; progress != nil {
+			line := fmt.Sprintf("%s %s", progress.Step, notify.FormatBytes(progress.Done))
+			if progress.Total > 0 {
+				line = fmt.Sprintf("%s %.1f%% (%s / %s", progress.Step, progress.Percent, notify.FormatBytes(progress.Done), notify.FormatBytes(progress.Total))
+			} else {
+				line += " ("
+			}
+			return line + fmt.Sprintf(", %s/s)", notify.FormatBytes(int64(progress.BytesPerSecond)))
+		}
+		if run.Step != "" && steps[run.ID] != run.Step {
+			steps[run.ID] = run.Step
+			return "step " + run.Step
+		}
+		return ""
+	case events.BackupUploaded, events.RestoreDownloaded:
+		return fmt.Sprintf("%s %s %s/%s (%s)", event.Type, event.Storage, event.Bucket, event.Key, notify.FormatBytes(event.Bytes))
+	case events.BackupUploadFailed:
+		return fmt.Sprintf("%s %s: %s", event.Type, event.Storage, event.Error)
+	}
+	if event.Run != nil {
+		delete(steps, event.RunID)
+		line := fmt.Sprintf("%s %s %s", event.Kind, event.Job, event.Run.State)
+		if event.Error != "" {
+			line += ": " + event.Error
+		}
+		return line
+	}
+	if event.Path != "" {
+		return fmt.Sprintf("%s %s", event.Type, event.Path)
+	}
+	return string(event.Type)
+}
+
+func newJobsCancelCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cancel <id>",
+		Short: "Cancel a queued or running job",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := requireAPIClient(cmd).do(http.MethodPost, "/api/jobs/"+url.PathEscape(args[0])+"/cancel", nil, nil); err != nil {
+				fmt.Printf("Failed to cancel job: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Cancellation requested for job %s\n", args[0])
+		},
+	}
+}
+
+// requireAPIClient retourne le client du serveur distant ; les jobs n'existent que dans le serveur.
+func requireAPIClient(cmd *cobra.Command) *apiClient {
+	client := newAPIClient(cmd)
+	if client == nil {
+		fmt.Printf("No server configured: use --server or %s\n", ServerEnv)
+		os.Exit(1)
+	}
+	return client
+}
@@ -2,6 +2,8 @@ package commands
 
 import (
 	"fmt"
+	"mini-backup/pkg/audit"
+	"mini-backup/pkg/jobs"
 	"mini-backup/pkg/restore"
 	"mini-backup/pkg/utils"
 	"path/filepath"
@@ -91,8 +93,10 @@ func NewRestoreCommand() *cobra.Command {
 				}
 			}
 
-			// Appeler CoreRestore avec la version sélectionnée ou "last"
-			err := restore.CoreRestore(name, version, "", "")
+			// Restaurer la version sélectionnée ou "last" (enregistrée dans l'historique local)
+			recordLocalRuns()
+			_, err := restore.RunRestore(name, version, jobs.TriggerCLI)
+			recordLocalAudit(audit.ActionRestore, name, map[string]string{"file": version}, err)
 			if err != nil {
 				fmt.Printf("Erreur lors de la restauration : %v\n", err)
 			} else {
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+	"mini-backup/pkg/utils"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// NewSchemaCommand crée la commande CLI qui exporte les JSON Schema de configuration.
+func NewSchemaCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema [config|server]",
+		Short: "Write the JSON Schema of the configuration files",
+		Long: `Write the JSON Schema of config.yaml (and *.backups.yaml) or server.yaml.
+Without argument, both schemas are written to the output directory.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			output, _ := cmd.Flags().GetString("output")
+
+			if len(args) == 1 {
+				data, err := utils.GenerateSchemaJSON(args[0])
+				if err != nil {
+					fmt.Printf("Failed to generate schema: %v\n", err)
+					os.Exit(1)
+				}
+				if output == "" {
+					fmt.Println(string(data))
+					return
+				}
+				if err := os.WriteFile(output, append(data, '\n'), 0644); err != nil {
+					fmt.Printf("Failed to write %s: %v\n", output, err)
+					os.Exit(1)
+				}
+				fmt.Printf("Schema written to %s\n", output)
+				return
+			}
+
+			if output == "" {
+				output = "."
+			}
+			if err := os.MkdirAll(output, 0755); err != nil {
+				fmt.Printf("Failed to create %s: %v\n", output, err)
+				os.Exit(1)
+			}
+			for _, name := range utils.SchemaNames {
+				data, err := utils.GenerateSchemaJSON(name)
+				if err != nil {
+					fmt.Printf("Failed to generate schema %s: %v\n", name, err)
+					os.Exit(1)
+				}
+				path := filepath.Join(output, name+".schema.json")
+				if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+					fmt.Printf("Failed to write %s: %v\n", path, err)
+					os.Exit(1)
+				}
+				fmt.Printf("Schema written to %s\n", path)
+			}
+		},
+	}
+	cmd.Flags().StringP("output", "o", "", "Output file (single schema) or directory (all schemas)")
+	return cmd
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"mini-backup/cli/commands"
+	"mini-backup/pkg/utils"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -16,12 +17,30 @@ func main() {
 		Use:   "cli",
 		Short: "Mini Backup cli",
 		Long:  `A CLI tool for managing backups and restores.`,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			configDir, _ := cmd.Flags().GetString("config-dir")
+			utils.SetConfigDirFlag(configDir)
+		},
 	}
+	rootCmd.PersistentFlags().String("server", "", "URL of a remote mini-backup server (overrides "+commands.ServerEnv+")")
+	rootCmd.PersistentFlags().String("token", "", "API key sent to the remote server (overrides "+commands.TokenEnv+")")
+	rootCmd.PersistentFlags().String("ca-cert", "", "CA certificate (PEM) of the remote server (overrides "+commands.CACertEnv+")")
+	rootCmd.PersistentFlags().String("client-cert", "", "Client certificate (PEM) for servers requiring mTLS (overrides "+commands.ClientCertEnv+")")
+	rootCmd.PersistentFlags().String("client-key", "", "Private key (PEM) of the client certificate (overrides "+commands.ClientKeyEnv+")")
+	rootCmd.PersistentFlags().String("config-dir", "", "Configuration directories or glob patterns, comma separated (overrides "+utils.ConfigDirEnv+")")
 
 	// Ajouter les commandes depuis les sous-packages
 	rootCmd.AddCommand(commands.NewListCommand())
 	rootCmd.AddCommand(commands.NewRestoreCommand())
 	rootCmd.AddCommand(commands.NewUpdateCommand(currentVersion))
+	rootCmd.AddCommand(commands.NewConfigCommand())
+	rootCmd.AddCommand(commands.NewSchemaCommand())
+	rootCmd.AddCommand(commands.NewInitCommand())
+	rootCmd.AddCommand(commands.NewBackupCommand())
+	rootCmd.AddCommand(commands.NewJobsCommand())
+	rootCmd.AddCommand(commands.NewHistoryCommand())
+	rootCmd.AddCommand(commands.NewAPIKeyCommand())
+	rootCmd.AddCommand(commands.NewAuditCommand())
 
 	// Exécuter la CLI
 	if err := rootCmd.Execute(); err != nil {
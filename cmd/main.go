@@ -1,13 +1,29 @@
 package main
 
 import (
+	"crypto/tls"
+	"errors"
+	"flag"
 	"fmt"
 	"mini-backup/pkg/api"
+	"mini-backup/pkg/audit"
+	"mini-backup/pkg/auth"
 	"mini-backup/pkg/backup"
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/history"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/metrics"
+	"mini-backup/pkg/notify"
 	"mini-backup/pkg/utils"
 )
 
 func main() {
+	configDir := flag.String("config-dir", "", "Configuration directories or glob patterns, comma separated (overrides "+utils.ConfigDirEnv+")")
+	flag.Parse()
+	utils.SetConfigDirFlag(*configDir)
+	// Sans logging.output, les logs du serveur vont sur la sortie standard seule (journald, conteneurs) :
+	// un fichier en plus dupliquerait chaque ligne
+	utils.SetDefaultLogOutput("stdout")
 
 	logger := utils.LoggerFunc()
 
@@ -28,58 +44,195 @@ func main() {
 	defer logger.Close()
 
 	logger.Info("Starting backup tool", utils.Bootstrap_server)
+
+	if err := serverConfig.Logging.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("Invalid server configuration: %v", err), utils.Bootstrap_server)
+		return
+	}
+	if err := serverConfig.Notifications.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("Invalid server configuration: %v", err), utils.Bootstrap_server)
+		return
+	}
+	if err := serverConfig.Concurrency.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("Invalid server configuration: %v", err), utils.Bootstrap_server)
+		return
+	}
+	if err := serverConfig.CORS.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("Invalid server configuration: %v", err), utils.Bootstrap_server)
+		return
+	}
+	if err := serverConfig.Auth.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("Invalid server configuration: %v", err), utils.Bootstrap_server)
+		return
+	}
+	if err := serverConfig.Audit.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("Invalid server configuration: %v", err), utils.Bootstrap_server)
+		return
+	}
+	if serverConfig.Server.TLS != nil {
+		if err := serverConfig.Server.TLS.Validate(); err != nil {
+			logger.Error(fmt.Sprintf("Invalid server configuration: %v", err), utils.Bootstrap_server)
+			return
+		}
+	}
+	jobs.Default.SetMaxParallel(serverConfig.Concurrency.MaxParallel)
+
+	// Les clés d'API protègent les routes, sauf avec auth.enabled: false
+	keys, err := auth.OpenStore(serverConfig.Auth.KeysPath(utils.DataDir()))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to open API keys: %v", err), utils.Bootstrap_server)
+		return
+	}
+	auth.SetDefault(keys)
+	// Le journal d'audit enregistre les restaurations, téléchargements et modifications
+	auditLog, err := audit.Open(serverConfig.Audit.Path(utils.DataDir()))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to open audit log: %v", err), utils.Bootstrap_server)
+		return
+	}
+	if serverConfig.Audit.Syslog != nil {
+		forwarder, err := audit.NewSyslogForwarder(*serverConfig.Audit.Syslog)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to connect to the audit syslog: %v", err), utils.Bootstrap_server)
+			return
+		}
+		auditLog.SetForwarder(forwarder)
+	}
+	audit.SetDefault(auditLog)
+	// La connexion OIDC ouvre des sessions signées, qui passent par les mêmes rôles que les clés
+	if oidcConfig := serverConfig.Auth.OIDC; oidcConfig != nil {
+		sessionKey, err := auth.LoadSessionKey(oidcConfig.SessionSecret, oidcConfig.SessionKeyPath(utils.DataDir()))
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to load the OIDC session key: %v", err), utils.Bootstrap_server)
+			return
+		}
+		provider, err := auth.NewOIDC(*oidcConfig, sessionKey)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Invalid server configuration: %v", err), utils.Bootstrap_server)
+			return
+		}
+		auth.SetDefaultOIDC(provider)
+		logger.Info(fmt.Sprintf("OIDC login enabled with %s", oidcConfig.Issuer), utils.Bootstrap_server)
+	}
+	if !serverConfig.Auth.IsEnabled() {
+		logger.Warn("API authentication is disabled by auth.enabled: false: every route, including restores and downloads, is open to anyone who can reach the server", utils.Bootstrap_server)
+	} else if existing, err := keys.List(); err == nil && len(existing) == 0 && serverConfig.Auth.OIDC == nil {
+		logger.Warn("API authentication is required but no API key exists: create one with `backup-cli apikey create admin --role admin`", utils.Bootstrap_server)
+	}
+
+	// L'historique enregistre chaque exécution ; les exécutions interrompues par un arrêt sont clôturées
+	store, err := history.Open(utils.DataDir(), serverConfig.Server.HistoryRetention(), jobs.Default, true)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to open run history: %v", err), utils.Bootstrap_server)
+		return
+	}
+	// Les métriques reprennent les derniers succès de l'historique puis suivent chaque job
+	if err := metrics.LoadHistory(store); err != nil {
+		logger.Error(fmt.Sprintf("Failed to load metrics from run history: %v", err), utils.Bootstrap_server)
+	}
+	events.Attach(jobs.Default, events.Default)
+	metrics.Attach(events.Default)
+	notify.Attach(events.Default, store)
+	notify.AttachHeartbeats(events.Default)
+	go history.RetainLogs(utils.DataDir(), serverConfig.Server.RunLogRetention(), func(removed int, err error) {
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to prune run logs: %v", err), utils.Bootstrap_server)
+		} else if removed > 0 {
+			logger.Info(fmt.Sprintf("Removed %d expired run logs", removed), utils.Bootstrap_server)
+		}
+	})
+	go history.RetainRuns(store, func(removed int, err error) {
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to prune run history: %v", err), utils.Bootstrap_server)
+		} else if removed > 0 {
+			logger.Info(fmt.Sprintf("Removed %d expired runs from the history", removed), utils.Bootstrap_server)
+		}
+	})
+
+	// Les définitions des backups peuvent provenir d'un dépôt git plutôt que du répertoire local
+	var gitSource *utils.GitConfigSource
+	if serverConfig.ConfigSource != nil {
+		gitSource, err = utils.NewGitConfigSource(*serverConfig.ConfigSource)
+		if err == nil {
+			err = gitSource.Prepare()
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to prepare configuration source: %v", err), utils.Bootstrap_server)
+			return
+		}
+	}
+
+	// Avec server.tls, l'API est servie en HTTPS et le certificat est relu à son renouvellement
+	var tlsConfig *tls.Config
+	scheme := "http"
+	if serverConfig.Server.TLS != nil {
+		if tlsConfig, err = api.NewTLSConfig(*serverConfig.Server.TLS); err != nil {
+			logger.Error(fmt.Sprintf("Failed to load TLS configuration: %v", err), utils.Bootstrap_server)
+			return
+		}
+		scheme = "https"
+		if mode := serverConfig.Server.TLS.ClientAuthMode(); mode != "" {
+			logger.Info(fmt.Sprintf("Client certificates (mTLS) policy: %s", mode), utils.Bootstrap_server)
+		}
+	}
+
 	app := api.ApiServer()
 
 	go func() {
 		// log.Println("API server running at http://localhost:" + serverConfig.Server.Port)
-		logger.Info(fmt.Sprintf("API server running at http://localhost:%s", serverConfig.Server.Port), utils.Bootstrap_server)
-		if err := app.Listen(":" + serverConfig.Server.Port); err != nil {
+		logger.Info(fmt.Sprintf("API server running at %s://localhost:%s", scheme, serverConfig.Server.Port), utils.Bootstrap_server)
+		if err := api.Listen(app, serverConfig.Server.Port, tlsConfig); err != nil {
 			logger.Error(fmt.Sprintf("Failed to start API server: %v", err))
 			logger.Error(fmt.Sprintf("Failed to start API server: %v", err))
 		}
 	}()
-	// Load configuration
-	config, err := utils.GetConfig()
+	// Load and validate configuration
+	config, err := utils.GetConfigStrict()
 	if err != nil {
+		var validationErr *utils.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, issue := range validationErr.Report.Issues {
+				logger.Error(issue.String(), utils.Bootstrap_server)
+			}
+		}
 		logger.Error(fmt.Sprintf("Failed to load configuration: %v", err), utils.Bootstrap_server)
 		return
 	}
+	if location, err := utils.GetConfigLocation(); err == nil {
+		logger.Info(fmt.Sprintf("Configuration loaded from %v (%s)", location.Entries, location.Source), utils.Bootstrap_server)
+	}
 	logger.Debug(fmt.Sprintf("Loaded configuration: %v", config), utils.Bootstrap_server)
-	// Initialize scheduler
-	scheduler := utils.NewScheduler()
+	// Initialize scheduler and schedule backups
+	scheduler, err := backup.StartScheduler(config)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to schedule backups: %v", err), utils.Bootstrap_server)
+		return
+	}
 	defer scheduler.Stop()
 
-	// Schedule backups
-	for name, backupConfig := range config.Backups {
-		if backupConfig.Schedule.Standard != "" {
-			logger.Info(fmt.Sprintf("Scheduling standard backup for %s: %s", name, backupConfig.Schedule.Standard), utils.Bootstrap_server)
-			err := scheduler.AddJob(backupConfig.Schedule.Standard, func() {
-				logger.Info(fmt.Sprintf("Executing standard backup for %s", name), utils.Bootstrap_server)
-				backup.CoreBackup(name, false)
-			})
-			if err != nil {
-				logger.Error(fmt.Sprintf("Failed to schedule standard backup for %s: %v", name, err), utils.Bootstrap_server)
-			}
-		} else {
-			logger.Info(fmt.Sprintf("No schedule found for %s, executing backup immediately", name), utils.Bootstrap_server)
-			backup.CoreBackup(name, false)
+	if gitSource != nil {
+		// Appliquer les nouveaux commits du dépôt ; un commit invalide ne remplace jamais la configuration active
+		if err := gitSource.MarkApplied(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to read applied commit: %v", err), utils.Bootstrap_server)
 		}
-
-		if backupConfig.Schedule.Glacier != "" {
-			logger.Info(fmt.Sprintf("Scheduling Glacier backup for %s: %s", name, backupConfig.Schedule.Glacier), utils.Bootstrap_server)
-			err := scheduler.AddJob(backupConfig.Schedule.Glacier, func() {
-				logger.Info(fmt.Sprintf("Executing Glacier backup for %s", name), utils.Bootstrap_server)
-				backup.CoreBackup(name, true)
-			})
-			if err != nil {
-				logger.Error(fmt.Sprintf("Failed to schedule Glacier backup for %s: %v", name, err), utils.Bootstrap_server)
-			}
+		gitSource.Start(func() error {
+			_, err := backup.ReloadConfig()
+			return err
+		})
+		defer gitSource.Stop()
+	} else {
+		// Recharger la configuration à chaud lorsque les fichiers changent
+		location, _ := utils.GetConfigLocation()
+		watcher, err := utils.WatchConfigDirs(location.Dirs, func() {
+			backup.ReloadConfig()
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to watch configuration directory: %v", err), utils.Bootstrap_server)
+		} else {
+			defer watcher.Close()
 		}
 	}
 
-	// Start the scheduler
-	scheduler.Start()
-
 	// Keep the program running
 	select {}
 }
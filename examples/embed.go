@@ -0,0 +1,10 @@
+// Package examples embarque les fichiers de configuration d'exemple dans les binaires,
+// afin de pouvoir initialiser une installation sans accès réseau.
+package examples
+
+import "embed"
+
+// Files contient server.yaml et config.yaml.
+//
+//go:embed server.yaml config.yaml
+var Files embed.FS
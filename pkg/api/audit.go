@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mini-backup/pkg/api/handlers"
+	"mini-backup/pkg/audit"
+	"mini-backup/pkg/auth"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// auditTarget retourne la cible d'une action et ses détails à partir de la requête.
+type auditTarget func(c *fiber.Ctx) (string, map[string]string)
+
+// audited retourne le middleware qui enregistre l'action dans le journal d'audit, une fois la
+// requête traitée. Il est placé avant requireRole pour enregistrer aussi les accès refusés.
+func audited(action string, target auditTarget) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		recordAudit(c, action, target, err)
+		return err
+	}
+}
+
+// recordAudit enregistre l'action d'après la réponse écrite et l'erreur retournée par la suite de la chaîne.
+func recordAudit(c *fiber.Ctx, action string, target auditTarget, err error) {
+	status := c.Response().StatusCode()
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		status = fiberErr.Code
+	} else if err != nil {
+		status = fiber.StatusInternalServerError
+	}
+	entry := audit.Entry{
+		Actor:  "anonymous",
+		Method: "none",
+		Source: audit.SourceAPI,
+		IP:     c.IP(),
+		Action: action,
+		Status: status,
+	}
+	if principal, ok := c.Locals(handlers.PrincipalLocal).(auth.Principal); ok {
+		entry.Actor, entry.Method = principal.Name, principal.Method
+	}
+	if target != nil {
+		entry.Target, entry.Details = target(c)
+	}
+	if forwarded := c.Get(fiber.HeaderXForwardedFor); forwarded != "" {
+		if entry.Details == nil {
+			entry.Details = map[string]string{}
+		}
+		entry.Details["forwarded_for"] = strings.Clone(forwarded)
+	}
+	switch {
+	case status == fiber.StatusUnauthorized || status == fiber.StatusForbidden:
+		entry.Result = audit.ResultDenied
+	case status >= 400:
+		entry.Result = audit.ResultFailure
+	default:
+		entry.Result = audit.ResultSuccess
+	}
+	entry.Error, entry.Details = responseDetails(c, status, entry.Details)
+	if auditErr := audit.Record(entry); auditErr != nil {
+		logger.Error(fmt.Sprintf("Failed to record audit entry for %s %s: %v", action, entry.Target, auditErr), source_api)
+	}
+}
+
+// responseDetails extrait de la réponse JSON le message d'erreur et l'identifiant du job lancé.
+// Le corps des téléchargements n'est pas lu.
+func responseDetails(c *fiber.Ctx, status int, details map[string]string) (string, map[string]string) {
+	if !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		return "", details
+	}
+	var response struct {
+		Error string `json:"error"`
+		Job   struct {
+			ID string `json:"id"`
+		} `json:"job"`
+	}
+	if json.Unmarshal(c.Response().Body(), &response) != nil {
+		return "", details
+	}
+	if response.Job.ID != "" {
+		if details == nil {
+			details = map[string]string{}
+		}
+		details["run_id"] = response.Job.ID
+	}
+	if status < 400 {
+		return "", details
+	}
+	return response.Error, details
+}
+
+// auditParam retourne la cible d'une action désignée par un paramètre de la route.
+func auditParam(name string) auditTarget {
+	return func(c *fiber.Ctx) (string, map[string]string) {
+		return strings.Clone(c.Params(name)), nil
+	}
+}
+
+// auditRestore retourne le backup restauré et le fichier demandé.
+func auditRestore(c *fiber.Ctx) (string, map[string]string) {
+	var request struct {
+		PathFile string `json:"pathFile"`
+	}
+	json.Unmarshal(c.Body(), &request)
+	target := strings.Clone(c.Params("name"))
+	if request.PathFile == "" {
+		return target, nil
+	}
+	return target, map[string]string{"file": request.PathFile}
+}
+
+// auditKey retourne la clé créée (nom et rôle demandés) ; le jeton n'est jamais enregistré.
+func auditKey(c *fiber.Ctx) (string, map[string]string) {
+	var request struct {
+		Name string `json:"name"`
+		Role string `json:"role"`
+	}
+	json.Unmarshal(c.Body(), &request)
+	return request.Name, map[string]string{"role": request.Role}
+}
+
+// revealable autorise `?reveal=true`, qui retourne les secrets en clair au lieu de les masquer,
+// aux seuls administrateurs. Chaque demande, accordée ou refusée, est enregistrée dans le journal d'audit.
+func revealable(target string) fiber.Handler {
+	reveal := func(*fiber.Ctx) (string, map[string]string) {
+		return target, nil
+	}
+	return func(c *fiber.Ctx) error {
+		if !c.QueryBool("reveal") {
+			return c.Next()
+		}
+		allowed, err := authorize(c, auth.RoleAdmin)
+		if allowed {
+			c.Locals(handlers.RevealLocal, true)
+			err = c.Next()
+		}
+		recordAudit(c, audit.ActionSecretReveal, reveal, err)
+		return err
+	}
+}
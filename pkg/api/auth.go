@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mini-backup/pkg/api/handlers"
+	"mini-backup/pkg/auth"
+	"mini-backup/pkg/utils"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requireRole retourne le middleware qui exige une identité d'au moins ce rôle : une clé d'API
+// transmise dans `Authorization: Bearer <clé>` ou `X-API-Key`, ou le cookie de session d'un
+// utilisateur connecté par OIDC. Seul `auth.enabled: false` laisse les routes ouvertes.
+func requireRole(role auth.Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if allowed, err := authorize(c, role); !allowed {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// authorize vérifie que la requête a au moins ce rôle et enregistre son identité dans les Locals.
+// Sinon, la réponse d'erreur (401, 403 ou 503) est écrite et authorize retourne false.
+func authorize(c *fiber.Ctx, role auth.Role) (bool, error) {
+	serverConfig, err := utils.GetConfigServer()
+	if err != nil {
+		return false, c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": fmt.Sprintf("server configuration unavailable: %v", err),
+		})
+	}
+	if !serverConfig.Auth.IsEnabled() {
+		return true, nil
+	}
+	var principal auth.Principal
+	if token := requestToken(c); token != "" {
+		principal, err = authenticate(token)
+	} else if cookie := c.Cookies(handlers.SessionCookie); cookie != "" {
+		principal, err = authenticateSession(c, cookie, serverConfig.CORS)
+	} else {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="mini-backup"`)
+		response := fiber.Map{"error": "authentication required"}
+		if auth.DefaultOIDC() != nil {
+			response["login_url"] = "/auth/login"
+		}
+		return false, c.Status(fiber.StatusUnauthorized).JSON(response)
+	}
+	if err != nil {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="mini-backup", error="invalid_token"`)
+		return false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if !principal.Role.Allows(role) {
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": fmt.Sprintf("role %s is required (%s has role %s)", role, principal.Name, principal.Role),
+		})
+	}
+	c.Locals(handlers.PrincipalLocal, principal)
+	return true, nil
+}
+
+// requestToken retourne le jeton transmis dans Authorization (Bearer) ou X-API-Key.
+func requestToken(c *fiber.Ctx) string {
+	if scheme, token, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " "); found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(c.Get("X-API-Key"))
+}
+
+func authenticate(token string) (auth.Principal, error) {
+	store := auth.Default()
+	if store == nil {
+		return auth.Principal{}, errors.New("API keys are not available")
+	}
+	key, err := store.Authenticate(token)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	return auth.Principal{Name: key.Name, Role: key.Role, Method: "api_key", KeyID: key.ID}, nil
+}
+
+// authenticateSession vérifie le cookie de session OIDC. Les requêtes qui modifient l'état doivent
+// venir du serveur lui-même ou d'une origine de cors.allow_origins : le cookie est envoyé
+// automatiquement par le navigateur, une autre page ne doit pas pouvoir s'en servir.
+func authenticateSession(c *fiber.Ctx, cookie string, cors utils.CORSConfig) (auth.Principal, error) {
+	provider := auth.DefaultOIDC()
+	if provider == nil {
+		return auth.Principal{}, errors.New("OIDC login is not configured")
+	}
+	session, err := provider.Authenticate(cookie)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+	default:
+		if origin := c.Get(fiber.HeaderOrigin); origin != "" && origin != c.BaseURL() && !slices.Contains(cors.AllowOrigins, origin) {
+			return auth.Principal{}, fmt.Errorf("origin %s is not allowed to use the session cookie", origin)
+		}
+	}
+	return session.Principal(), nil
+}
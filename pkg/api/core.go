@@ -1,20 +1,52 @@
 package api
 
 import (
+	"mini-backup/pkg/utils"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
 )
 
 var source_api = "API CORE"
 
+var logger = utils.LoggerFunc()
+
 // NewServer configure et retourne un serveur Fiber.
 func ApiServer() *fiber.App {
 	app := fiber.New()
+	// Origines autorisées depuis un navigateur : cors.allow_origins de server.yaml (toutes par défaut)
+	var corsConfig utils.CORSConfig
+	var tlsConfig *utils.TLSConfig
+	if serverConfig, err := utils.GetConfigServer(); err == nil {
+		corsConfig = serverConfig.CORS
+		tlsConfig = serverConfig.Server.TLS
+	}
+	app.Use(securityHeaders(tlsConfig))
+	// Les cookies de session OIDC ne sont envoyés qu'aux origines listées explicitement
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
+		AllowOrigins:     corsConfig.Origins(),
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-API-Key",
+		AllowCredentials: corsConfig.Origins() != "*",
 	}))
 	// Configurer les routes
 	SetupRoutes(app)
 
 	return app
 }
+
+// securityHeaders ajoute les en-têtes de sécurité aux réponses : l'API ne sert que du JSON et
+// des fichiers, qui ne doivent être ni interprétés comme du HTML ni intégrés dans une page.
+// Strict-Transport-Security n'est envoyé qu'en HTTPS.
+func securityHeaders(tlsConfig *utils.TLSConfig) fiber.Handler {
+	config := helmet.Config{
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		XFrameOptions:         "DENY",
+		ReferrerPolicy:        "no-referrer",
+	}
+	if tlsConfig != nil {
+		config.HSTSMaxAge = int(tlsConfig.HSTSDuration().Seconds())
+		config.HSTSExcludeSubdomains = !tlsConfig.HSTSSubdomains
+	}
+	return helmet.New(config)
+}
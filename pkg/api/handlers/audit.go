@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"mini-backup/pkg/audit"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListAudit retourne le journal d'audit paginé, des entrées les plus récentes aux plus anciennes.
+// Filtres : `actor`, `action` (config retient config.*), `target`, `result`, `since` et `until`
+// (RFC 3339) ; pagination : `page`, `per_page`.
+func ListAudit(c *fiber.Ctx) error {
+	log := audit.Default()
+	if log == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "audit log is not available",
+		})
+	}
+	query := audit.Query{
+		Actor:   c.Query("actor"),
+		Action:  c.Query("action"),
+		Target:  c.Query("target"),
+		Result:  c.Query("result"),
+		Page:    c.QueryInt("page", 1),
+		PerPage: c.QueryInt("per_page", audit.DefaultPerPage),
+	}
+	for name, value := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		if raw := c.Query(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": fmt.Sprintf("invalid %s parameter (expected RFC 3339): %v", name, err),
+				})
+			}
+			*value = parsed
+		}
+	}
+	page, err := log.List(query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(page)
+}
+
+// VerifyAudit recalcule la chaîne des empreintes du journal d'audit.
+func VerifyAudit(c *fiber.Ctx) error {
+	log := audit.Default()
+	if log == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "audit log is not available",
+		})
+	}
+	result, err := log.Verify()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(result)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"mini-backup/pkg/backup"
+	"mini-backup/pkg/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateBackup ajoute un backup au fichier géré par l'API puis le planifie.
+func CreateBackup(c *fiber.Ctx) error {
+	version, err := utils.SaveManagedBackup(c.Params("name"), c.Body(), true)
+	return configChangeResponse(c, fiber.StatusCreated, version, err)
+}
+
+// UpdateBackup remplace la définition d'un backup du fichier géré par l'API.
+func UpdateBackup(c *fiber.Ctx) error {
+	version, err := utils.SaveManagedBackup(c.Params("name"), c.Body(), false)
+	return configChangeResponse(c, fiber.StatusOK, version, err)
+}
+
+// DeleteBackup supprime un backup du fichier géré par l'API et le retire du scheduler.
+func DeleteBackup(c *fiber.Ctx) error {
+	version, err := utils.DeleteManagedBackup(c.Params("name"))
+	return configChangeResponse(c, fiber.StatusOK, version, err)
+}
+
+// ListConfigVersions retourne l'historique des modifications faites via l'API.
+func ListConfigVersions(c *fiber.Ctx) error {
+	versions, err := utils.ListConfigVersions()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"file":     utils.ManagedConfigPath(),
+		"versions": versions,
+	})
+}
+
+// RollbackConfigVersion restaure le fichier géré par l'API à une version précédente.
+func RollbackConfigVersion(c *fiber.Ctx) error {
+	target, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Version must be an integer",
+		})
+	}
+	version, err := utils.RollbackConfigVersion(target)
+	return configChangeResponse(c, fiber.StatusOK, version, err)
+}
+
+// configChangeResponse recharge le scheduler après une modification et construit la réponse.
+func configChangeResponse(c *fiber.Ctx, status int, version *utils.ConfigVersion, err error) error {
+	if err != nil {
+		response := fiber.Map{"error": err.Error()}
+		var validationErr *utils.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			validationErr.Report.SortIssues()
+			response["issues"] = validationErr.Report.Issues
+			status = fiber.StatusUnprocessableEntity
+		case errors.Is(err, utils.ErrBackupNotFound), errors.Is(err, utils.ErrVersionNotFound):
+			status = fiber.StatusNotFound
+		case errors.Is(err, utils.ErrBackupExists), errors.Is(err, utils.ErrBackupNotManaged), errors.Is(err, utils.ErrConfigReadOnly):
+			status = fiber.StatusConflict
+		default:
+			status = fiber.StatusBadRequest
+		}
+		return c.Status(status).JSON(response)
+	}
+
+	response := fiber.Map{"version": version}
+	result, err := backup.ReloadConfig()
+	if err != nil {
+		response["error"] = "Configuration saved but not applied: " + err.Error()
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	response["result"] = result
+	return c.Status(status).JSON(response)
+}
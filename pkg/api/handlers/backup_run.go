@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"fmt"
+	"mini-backup/pkg/backup"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RunBackup lance immédiatement le backup demandé sous forme de job et retourne 202 avec le job.
+// Le paramètre `glacier` (query ou corps JSON) envoie le backup en classe Glacier ;
+// avec `?wait=true`, la réponse n'est envoyée qu'à la fin du backup et reflète son résultat.
+func RunBackup(c *fiber.Ctx) error {
+	// Le nom est conservé par le job après la requête : copie du buffer de fiber
+	name := strings.Clone(c.Params("name"))
+	logger := utils.LoggerFunc()
+
+	var req struct {
+		Glacier bool `json:"glacier"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Payload invalide",
+			})
+		}
+	}
+	glacier := req.Glacier || c.QueryBool("glacier", false)
+
+	if !backupExists(name) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": fmt.Sprintf("Backup failed: %v: %s", utils.ErrBackupNotFound, name),
+			"name":  name,
+		})
+	}
+
+	logger.Info(fmt.Sprintf("On-demand backup requested for %s (glacier: %t)", name, glacier), "SOURCE API")
+	job := backup.SubmitBackup(name, glacier, jobs.TriggerAPI)
+	if job.State == jobs.StateSkipped {
+		// La politique de chevauchement du backup (skip) refuse un second job
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   job.Error,
+			"name":    name,
+			"glacier": glacier,
+			"job":     job,
+		})
+	}
+	if !c.QueryBool("wait", false) {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Backup started",
+			"name":    name,
+			"glacier": glacier,
+			"job":     job,
+		})
+	}
+
+	job, err := jobs.Default.Wait(job.ID)
+	duration := job.Duration().Round(time.Millisecond).String()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":    fmt.Sprintf("Backup failed: %v", err),
+			"name":     name,
+			"glacier":  glacier,
+			"duration": duration,
+			"job":      job,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message":  "Backup completed successfully",
+		"name":     name,
+		"glacier":  glacier,
+		"duration": duration,
+		"job":      job,
+	})
+}
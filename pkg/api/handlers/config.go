@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"mini-backup/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ValidateConfig valide la configuration des backups.
+// Si un document YAML est envoyé dans le corps de la requête, seul ce document est validé,
+// sinon l'ensemble du répertoire de configuration du serveur est vérifié.
+func ValidateConfig(c *fiber.Ctx) error {
+	var report *utils.ValidationReport
+	if body := c.Body(); len(body) > 0 {
+		name := c.Query("file", "request.yaml")
+		report = utils.ValidateConfigData(name, body)
+	} else {
+		report = utils.ValidateConfig()
+	}
+	report.SortIssues()
+
+	return c.JSON(fiber.Map{
+		"valid":  !report.HasErrors(),
+		"files":  report.Files,
+		"issues": report.Issues,
+	})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/redact"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// streamBuffer est le nombre d'événements en attente pour un client lent ; au-delà, ils sont
+	// ignorés et leur nombre est signalé par un message stream.dropped.
+	streamBuffer = 1024
+	// streamKeepAlive est l'intervalle des commentaires qui gardent la connexion ouverte à travers
+	// les proxys et détectent la déconnexion du client.
+	streamKeepAlive = 15 * time.Second
+)
+
+// StreamEvents diffuse en Server-Sent Events les changements d'état des jobs, leur progression
+// (étape, pourcentage et débit des transferts), les étapes des backups et restaurations et les lignes
+// de leurs journaux. Chaque message contient un événement JSON (voir events.Event).
+//
+// `run_id` limite le flux à une exécution : son état courant est envoyé d'abord et le flux se termine
+// avec elle. `job` limite le flux à un backup, `types` à une liste de types séparés par des virgules
+// (`job` retient tous les job.*).
+func StreamEvents(c *fiber.Ctx) error {
+	runID, name := strings.Clone(c.Query("run_id")), strings.Clone(c.Query("job"))
+	var types []string
+	for _, value := range strings.Split(c.Query("types"), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			types = append(types, strings.Clone(value))
+		}
+	}
+
+	stream := make(chan events.Event, streamBuffer)
+	var dropped atomic.Int64
+	// Abonnement avant la lecture de l'état courant : aucun événement n'est perdu entre les deux
+	unsubscribe := events.Subscribe(func(event events.Event) {
+		if (runID != "" && event.RunID != runID) || (name != "" && event.Job != name) || !matchesType(event.Type, types) {
+			return
+		}
+		select {
+		case stream <- event:
+		default:
+			dropped.Add(1)
+		}
+	})
+
+	var initial *events.Event
+	if runID != "" {
+		job, exists := jobs.Default.Get(runID)
+		if !exists {
+			unsubscribe()
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": fmt.Sprintf("%v: %s", jobs.ErrJobNotFound, runID),
+			})
+		}
+		event := events.StateEvent(job)
+		event.Time = time.Now()
+		initial = &event
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Désactive la mise en tampon des réponses par nginx
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		// Retour immédiat des en-têtes, même sans événement
+		fmt.Fprint(w, ": connected\n\n")
+		if initial != nil {
+			writeStreamEvent(w, *initial)
+			if initial.Run.State.Finished() {
+				w.Flush()
+				return
+			}
+		}
+		if w.Flush() != nil {
+			return
+		}
+		for {
+			select {
+			case event := <-stream:
+				if n := dropped.Swap(0); n > 0 {
+					fmt.Fprintf(w, "data: {\"type\":\"stream.dropped\",\"time\":%q,\"dropped\":%d}\n\n", time.Now().Format(time.RFC3339Nano), n)
+				}
+				writeStreamEvent(w, event)
+				if runID != "" && event.Type != events.JobProgress && event.Run != nil && event.Run.State.Finished() {
+					w.Flush()
+					return
+				}
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			if w.Flush() != nil {
+				// Client déconnecté
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// matchesType indique si le type de l'événement fait partie des types demandés (tous si aucun).
+func matchesType(eventType events.Type, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if string(eventType) == t || strings.HasPrefix(string(eventType), t+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeStreamEvent écrit l'événement, secrets masqués, au format Server-Sent Events.
+func writeStreamEvent(w *bufio.Writer, event events.Event) {
+	data, err := json.Marshal(redact.Value(event))
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
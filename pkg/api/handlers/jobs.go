@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListJobs retourne les jobs de backup et de restauration, du plus récent au plus ancien.
+// Les paramètres `state`, `kind` et `name` filtrent la liste.
+func ListJobs(c *fiber.Ctx) error {
+	state, kind, name := c.Query("state"), c.Query("kind"), c.Query("name")
+	list := []jobs.Job{}
+	for _, job := range jobs.Default.List() {
+		if (state != "" && string(job.State) != state) || (kind != "" && job.Kind != kind) || (name != "" && job.Name != name) {
+			continue
+		}
+		list = append(list, job)
+	}
+	return c.JSON(fiber.Map{
+		"jobs":  list,
+		"count": len(list),
+	})
+}
+
+// GetJob retourne l'état et la progression d'un job.
+func GetJob(c *fiber.Ctx) error {
+	job, exists := jobs.Default.Get(c.Params("id"))
+	if !exists {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": fmt.Sprintf("%v: %s", jobs.ErrJobNotFound, c.Params("id")),
+		})
+	}
+	return c.JSON(job)
+}
+
+// CancelJob annule un job en attente ou en cours.
+func CancelJob(c *fiber.Ctx) error {
+	job, err := jobs.Default.Cancel(c.Params("id"))
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, jobs.ErrJobFinished):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "job": job})
+	}
+	utils.LoggerFunc().Info(fmt.Sprintf("Cancellation requested for job %s (%s %s)", job.ID, job.Kind, job.Name), "SOURCE API")
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Cancellation requested",
+		"job":     job,
+	})
+}
+
+// backupExists vérifie que le backup est défini dans la configuration active,
+// pour répondre 404 avant de créer un job.
+func backupExists(name string) bool {
+	config, err := utils.GetActiveConfig()
+	if err != nil {
+		return false
+	}
+	_, exists := config.Backups[name]
+	return exists
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"mini-backup/pkg/auth"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListAPIKeys retourne les clés d'API, sans leur jeton.
+func ListAPIKeys(c *fiber.Ctx) error {
+	store := auth.Default()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "API keys are not available",
+		})
+	}
+	keys, err := store.List()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{"keys": keys})
+}
+
+// CreateAPIKey crée une clé d'API. Le jeton n'est retourné qu'une fois.
+// Corps : {"name": "ci", "role": "operator", "expires": "90d"}.
+func CreateAPIKey(c *fiber.Ctx) error {
+	store := auth.Default()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "API keys are not available",
+		})
+	}
+	var request struct {
+		Name    string `json:"name"`
+		Role    string `json:"role"`
+		Expires string `json:"expires"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid payload: " + err.Error(),
+		})
+	}
+	ttl, err := auth.ParseTTL(request.Expires)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	key, token, err := store.Create(request.Name, auth.Role(request.Role), ttl)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"key":   key,
+		"token": token,
+	})
+}
+
+// RevokeAPIKey supprime une clé d'API désignée par son identifiant ou son nom.
+func RevokeAPIKey(c *fiber.Ctx) error {
+	store := auth.Default()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "API keys are not available",
+		})
+	}
+	key, err := store.Revoke(strings.Clone(c.Params("id")))
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, auth.ErrKeyNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message": "API key revoked",
+		"key":     key,
+	})
+}
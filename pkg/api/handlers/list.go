@@ -26,6 +26,7 @@ func ListBackups(c *fiber.Ctx) error {
 	})
 }
 
+// DetailBackup retourne la définition des backups ; les secrets sont masqués sauf avec ?reveal=true.
 func DetailBackup(c *fiber.Ctx) error {
 	config, err := utils.GetConfig()
 	if err != nil {
@@ -34,7 +35,7 @@ func DetailBackup(c *fiber.Ctx) error {
 		})
 	}
 	return c.JSON(fiber.Map{
-		"backup": config.Backups,
+		"backup": redacted(c, config.Backups),
 	})
 }
 
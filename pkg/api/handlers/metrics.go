@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"bytes"
+	"mini-backup/pkg/backup"
+	"mini-backup/pkg/metrics"
+	"mini-backup/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics expose les métriques au format texte de Prometheus.
+func Metrics(c *fiber.Ctx) error {
+	// Les prochaines exécutions sont relues à chaque collecte : un rechargement peut les modifier
+	types := map[string]string{}
+	if config, err := utils.GetActiveConfig(); err == nil {
+		for name, backupConfig := range config.Backups {
+			types[name] = backupConfig.Type
+		}
+	}
+	metrics.NextRun.Reset()
+	for name, next := range backup.NextRuns() {
+		metrics.NextRun.Set(float64(next.Unix()), name, types[name])
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.Write(&buf); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return c.Send(buf.Bytes())
+}
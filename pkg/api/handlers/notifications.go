@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"errors"
+	"mini-backup/pkg/notify"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestNotification envoie un message de test vers un canal de notification.
+func TestNotification(c *fiber.Ctx) error {
+	channel := strings.Clone(c.Params("channel"))
+	if err := notify.SendTest(channel); err != nil {
+		status := fiber.StatusBadGateway
+		if errors.Is(err, notify.ErrChannelNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message": "Test notification sent",
+		"channel": channel,
+	})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mini-backup/pkg/audit"
+	"mini-backup/pkg/auth"
+	"mini-backup/pkg/utils"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// SessionCookie contient la session signée d'un utilisateur connecté par OIDC.
+	SessionCookie = "mini_backup_session"
+	// loginCookie contient l'état de connexion entre /auth/login et /auth/callback.
+	loginCookie = "mini_backup_login"
+	// PrincipalLocal est la clé de c.Locals qui contient l'auth.Principal de la requête.
+	PrincipalLocal = "principal"
+)
+
+// OIDCLogin redirige vers le fournisseur d'identité. ?redirect= indique la page à ouvrir
+// après la connexion (chemin du serveur ou origine autorisée par cors.allow_origins).
+func OIDCLogin(c *fiber.Ctx) error {
+	logger := utils.LoggerFunc()
+	provider := auth.DefaultOIDC()
+	if provider == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "OIDC login is not configured (auth.oidc in server.yaml)",
+		})
+	}
+	target, state, err := provider.Login(c.UserContext(), safeRedirect(provider, c.Query("redirect")))
+	if err != nil {
+		logger.Error(fmt.Sprintf("OIDC login failed: %v", err), "SOURCE API")
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     loginCookie,
+		Value:    state,
+		Path:     "/auth",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HTTPOnly: true,
+		Secure:   provider.SecureCookies(),
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect(target, fiber.StatusFound)
+}
+
+// OIDCCallback termine la connexion au retour du fournisseur, ouvre la session et redirige
+// vers la page demandée.
+func OIDCCallback(c *fiber.Ctx) error {
+	logger := utils.LoggerFunc()
+	provider := auth.DefaultOIDC()
+	if provider == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "OIDC login is not configured (auth.oidc in server.yaml)",
+		})
+	}
+	clearCookie(c, loginCookie, "/auth", provider.SecureCookies())
+	if providerError := c.Query("error"); providerError != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": strings.TrimSpace("login refused by the identity provider: " + providerError + " " + c.Query("error_description")),
+		})
+	}
+	session, cookie, redirect, err := provider.Callback(c.UserContext(), c.Query("code"), c.Query("state"), c.Cookies(loginCookie))
+	entry := audit.Entry{Actor: session.Name, Method: "oidc", Source: audit.SourceAPI, IP: c.IP(), Action: audit.ActionLogin, Result: audit.ResultSuccess}
+	if entry.Actor == "" {
+		entry.Actor = "anonymous"
+	}
+	if err != nil {
+		status := fiber.StatusUnauthorized
+		if errors.Is(err, auth.ErrNoRole) {
+			status = fiber.StatusForbidden
+		}
+		logger.Warn(fmt.Sprintf("OIDC login failed: %v", err), "SOURCE API")
+		entry.Result, entry.Status, entry.Error = audit.ResultDenied, status, err.Error()
+		if auditErr := audit.Record(entry); auditErr != nil {
+			logger.Error(fmt.Sprintf("Failed to record audit entry: %v", auditErr), "SOURCE API")
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	logger.Info(fmt.Sprintf("User %s logged in with role %s", session.Name, session.Role), "SOURCE API")
+	entry.Details = map[string]string{"role": string(session.Role), "subject": session.Subject}
+	if auditErr := audit.Record(entry); auditErr != nil {
+		logger.Error(fmt.Sprintf("Failed to record audit entry: %v", auditErr), "SOURCE API")
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     SessionCookie,
+		Value:    cookie,
+		Path:     "/",
+		Expires:  time.Unix(session.ExpiresAt, 0),
+		HTTPOnly: true,
+		Secure:   provider.SecureCookies(),
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect(redirect, fiber.StatusFound)
+}
+
+// Logout ferme la session OIDC. En GET, il redirige vers ?redirect= (ou login_redirect) ;
+// en POST, il répond en JSON.
+func Logout(c *fiber.Ctx) error {
+	provider := auth.DefaultOIDC()
+	secure := provider != nil && provider.SecureCookies()
+	clearCookie(c, SessionCookie, "/", secure)
+	if c.Method() == fiber.MethodGet && provider != nil {
+		return c.Redirect(safeRedirect(provider, c.Query("redirect")), fiber.StatusFound)
+	}
+	return c.JSON(fiber.Map{"message": "logged out"})
+}
+
+// Me retourne l'identité de la requête (clé d'API ou session OIDC), pour l'interface web.
+func Me(c *fiber.Ctx) error {
+	principal, ok := c.Locals(PrincipalLocal).(auth.Principal)
+	if !ok {
+		// Avec auth.enabled: false, les requêtes ne sont pas authentifiées
+		return c.JSON(fiber.Map{
+			"authenticated": false,
+			"oidc":          auth.DefaultOIDC() != nil,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"authenticated": true,
+		"principal":     principal,
+		"oidc":          auth.DefaultOIDC() != nil,
+	})
+}
+
+func clearCookie(c *fiber.Ctx, name, path string, secure bool) {
+	c.Cookie(&fiber.Cookie{
+		Name:     name,
+		Path:     path,
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HTTPOnly: true,
+		Secure:   secure,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// safeRedirect n'accepte qu'un chemin du serveur ou une URL d'une origine autorisée
+// (cors.allow_origins ou login_redirect), pour ne pas rediriger vers un site tiers.
+func safeRedirect(provider *auth.OIDC, target string) string {
+	if target == "" {
+		return provider.LoginRedirect()
+	}
+	if strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") && !strings.HasPrefix(target, "/\\") {
+		return target
+	}
+	origin := urlOrigin(target)
+	if origin == "" {
+		return provider.LoginRedirect()
+	}
+	if origin == urlOrigin(provider.LoginRedirect()) {
+		return target
+	}
+	if serverConfig, err := utils.GetConfigServer(); err == nil && slices.Contains(serverConfig.CORS.AllowOrigins, origin) {
+		return target
+	}
+	return provider.LoginRedirect()
+}
+
+// urlOrigin retourne scheme://hôte[:port] d'une URL absolue http(s), sinon "".
+func urlOrigin(target string) string {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
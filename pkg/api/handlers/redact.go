@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"mini-backup/pkg/redact"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RevealLocal est la clé de c.Locals posée lorsqu'un administrateur a obtenu les secrets
+// en clair avec ?reveal=true (voir le middleware revealable).
+const RevealLocal = "reveal"
+
+// redacted retourne v avec ses secrets masqués, sauf si la requête a obtenu leur affichage.
+func redacted[T any](c *fiber.Ctx, v T) T {
+	if revealed, _ := c.Locals(RevealLocal).(bool); revealed {
+		return v
+	}
+	return redact.Value(v)
+}
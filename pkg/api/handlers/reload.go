@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mini-backup/pkg/backup"
+	"mini-backup/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReloadConfig relit la configuration des backups et reprogramme les jobs modifiés.
+func ReloadConfig(c *fiber.Ctx) error {
+	result, err := backup.ReloadConfig()
+	if err != nil {
+		response := fiber.Map{
+			"error": fmt.Sprintf("Configuration rejected, previous configuration kept: %v", err),
+		}
+		var validationErr *utils.ValidationError
+		if errors.As(err, &validationErr) {
+			response["issues"] = validationErr.Report.Issues
+		}
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(response)
+	}
+	return c.JSON(fiber.Map{
+		"message": "Configuration reloaded",
+		"result":  result,
+	})
+}
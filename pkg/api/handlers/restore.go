@@ -3,15 +3,18 @@ package handlers
 import (
 	"fmt"
 	"log"
+	"mini-backup/pkg/jobs"
 	"mini-backup/pkg/restore"
 	"mini-backup/pkg/utils"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func RestoreBackup(c *fiber.Ctx) error {
 	// Récupérer le paramètre `name` depuis la route
-	name := c.Params("name")
+	// Le nom est conservé par le job après la requête : copie du buffer de fiber
+	name := strings.Clone(c.Params("name"))
 	logger := utils.LoggerFunc()
 	// Définir une structure pour récupérer le payload JSON
 	type RestoreRequest struct {
@@ -28,18 +31,33 @@ func RestoreBackup(c *fiber.Ctx) error {
 	}
 	logger.Info(fmt.Sprintf("RestoreBackup file : %s", req.PathFile), "SOURCE API")
 	logger.Info(fmt.Sprintf("RestoreBackup : %s", name), "SOURCE API")
-	// Appeler la fonction de restauration avec le nom
-	err := restore.CoreRestore(name, req.PathFile, "", "")
+	if !backupExists(name) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to restore backup: %v: %s", utils.ErrBackupNotFound, name),
+		})
+	}
+	// La restauration s'exécute en arrière-plan ; ?wait=true attend sa fin
+	job := restore.SubmitRestore(name, req.PathFile, jobs.TriggerAPI)
+	if !c.QueryBool("wait", false) {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Restore started",
+			"name":    name,
+			"job":     job,
+		})
+	}
+	job, err := jobs.Default.Wait(job.ID)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Erreur de restauration : %v", err), "SOURCE API")
 		log.Printf("Erreur de restauration : %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to restore backup: %v", err),
+			"job":   job,
 		})
 	}
 
 	return c.JSON(fiber.Map{
 		"message": "Backup restored successfully",
 		"name":    name,
+		"job":     job,
 	})
 }
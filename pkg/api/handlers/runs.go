@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mini-backup/pkg/history"
+	"mini-backup/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListRuns retourne l'historique paginé des exécutions, des plus récentes aux plus anciennes.
+// Filtres : `backup`, `kind`, `status`, `since` (RFC 3339) ; pagination : `page`, `per_page`.
+func ListRuns(c *fiber.Ctx) error {
+	store := history.Default()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "run history is not available",
+		})
+	}
+	query := history.Query{
+		Backup:  c.Query("backup"),
+		Kind:    c.Query("kind"),
+		Status:  c.Query("status"),
+		Page:    c.QueryInt("page", 1),
+		PerPage: c.QueryInt("per_page", history.DefaultPerPage),
+	}
+	if since := c.Query("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("invalid since parameter (expected RFC 3339): %v", err),
+			})
+		}
+		query.Since = parsed
+	}
+	page, err := store.List(query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(page)
+}
+
+// GetRun retourne une exécution de l'historique.
+func GetRun(c *fiber.Ctx) error {
+	store := history.Default()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "run history is not available",
+		})
+	}
+	run, err := store.Get(c.Params("id"))
+	if err != nil {
+		return runError(c, err)
+	}
+	return c.JSON(run)
+}
+
+// GetRunLogs retourne le journal d'une exécution en texte brut (`tail` : dernières lignes seulement).
+func GetRunLogs(c *fiber.Ctx) error {
+	store := history.Default()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "run history is not available",
+		})
+	}
+	run, err := store.Get(c.Params("id"))
+	if err != nil {
+		return runError(c, err)
+	}
+	// Le journal a pu être supprimé par la rétention (run_log_days)
+	data, err := history.ReadLog(utils.DataDir(), run.ID, c.QueryInt("tail", 0))
+	if err != nil {
+		return runError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return c.Send(data)
+}
+
+// runError répond 404 pour une exécution ou un journal inconnu, 500 sinon.
+func runError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	if errors.Is(err, history.ErrRunNotFound) || errors.Is(err, history.ErrLogNotFound) {
+		status = fiber.StatusNotFound
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
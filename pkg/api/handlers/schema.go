@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"mini-backup/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListSchemas retourne les URLs des JSON Schema disponibles.
+func ListSchemas(c *fiber.Ctx) error {
+	schemas := fiber.Map{}
+	for _, name := range utils.SchemaNames {
+		schemas[name] = c.BaseURL() + "/api/schema/" + name + ".json"
+	}
+	return c.JSON(fiber.Map{
+		"schemas": schemas,
+	})
+}
+
+// GetSchema retourne le JSON Schema d'un fichier de configuration ("config" ou "server").
+func GetSchema(c *fiber.Ctx) error {
+	data, err := utils.GenerateSchemaJSON(c.Params("name"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	c.Set("Content-Type", "application/schema+json")
+	return c.Send(data)
+}
@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"fmt"
+	"mini-backup/pkg/redact"
 	"mini-backup/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// GetConfigServer retourne la configuration du serveur ; les secrets sont masqués sauf avec ?reveal=true.
 func GetConfigServer(c *fiber.Ctx) error {
 	config, err := utils.GetConfigServer()
 	logger := utils.LoggerFunc()
@@ -16,10 +18,19 @@ func GetConfigServer(c *fiber.Ctx) error {
 			"error": "Failed to load configuration",
 		})
 	}
-	logger.Debug(fmt.Sprintf("Loaded configuration: %v", config), "[API] [HANDLER SERVER]")
-	return c.JSON(fiber.Map{
-		"server": config,
-	})
+	logger.Debug(fmt.Sprintf("Loaded configuration: %+v", redact.Value(config)), "[API] [HANDLER SERVER]")
+	response := fiber.Map{
+		"server": redacted(c, config),
+	}
+	// Emplacement de la configuration des backups, identique à celui résolu par la CLI
+	if location, err := utils.GetConfigLocation(); err == nil {
+		response["config_location"] = location
+	}
+	// Commit appliqué lorsque les backups sont synchronisés depuis un dépôt git
+	if status := utils.GetConfigSourceStatus(); status != nil {
+		response["config_source"] = redacted(c, status)
+	}
+	return c.JSON(response)
 }
 
 // GetRStorageCount retourne le nombre de configurations RStorage
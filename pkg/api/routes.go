@@ -2,28 +2,80 @@ package api
 
 import (
 	"mini-backup/pkg/api/handlers"
+	"mini-backup/pkg/audit"
+	"mini-backup/pkg/auth"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// SetupRoutes configure les routes de l'application.
+// SetupRoutes configure les routes de l'application. Chaque route exige un rôle minimal :
+// viewer pour consulter, operator pour lancer des backups et des restaurations, admin pour
+// télécharger les backups, lire les secrets en clair (?reveal=true) et modifier la configuration.
+// Les actions sensibles sont enregistrées dans le journal d'audit.
 func SetupRoutes(app *fiber.App) {
+	viewer, operator, admin := requireRole(auth.RoleViewer), requireRole(auth.RoleOperator), requireRole(auth.RoleAdmin)
+
+	// Routes de connexion OIDC (publiques) et de déconnexion
+	app.Get("/auth/login", handlers.OIDCLogin)
+	app.Get("/auth/callback", handlers.OIDCCallback)
+	app.Get("/auth/logout", handlers.Logout)
+	app.Post("/auth/logout", handlers.Logout)
+
+	// Route pour les métriques Prometheus
+	app.Get("/metrics", viewer, handlers.Metrics)
+
 	api := app.Group("/api")
+	// Route pour obtenir l'identité de la requête (clé d'API ou session OIDC)
+	api.Get("/me", viewer, handlers.Me)
 	// Route pour récupérer la configuration d'un backup
 	// api.Get("/backup", handlers.GetBackupConfig)
 	// Route pour lister les backups
-	api.Get("/backups", handlers.ListBackups)
-	// Route pour obtenir les backups détaillés
-	api.Get("/backups/all", handlers.DetailBackup)
-	api.Post("/restore/:name", handlers.RestoreBackup)
+	api.Get("/backups", viewer, handlers.ListBackups)
+	// Route pour obtenir les backups détaillés (secrets masqués, ?reveal=true pour un admin)
+	api.Get("/backups/all", viewer, revealable("backups"), handlers.DetailBackup)
+	api.Post("/restore/:name", audited(audit.ActionRestore, auditRestore), operator, handlers.RestoreBackup)
 	// Route pour lister les fichiers d'un backup
-	api.Get("/backups/:name/files", handlers.ListFilesForBackup)
+	api.Get("/backups/:name/files", viewer, handlers.ListFilesForBackup)
+	// Route pour lancer un backup immédiatement (?glacier=true pour la classe Glacier)
+	api.Post("/backups/:name/run", audited(audit.ActionBackupRun, auditParam("name")), operator, handlers.RunBackup)
 	// api.Get("/backups/:name/list", handlers.ListBackupDetails)
-	api.Get("/download/:file", handlers.DownloadBackup)
-	api.Get("/server/config", handlers.GetConfigServer)
+	api.Get("/download/:file", audited(audit.ActionDownload, auditParam("file")), admin, handlers.DownloadBackup)
+	api.Get("/server/config", viewer, revealable("server"), handlers.GetConfigServer)
 
-	api.Get("/server/backups/list", handlers.ListFilesForAllBackup)
-	api.Get("/backups/last-logs", handlers.LastBackupsFromLogs)
-	api.Get("/backup/next-backup", handlers.GetNextBackup)
-	api.Get("/server/rstorage/count", handlers.GetRStorageCount)
+	api.Get("/server/backups/list", viewer, handlers.ListFilesForAllBackup)
+	api.Get("/backups/last-logs", viewer, handlers.LastBackupsFromLogs)
+	api.Get("/backup/next-backup", viewer, handlers.GetNextBackup)
+	api.Get("/server/rstorage/count", viewer, handlers.GetRStorageCount)
+	// Route pour recharger la configuration des backups sans redémarrer le serveur
+	api.Post("/reload", audited(audit.ActionConfigReload, nil), operator, handlers.ReloadConfig)
+	// Route pour valider la configuration (répertoire du serveur ou document envoyé)
+	api.Post("/config/validate", viewer, handlers.ValidateConfig)
+	// Routes pour récupérer les JSON Schema des fichiers de configuration
+	api.Get("/schema", viewer, handlers.ListSchemas)
+	api.Get("/schema/:name", viewer, handlers.GetSchema)
+	// Routes pour créer, modifier et supprimer des backups (fichier api.backups.yaml versionné)
+	api.Post("/backups/:name", audited(audit.ActionConfigCreate, auditParam("name")), admin, handlers.CreateBackup)
+	api.Put("/backups/:name", audited(audit.ActionConfigUpdate, auditParam("name")), admin, handlers.UpdateBackup)
+	api.Delete("/backups/:name", audited(audit.ActionConfigDelete, auditParam("name")), admin, handlers.DeleteBackup)
+	api.Get("/config/versions", viewer, handlers.ListConfigVersions)
+	api.Post("/config/versions/:version/rollback", audited(audit.ActionConfigRollback, auditParam("version")), admin, handlers.RollbackConfigVersion)
+	// Routes pour suivre et annuler les backups et restaurations en cours
+	api.Get("/jobs", viewer, handlers.ListJobs)
+	api.Get("/jobs/:id", viewer, handlers.GetJob)
+	api.Post("/jobs/:id/cancel", audited(audit.ActionJobCancel, auditParam("id")), operator, handlers.CancelJob)
+	// Route pour suivre en direct les jobs (Server-Sent Events) : états, progression et journaux
+	api.Get("/events", viewer, handlers.StreamEvents)
+	// Routes pour consulter l'historique des exécutions
+	api.Get("/runs", viewer, handlers.ListRuns)
+	api.Get("/runs/:id", viewer, handlers.GetRun)
+	api.Get("/runs/:id/logs", viewer, handlers.GetRunLogs)
+	// Route pour tester un canal de notification
+	api.Post("/notifications/:channel/test", operator, handlers.TestNotification)
+	// Routes pour gérer les clés d'API
+	api.Get("/keys", admin, handlers.ListAPIKeys)
+	api.Post("/keys", audited(audit.ActionKeyCreate, auditKey), admin, handlers.CreateAPIKey)
+	api.Delete("/keys/:id", audited(audit.ActionKeyRevoke, auditParam("id")), admin, handlers.RevokeAPIKey)
+	// Routes pour consulter et vérifier le journal d'audit
+	api.Get("/audit", admin, handlers.ListAudit)
+	api.Get("/audit/verify", admin, handlers.VerifyAudit)
 }
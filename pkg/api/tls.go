@@ -0,0 +1,144 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"mini-backup/pkg/utils"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// certCheckInterval limite la vérification des fichiers du certificat aux nouvelles connexions.
+const certCheckInterval = 10 * time.Second
+
+// certReloader fournit le certificat et les autorités clientes courants. Les fichiers sont
+// relus lorsque leur date ou leur taille change ; un fichier invalide (renouvellement en cours)
+// est journalisé et le certificat précédent reste servi.
+type certReloader struct {
+	config utils.TLSConfig
+	base   *tls.Config
+
+	mu        sync.Mutex
+	current   *tls.Config
+	stamps    map[string]fileStamp
+	checkedAt time.Time
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewTLSConfig charge le certificat de server.tls et retourne la configuration TLS du serveur.
+func NewTLSConfig(config utils.TLSConfig) (*tls.Config, error) {
+	base := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.MinVersion == "1.3" {
+		base.MinVersion = tls.VersionTLS13
+	}
+	switch config.ClientAuthMode() {
+	case utils.ClientAuthRequire:
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+	case utils.ClientAuthOptional:
+		base.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	r := &certReloader{config: config, base: base}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:         base.MinVersion,
+		GetConfigForClient: r.configForClient,
+	}, nil
+}
+
+func (r *certReloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checkedAt) >= certCheckInterval {
+		r.checkedAt = time.Now()
+		if r.changed() {
+			if err := r.loadLocked(); err != nil {
+				logger.Error(fmt.Sprintf("Failed to reload TLS certificate, keeping the previous one: %v", err), source_api)
+			} else {
+				logger.Info(fmt.Sprintf("TLS certificate reloaded from %s", r.config.CertFile), source_api)
+			}
+		}
+	}
+	return r.current, nil
+}
+
+func (r *certReloader) load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkedAt = time.Now()
+	return r.loadLocked()
+}
+
+func (r *certReloader) loadLocked() error {
+	stamps := map[string]fileStamp{}
+	for _, path := range r.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("server.tls: %w", err)
+	}
+	config := r.base.Clone()
+	config.Certificates = []tls.Certificate{cert}
+	if r.config.ClientCAFile != "" {
+		data, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("server.tls.client_ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("server.tls.client_ca_file: no PEM certificate found in %s", r.config.ClientCAFile)
+		}
+		config.ClientCAs = pool
+	}
+	r.current, r.stamps = config, stamps
+	return nil
+}
+
+func (r *certReloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+	return files
+}
+
+func (r *certReloader) changed() bool {
+	for _, path := range r.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			// Fichier remplacé en deux temps : il sera relu à la vérification suivante
+			return false
+		}
+		if stamp := r.stamps[path]; !info.ModTime().Equal(stamp.modTime) || info.Size() != stamp.size {
+			return true
+		}
+	}
+	return false
+}
+
+// Listen démarre le serveur en HTTPS avec tlsConfig, ou en HTTP si tlsConfig est nil.
+func Listen(app *fiber.App, port string, tlsConfig *tls.Config) error {
+	if tlsConfig == nil {
+		return app.Listen(":" + port)
+	}
+	ln, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+	return app.Listener(tls.NewListener(ln, tlsConfig))
+}
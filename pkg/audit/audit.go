@@ -0,0 +1,401 @@
+// Package audit tient le journal d'audit des actions sensibles : qui a restauré, téléchargé ou
+// modifié quoi, quand et depuis où. Chaque entrée contient l'empreinte de la précédente, ce qui
+// rend détectable toute modification ou suppression au milieu du journal.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mini-backup/pkg/redact"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions enregistrées.
+const (
+	ActionRestore        = "restore"
+	ActionDownload       = "download"
+	ActionBackupRun      = "backup.run"
+	ActionJobCancel      = "job.cancel"
+	ActionConfigCreate   = "config.create"
+	ActionConfigUpdate   = "config.update"
+	ActionConfigDelete   = "config.delete"
+	ActionConfigRollback = "config.rollback"
+	ActionConfigReload   = "config.reload"
+	ActionKeyCreate      = "key.create"
+	ActionKeyRevoke      = "key.revoke"
+	ActionSecretReveal   = "secret.reveal"
+	ActionLogin          = "auth.login"
+)
+
+// Résultats d'une action : denied lorsque l'authentification ou le rôle a été refusé.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultDenied  = "denied"
+)
+
+// Sources d'une action.
+const (
+	SourceAPI = "api"
+	SourceCLI = "cli"
+)
+
+// Entry est une entrée du journal d'audit.
+type Entry struct {
+	Seq  int64     `json:"seq"`
+	Time time.Time `json:"time"`
+	// Actor est la clé d'API, l'utilisateur OIDC ou l'utilisateur système (CLI) à l'origine de l'action.
+	Actor string `json:"actor"`
+	// Method indique comment l'acteur a été identifié (api_key, oidc, local, none).
+	Method  string            `json:"method,omitempty"`
+	Source  string            `json:"source"`
+	IP      string            `json:"ip,omitempty"`
+	Action  string            `json:"action"`
+	Target  string            `json:"target,omitempty"`
+	Result  string            `json:"result"`
+	Status  int               `json:"status,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+	// PrevHash est l'empreinte de l'entrée précédente (vide pour la première).
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// computeHash retourne l'empreinte de l'entrée : SHA-256 de son JSON sans le champ hash.
+func (e Entry) computeHash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Forwarder reçoit une copie de chaque entrée (syslog).
+type Forwarder interface {
+	Forward(entry Entry) error
+}
+
+// Log est le journal d'audit, un fichier JSON Lines en ajout seul. Le fichier est verrouillé
+// pendant l'ajout, pour que le serveur et la CLI prolongent la même chaîne.
+type Log struct {
+	path string
+
+	mu        sync.Mutex
+	lastSeq   int64
+	lastHash  string
+	size      int64
+	forwarder Forwarder
+}
+
+// Open ouvre (ou crée) le journal d'audit.
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	l := &Log{path: path, size: -1}
+	file, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+	if err := l.readTail(file); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path retourne le chemin du journal.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// SetForwarder définit la destination des copies des entrées (nil : aucune).
+func (l *Log) SetForwarder(forwarder Forwarder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.forwarder = forwarder
+}
+
+// Append ajoute l'entrée en fin de journal et retourne l'entrée numérotée et chaînée.
+func (l *Log) Append(entry Entry) (Entry, error) {
+	entry.Error = redact.String(entry.Error)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return entry, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+	if err := lockFile(file); err != nil {
+		return entry, fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer unlockFile(file)
+
+	// Un autre processus a pu ajouter des entrées depuis la dernière écriture
+	info, err := file.Stat()
+	if err != nil {
+		return entry, err
+	}
+	if info.Size() != l.size {
+		if err := l.readTail(file); err != nil {
+			return entry, err
+		}
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Time = entry.Time.UTC()
+	entry.Seq, entry.PrevHash = l.lastSeq+1, l.lastHash
+	if entry.Hash, err = entry.computeHash(); err != nil {
+		return entry, err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return entry, err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return entry, fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return entry, fmt.Errorf("failed to write audit log: %w", err)
+	}
+	l.lastSeq, l.lastHash, l.size = entry.Seq, entry.Hash, info.Size()+int64(len(data))+1
+	if l.forwarder != nil {
+		if err := l.forwarder.Forward(entry); err != nil {
+			return entry, fmt.Errorf("audit entry %d recorded but not forwarded: %w", entry.Seq, err)
+		}
+	}
+	return entry, nil
+}
+
+// readTail relit le fichier pour retrouver la dernière entrée.
+func (l *Log) readTail(file *os.File) error {
+	if _, err := file.Seek(0, 0); err != nil {
+		return err
+	}
+	var size int64
+	l.lastSeq, l.lastHash = 0, ""
+	scanner := newScanner(file)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		size += int64(len(line)) + 1
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("invalid audit log %s: %w", l.path, err)
+		}
+		l.lastSeq, l.lastHash = entry.Seq, entry.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	l.size = size
+	return nil
+}
+
+// Query filtre les entrées du journal.
+type Query struct {
+	Actor string
+	// Action retient l'action et ses sous-actions (config retient config.update...).
+	Action  string
+	Target  string
+	Result  string
+	Since   time.Time
+	Until   time.Time
+	Page    int
+	PerPage int
+}
+
+// DefaultPerPage est le nombre d'entrées par page sans `per_page`.
+const DefaultPerPage = 50
+
+// Page est une page d'entrées, des plus récentes aux plus anciennes.
+type Page struct {
+	Entries []Entry `json:"entries"`
+	Total   int     `json:"total"`
+	Page    int     `json:"page"`
+	PerPage int     `json:"per_page"`
+	Pages   int     `json:"pages"`
+}
+
+func (q Query) matches(entry Entry) bool {
+	switch {
+	case q.Actor != "" && entry.Actor != q.Actor,
+		q.Action != "" && entry.Action != q.Action && !strings.HasPrefix(entry.Action, q.Action+"."),
+		q.Target != "" && !strings.Contains(entry.Target, q.Target),
+		q.Result != "" && entry.Result != q.Result,
+		!q.Since.IsZero() && entry.Time.Before(q.Since),
+		!q.Until.IsZero() && entry.Time.After(q.Until):
+		return false
+	}
+	return true
+}
+
+// List retourne une page des entrées correspondant à la requête.
+func (l *Log) List(query Query) (Page, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PerPage < 1 {
+		query.PerPage = DefaultPerPage
+	}
+	var matched []Entry
+	err := l.scan(func(entry Entry) error {
+		if query.matches(entry) {
+			matched = append(matched, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return Page{}, err
+	}
+	page := Page{Entries: []Entry{}, Total: len(matched), Page: query.Page, PerPage: query.PerPage}
+	page.Pages = (page.Total + query.PerPage - 1) / query.PerPage
+	// Les plus récentes d'abord
+	end := len(matched) - (query.Page-1)*query.PerPage
+	for i := end - 1; i >= 0 && i >= end-query.PerPage; i-- {
+		page.Entries = append(page.Entries, matched[i])
+	}
+	return page, nil
+}
+
+// Verification est le résultat de la vérification de la chaîne.
+type Verification struct {
+	Valid   bool  `json:"valid"`
+	Entries int64 `json:"entries"`
+	// LastHash est l'empreinte de la dernière entrée : conservée ailleurs, elle permet aussi de
+	// détecter la suppression des dernières entrées.
+	LastHash string `json:"last_hash,omitempty"`
+	// BrokenAt est le numéro de ligne de la première entrée invalide.
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Verify recalcule la chaîne des empreintes et s'arrête à la première entrée modifiée,
+// supprimée ou insérée.
+func (l *Log) Verify() (Verification, error) {
+	result := Verification{Valid: true}
+	var line int64
+	err := l.scan(func(entry Entry) error {
+		line++
+		hash, err := entry.computeHash()
+		if err != nil {
+			return err
+		}
+		var problem string
+		switch {
+		case entry.Seq != result.Entries+1:
+			problem = fmt.Sprintf("sequence %d follows %d", entry.Seq, result.Entries)
+		case entry.PrevHash != result.LastHash:
+			problem = fmt.Sprintf("entry %d does not chain to the previous entry", entry.Seq)
+		case entry.Hash != hash:
+			problem = fmt.Sprintf("entry %d was modified (hash mismatch)", entry.Seq)
+		}
+		if problem != "" {
+			result.Valid, result.BrokenAt, result.Error = false, line, problem
+			return errStop
+		}
+		result.Entries, result.LastHash = entry.Seq, entry.Hash
+		return nil
+	})
+	if err != nil {
+		var parseErr *lineError
+		if errors.As(err, &parseErr) {
+			result.Valid, result.BrokenAt, result.Error = false, parseErr.line, parseErr.Error()
+			return result, nil
+		}
+		return Verification{}, err
+	}
+	return result, nil
+}
+
+var errStop = errors.New("stop")
+
+type lineError struct {
+	line int64
+	err  error
+}
+
+func (e *lineError) Error() string {
+	return fmt.Sprintf("line %d is not a valid entry: %v", e.line, e.err)
+}
+
+// scan lit les entrées dans l'ordre ; fn peut retourner errStop pour arrêter la lecture.
+func (l *Log) scan(fn func(entry Entry) error) error {
+	file, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := newScanner(file)
+	var line int64
+	for scanner.Scan() {
+		line++
+		raw := scanner.Bytes()
+		if len(strings.TrimSpace(string(raw))) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return &lineError{line: line, err: err}
+		}
+		if err := fn(entry); err != nil {
+			if errors.Is(err, errStop) {
+				return nil
+			}
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func newScanner(file *os.File) *bufio.Scanner {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	return scanner
+}
+
+var (
+	defaultMu  sync.Mutex
+	defaultLog *Log
+)
+
+// SetDefault définit le journal utilisé par Record.
+func SetDefault(l *Log) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLog = l
+}
+
+// Default retourne le journal défini par SetDefault, ou nil.
+func Default() *Log {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return defaultLog
+}
+
+// Record ajoute l'entrée au journal par défaut. Une action n'est jamais bloquée par le journal :
+// l'erreur est retournée pour être journalisée par l'appelant.
+func Record(entry Entry) error {
+	l := Default()
+	if l == nil {
+		return errors.New("audit log is not available")
+	}
+	_, err := l.Append(entry)
+	return err
+}
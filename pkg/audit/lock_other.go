@@ -0,0 +1,13 @@
+//go:build !unix
+
+package audit
+
+import "os"
+
+// lockFile ne verrouille pas le journal sur ces plateformes : le serveur et la CLI ne doivent
+// pas y écrire en même temps.
+func lockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) {}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package audit
+
+import (
+	"errors"
+	"mini-backup/pkg/utils"
+)
+
+// NewSyslogForwarder n'est pas disponible sur cette plateforme.
+func NewSyslogForwarder(config utils.AuditSyslogConfig) (Forwarder, error) {
+	return nil, errors.New("audit.syslog is not supported on this platform")
+}
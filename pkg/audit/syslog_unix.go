@@ -0,0 +1,51 @@
+//go:build !windows && !plan9
+
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+	"mini-backup/pkg/utils"
+)
+
+// DefaultSyslogTag est le tag des messages sans `tag`.
+const DefaultSyslogTag = "mini-backup-audit"
+
+var syslogFacilities = map[string]syslog.Priority{
+	"auth": syslog.LOG_AUTH, "authpriv": syslog.LOG_AUTHPRIV, "daemon": syslog.LOG_DAEMON, "user": syslog.LOG_USER,
+	"local0": syslog.LOG_LOCAL0, "local1": syslog.LOG_LOCAL1, "local2": syslog.LOG_LOCAL2, "local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4, "local5": syslog.LOG_LOCAL5, "local6": syslog.LOG_LOCAL6, "local7": syslog.LOG_LOCAL7,
+}
+
+type syslogForwarder struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogForwarder se connecte au serveur syslog de la configuration. Chaque entrée est
+// envoyée en JSON, en notice (warning pour les actions refusées ou en échec).
+func NewSyslogForwarder(config utils.AuditSyslogConfig) (Forwarder, error) {
+	facility, ok := syslogFacilities[config.Facility]
+	if !ok {
+		facility = syslog.LOG_AUTH
+	}
+	tag := config.Tag
+	if tag == "" {
+		tag = DefaultSyslogTag
+	}
+	writer, err := syslog.Dial(config.Network, config.Address, facility|syslog.LOG_NOTICE, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogForwarder{writer: writer}, nil
+}
+
+func (f *syslogForwarder) Forward(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if entry.Result != ResultSuccess {
+		return f.writer.Warning(string(data))
+	}
+	return f.writer.Notice(string(data))
+}
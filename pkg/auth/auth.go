@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Principal est l'identité authentifiée d'une requête.
+type Principal struct {
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+	// Method indique comment la requête a été authentifiée (api_key ou oidc).
+	Method string `json:"method"`
+	KeyID  string `json:"key_id,omitempty"`
+	// Subject est l'identifiant de l'utilisateur chez le fournisseur OIDC.
+	Subject string `json:"subject,omitempty"`
+}
+
+var (
+	defaultMu    sync.Mutex
+	defaultStore *Store
+)
+
+// SetDefault définit le stockage des clés utilisé par le serveur.
+func SetDefault(store *Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = store
+}
+
+// Default retourne le stockage défini par SetDefault, ou nil.
+func Default() *Store {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return defaultStore
+}
+
+// ParseTTL convertit une durée de validité : durée Go (12h) ou nombre de jours (90d). Vide : sans expiration.
+func ParseTTL(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid expiration %q (expected e.g. 90d or 12h)", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid expiration %q (expected e.g. 90d or 12h)", value)
+	}
+	return ttl, nil
+}
@@ -0,0 +1,279 @@
+// Package auth gère les clés d'API et les rôles qui protègent les routes du serveur.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Role détermine les routes accessibles : chaque rôle inclut les droits des rôles inférieurs.
+type Role string
+
+const (
+	// RoleViewer consulte les backups, les jobs, l'historique et les métriques.
+	RoleViewer Role = "viewer"
+	// RoleOperator lance les backups et les restaurations et annule les jobs.
+	RoleOperator Role = "operator"
+	// RoleAdmin télécharge les backups, modifie la configuration et gère les clés.
+	RoleAdmin Role = "admin"
+)
+
+// Roles liste les rôles, du moins au plus privilégié.
+var Roles = []string{string(RoleViewer), string(RoleOperator), string(RoleAdmin)}
+
+// tokenPrefix permet de reconnaître une clé mini-backup (scanners de secrets, journaux).
+const tokenPrefix = "mbk_"
+
+var (
+	ErrInvalidKey  = errors.New("invalid API key")
+	ErrExpiredKey  = errors.New("API key expired")
+	ErrKeyNotFound = errors.New("API key not found")
+)
+
+// Valid indique si le rôle existe.
+func (r Role) Valid() bool {
+	return r.rank() > 0
+}
+
+// Allows indique si le rôle donne accès aux routes qui exigent required.
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && r.rank() >= required.rank()
+}
+
+func (r Role) rank() int {
+	for i, role := range Roles {
+		if string(r) == role {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Key est une clé d'API. Seule l'empreinte SHA-256 du jeton est conservée.
+type Key struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Role      Role       `json:"role"`
+	Hash      string     `json:"hash,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired indique si la clé a expiré.
+func (k Key) Expired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+// Public retourne la clé sans son empreinte, pour l'affichage.
+func (k Key) Public() Key {
+	k.Hash = ""
+	return k
+}
+
+// Store conserve les clés dans un fichier JSON (droits 0600). Le fichier est relu lorsqu'il
+// change, pour que les clés créées ou révoquées par la CLI s'appliquent sans redémarrage.
+type Store struct {
+	path    string
+	mu      sync.Mutex
+	keys    []Key
+	modTime time.Time
+	size    int64
+}
+
+// OpenStore ouvre le fichier de clés ; il est créé à la première clé.
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Path retourne le chemin du fichier de clés.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// reload relit le fichier s'il a changé depuis la dernière lecture.
+func (s *Store) reload() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.keys, s.modTime, s.size = nil, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var keys []Key
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return fmt.Errorf("invalid API keys file %s: %w", s.path, err)
+		}
+	}
+	s.keys, s.modTime, s.size = keys, info.ModTime(), info.Size()
+	return nil
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime, s.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
+
+// Create crée une clé et retourne le jeton, qui n'est affiché qu'une fois. Sans expiration
+// (ttl nul), la clé reste valide jusqu'à sa révocation.
+func (s *Store) Create(name string, role Role, ttl time.Duration) (Key, string, error) {
+	if name == "" {
+		return Key{}, "", fmt.Errorf("API key name is required")
+	}
+	if !role.Valid() {
+		return Key{}, "", fmt.Errorf("unsupported role %q (expected one of %s)", role, strings.Join(Roles, ", "))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return Key{}, "", err
+	}
+	for _, key := range s.keys {
+		if key.Name == name {
+			return Key{}, "", fmt.Errorf("an API key named %q already exists", name)
+		}
+	}
+	id, err := randomHex(6)
+	if err != nil {
+		return Key{}, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return Key{}, "", err
+	}
+	token := tokenPrefix + id + "_" + secret
+	key := Key{ID: id, Name: name, Role: role, Hash: hashToken(token), CreatedAt: time.Now().UTC()}
+	if ttl > 0 {
+		expires := key.CreatedAt.Add(ttl)
+		key.ExpiresAt = &expires
+	}
+	s.keys = append(s.keys, key)
+	if err := s.save(); err != nil {
+		s.keys = s.keys[:len(s.keys)-1]
+		return Key{}, "", err
+	}
+	return key.Public(), token, nil
+}
+
+// List retourne les clés (sans empreinte), triées par date de création.
+func (s *Store) List() ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	keys := make([]Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key.Public())
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// Revoke supprime la clé désignée par son identifiant ou son nom.
+func (s *Store) Revoke(ref string) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return Key{}, err
+	}
+	for i, key := range s.keys {
+		if key.ID == ref || key.Name == ref {
+			previous := s.keys
+			s.keys = append(append([]Key{}, s.keys[:i]...), s.keys[i+1:]...)
+			if err := s.save(); err != nil {
+				s.keys = previous
+				return Key{}, err
+			}
+			return key.Public(), nil
+		}
+	}
+	return Key{}, fmt.Errorf("%w: %s", ErrKeyNotFound, ref)
+}
+
+// Authenticate retourne la clé correspondant au jeton.
+func (s *Store) Authenticate(token string) (Key, error) {
+	id, _, found := strings.Cut(strings.TrimPrefix(token, tokenPrefix), "_")
+	if !strings.HasPrefix(token, tokenPrefix) || !found {
+		return Key{}, ErrInvalidKey
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return Key{}, err
+	}
+	hash := hashToken(token)
+	for _, key := range s.keys {
+		if key.ID != id {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hash)) != 1 {
+			return Key{}, ErrInvalidKey
+		}
+		if key.Expired() {
+			return Key{}, ErrExpiredKey
+		}
+		return key.Public(), nil
+	}
+	return Key{}, ErrInvalidKey
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
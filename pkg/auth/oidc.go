@@ -0,0 +1,454 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mini-backup/pkg/utils"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoRole est retourné lorsqu'aucun groupe de l'utilisateur n'est associé à un rôle.
+var ErrNoRole = errors.New("no role is granted to this user")
+
+const (
+	// loginStateTTL est le délai accordé pour se connecter chez le fournisseur.
+	loginStateTTL = 10 * time.Minute
+	// clockSkew tolère un décalage d'horloge avec le fournisseur sur exp et iat.
+	clockSkew = time.Minute
+	// jwksRefreshInterval limite le rechargement des clés du fournisseur lorsqu'un kid est inconnu.
+	jwksRefreshInterval = time.Minute
+)
+
+// OIDC connecte les utilisateurs via un fournisseur OpenID Connect (authorization code avec
+// PKCE) et leur attribue un rôle selon leurs groupes. La découverte du fournisseur est faite
+// à la première connexion, pour que le serveur démarre même si le fournisseur est injoignable.
+type OIDC struct {
+	config      utils.OIDCConfig
+	roles       map[string]Role
+	defaultRole Role
+	signer      signer
+	client      *http.Client
+
+	mu          sync.Mutex
+	provider    *providerMetadata
+	keys        []signingKey
+	keysFetched time.Time
+}
+
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type signingKey struct {
+	kid string
+	key crypto.PublicKey
+}
+
+// loginState est conservé dans un cookie signé entre la redirection vers le fournisseur et le retour.
+type loginState struct {
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	Redirect  string `json:"redirect"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+const (
+	purposeLogin   = "oidc-login"
+	purposeSession = "oidc-session"
+)
+
+// NewOIDC prépare la connexion OIDC. sessionKey signe les cookies (voir LoadSessionKey).
+func NewOIDC(config utils.OIDCConfig, sessionKey []byte) (*OIDC, error) {
+	o := &OIDC{
+		config: config,
+		roles:  map[string]Role{},
+		signer: signer{key: sessionKey},
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for group, role := range config.Roles {
+		if !Role(role).Valid() {
+			return nil, fmt.Errorf("auth.oidc.roles.%s: unsupported role %q (expected one of %s)", group, role, strings.Join(Roles, ", "))
+		}
+		o.roles[group] = Role(role)
+	}
+	if config.DefaultRole != "" {
+		if !Role(config.DefaultRole).Valid() {
+			return nil, fmt.Errorf("auth.oidc.default_role: unsupported role %q (expected one of %s)", config.DefaultRole, strings.Join(Roles, ", "))
+		}
+		o.defaultRole = Role(config.DefaultRole)
+	}
+	return o, nil
+}
+
+// SecureCookies indique si les cookies doivent être limités à HTTPS (redirect_url en https).
+func (o *OIDC) SecureCookies() bool {
+	return strings.HasPrefix(o.config.RedirectURL, "https://")
+}
+
+// SessionTTL retourne la durée d'une session.
+func (o *OIDC) SessionTTL() time.Duration {
+	return o.config.SessionDuration()
+}
+
+// LoginRedirect retourne la page ouverte après la connexion sans redirection demandée.
+func (o *OIDC) LoginRedirect() string {
+	if o.config.LoginRedirect == "" {
+		return "/"
+	}
+	return o.config.LoginRedirect
+}
+
+// Login retourne l'URL d'autorisation du fournisseur et l'état de connexion signé, à conserver
+// dans un cookie jusqu'au retour sur Callback. redirect est la page ouverte après la connexion.
+func (o *OIDC) Login(ctx context.Context, redirect string) (string, string, error) {
+	provider, err := o.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	var state loginState
+	for _, value := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		if *value, err = randomHex(32); err != nil {
+			return "", "", err
+		}
+	}
+	state.Redirect = redirect
+	state.ExpiresAt = time.Now().Add(loginStateTTL).Unix()
+	sealed, err := o.signer.seal(purposeLogin, state)
+	if err != nil {
+		return "", "", err
+	}
+	challenge := sha256.Sum256([]byte(state.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {o.config.ClientID},
+		"redirect_uri":          {o.config.RedirectURL},
+		"scope":                 {strings.Join(o.config.ScopeList(), " ")},
+		"state":                 {state.State},
+		"nonce":                 {state.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return provider.AuthorizationEndpoint + separator + query.Encode(), sealed, nil
+}
+
+// Callback termine la connexion : il vérifie l'état, échange le code contre un jeton d'identité,
+// le vérifie et attribue le rôle. Il retourne la session, le cookie de session signé et la page
+// à ouvrir.
+func (o *OIDC) Callback(ctx context.Context, code, stateParam, sealedState string) (Session, string, string, error) {
+	var state loginState
+	if err := o.signer.open(purposeLogin, sealedState, &state); err != nil {
+		return Session{}, "", "", errors.New("login state is missing or invalid, start the login again")
+	}
+	if expired(state.ExpiresAt) {
+		return Session{}, "", "", errors.New("login took too long, start the login again")
+	}
+	if subtle.ConstantTimeCompare([]byte(state.State), []byte(stateParam)) != 1 {
+		return Session{}, "", "", errors.New("login state mismatch, start the login again")
+	}
+	if code == "" {
+		return Session{}, "", "", errors.New("authorization code is missing")
+	}
+	provider, err := o.discover(ctx)
+	if err != nil {
+		return Session{}, "", "", err
+	}
+	tokens, err := o.exchange(ctx, provider, code, state.Verifier)
+	if err != nil {
+		return Session{}, "", "", err
+	}
+	claims, err := o.verifyIDToken(ctx, provider, tokens.IDToken, state.Nonce)
+	if err != nil {
+		return Session{}, "", "", err
+	}
+	// Certains fournisseurs ne placent les groupes que dans la réponse userinfo
+	if claimValue(claims, o.config.GroupsClaimName()) == nil && provider.UserinfoEndpoint != "" && tokens.AccessToken != "" {
+		if userinfo, err := o.userinfo(ctx, provider, tokens.AccessToken); err == nil && claimString(userinfo, "sub") == claimString(claims, "sub") {
+			for name, value := range userinfo {
+				if _, exists := claims[name]; !exists {
+					claims[name] = value
+				}
+			}
+		}
+	}
+
+	session := Session{
+		Subject: claimString(claims, "sub"),
+		Email:   claimString(claims, "email"),
+		Groups:  claimStrings(claims, o.config.GroupsClaimName()),
+	}
+	for _, name := range []string{o.config.UsernameClaim, "preferred_username", "email", "sub"} {
+		if name != "" && session.Name == "" {
+			session.Name = claimString(claims, name)
+		}
+	}
+	session.Role = o.roleFor(session.Groups)
+	if session.Role == "" {
+		return session, "", "", fmt.Errorf("%w (user %s, groups: %s)", ErrNoRole, session.Name, strings.Join(session.Groups, ", "))
+	}
+	session.ExpiresAt = time.Now().Add(o.SessionTTL()).Unix()
+	sealed, err := o.signer.seal(purposeSession, session)
+	if err != nil {
+		return Session{}, "", "", err
+	}
+	return session, sealed, state.Redirect, nil
+}
+
+// Authenticate retourne la session contenue dans un cookie de session.
+func (o *OIDC) Authenticate(cookie string) (Session, error) {
+	var session Session
+	if err := o.signer.open(purposeSession, cookie, &session); err != nil {
+		return Session{}, err
+	}
+	if expired(session.ExpiresAt) {
+		return Session{}, ErrExpiredSession
+	}
+	if !session.Role.Valid() {
+		return Session{}, ErrInvalidSession
+	}
+	return session, nil
+}
+
+// roleFor retourne le rôle le plus élevé associé aux groupes, sinon default_role.
+func (o *OIDC) roleFor(groups []string) Role {
+	role := o.defaultRole
+	for _, group := range groups {
+		if mapped, ok := o.roles[group]; ok && mapped.rank() > role.rank() {
+			role = mapped
+		}
+	}
+	return role
+}
+
+// discover lit /.well-known/openid-configuration ; le résultat est conservé après un succès.
+// La requête est faite sans verrou : un fournisseur lent ne bloque pas les autres connexions.
+func (o *OIDC) discover(ctx context.Context) (*providerMetadata, error) {
+	o.mu.Lock()
+	cached := o.provider
+	o.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+	issuer := strings.TrimSuffix(o.config.Issuer, "/")
+	var provider providerMetadata
+	if err := o.getJSON(ctx, issuer+"/.well-known/openid-configuration", "", &provider); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("OIDC discovery failed: provider issuer %q does not match auth.oidc.issuer %q", provider.Issuer, o.config.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery failed: authorization, token or jwks endpoint is missing")
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.provider == nil {
+		o.provider = &provider
+	}
+	return o.provider, nil
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	AccessToken      string `json:"access_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchange échange le code d'autorisation contre les jetons (client_secret_basic si un secret est défini).
+func (o *OIDC) exchange(ctx context.Context, provider *providerMetadata, code, verifier string) (tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {o.config.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {o.config.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if o.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(o.config.ClientID), url.QueryEscape(o.config.ClientSecret))
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("OIDC token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	var tokens tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tokens); err != nil && resp.StatusCode < 300 {
+		return tokenResponse{}, fmt.Errorf("OIDC token request failed: invalid response: %w", err)
+	}
+	if resp.StatusCode >= 300 || tokens.Error != "" {
+		return tokenResponse{}, fmt.Errorf("OIDC token request failed: HTTP %d %s %s", resp.StatusCode, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return tokenResponse{}, errors.New("OIDC token response has no id_token (is the openid scope allowed?)")
+	}
+	return tokens, nil
+}
+
+// verifyIDToken vérifie la signature et les claims iss, aud, azp, exp, iat et nonce du jeton d'identité.
+func (o *OIDC) verifyIDToken(ctx context.Context, provider *providerMetadata, token, nonce string) (map[string]any, error) {
+	header, claims, signed, signature, err := parseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	key, err := o.signingKey(ctx, provider, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, signed, signature); err != nil {
+		return nil, fmt.Errorf("invalid ID token signature: %w", err)
+	}
+	if claimString(claims, "iss") != provider.Issuer {
+		return nil, fmt.Errorf("ID token issuer %q does not match %q", claimString(claims, "iss"), provider.Issuer)
+	}
+	audience := claimStrings(claims, "aud")
+	if !slices.Contains(audience, o.config.ClientID) {
+		return nil, fmt.Errorf("ID token audience %v does not include client %q", audience, o.config.ClientID)
+	}
+	if azp := claimString(claims, "azp"); azp != "" && azp != o.config.ClientID {
+		return nil, fmt.Errorf("ID token was issued to another client (%s)", azp)
+	}
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.Add(-clockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("ID token has expired")
+	}
+	if iat, ok := claims["iat"].(float64); ok && time.Unix(int64(iat), 0).After(now.Add(clockSkew)) {
+		return nil, errors.New("ID token is issued in the future, check the clocks")
+	}
+	if subtle.ConstantTimeCompare([]byte(claimString(claims, "nonce")), []byte(nonce)) != 1 {
+		return nil, errors.New("ID token nonce mismatch")
+	}
+	if claimString(claims, "sub") == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+	return claims, nil
+}
+
+// signingKey retourne la clé du fournisseur identifiée par kid. Les clés sont rechargées
+// lorsqu'un kid est inconnu, pour suivre leur rotation. Le verrou n'est pas gardé pendant la
+// requête au fournisseur, pour ne pas bloquer les autres connexions.
+func (o *OIDC) signingKey(ctx context.Context, provider *providerMetadata, kid string) (crypto.PublicKey, error) {
+	o.mu.Lock()
+	if key := findSigningKey(o.keys, kid); key != nil {
+		o.mu.Unlock()
+		return key, nil
+	}
+	previous := o.keysFetched
+	if time.Since(previous) < jwksRefreshInterval {
+		o.mu.Unlock()
+		return nil, fmt.Errorf("unknown ID token signing key %q", kid)
+	}
+	// Le rechargement est réservé avant la requête : un seul à la fois par jwksRefreshInterval
+	o.keysFetched = time.Now()
+	o.mu.Unlock()
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := o.getJSON(ctx, provider.JWKSURI, "", &jwks); err != nil {
+		o.mu.Lock()
+		o.keysFetched = previous
+		o.mu.Unlock()
+		return nil, fmt.Errorf("failed to fetch the provider keys: %w", err)
+	}
+	var keys []signingKey
+	for _, key := range jwks.Keys {
+		if public := key.publicKey(); public != nil {
+			keys = append(keys, signingKey{kid: key.Kid, key: public})
+		}
+	}
+	o.mu.Lock()
+	o.keys = keys
+	o.mu.Unlock()
+	if key := findSigningKey(keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown ID token signing key %q", kid)
+}
+
+// findSigningKey retourne la clé identifiée par kid. Sans kid, le jeton n'est accepté que si le
+// fournisseur publie une seule clé.
+func findSigningKey(keys []signingKey, kid string) crypto.PublicKey {
+	for _, key := range keys {
+		if key.kid == kid && (kid != "" || len(keys) == 1) {
+			return key.key
+		}
+	}
+	return nil
+}
+
+// userinfo retourne les claims de l'endpoint userinfo.
+func (o *OIDC) userinfo(ctx context.Context, provider *providerMetadata, accessToken string) (map[string]any, error) {
+	var claims map[string]any
+	err := o.getJSON(ctx, provider.UserinfoEndpoint, accessToken, &claims)
+	return claims, err
+}
+
+func (o *OIDC) getJSON(ctx context.Context, target, bearer string, value any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("GET %s: HTTP %d", target, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(value); err != nil {
+		return fmt.Errorf("GET %s: invalid JSON: %w", target, err)
+	}
+	return nil
+}
+
+var (
+	defaultOIDCMu sync.Mutex
+	defaultOIDC   *OIDC
+)
+
+// SetDefaultOIDC définit la connexion OIDC utilisée par le serveur (nil : désactivée).
+func SetDefaultOIDC(o *OIDC) {
+	defaultOIDCMu.Lock()
+	defer defaultOIDCMu.Unlock()
+	defaultOIDC = o
+}
+
+// DefaultOIDC retourne la connexion définie par SetDefaultOIDC, ou nil.
+func DefaultOIDC() *OIDC {
+	defaultOIDCMu.Lock()
+	defer defaultOIDCMu.Unlock()
+	return defaultOIDC
+}
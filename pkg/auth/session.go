@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSession = errors.New("invalid session")
+	ErrExpiredSession = errors.New("session expired, log in again")
+)
+
+// Session est la session d'un utilisateur connecté par OIDC, conservée dans un cookie signé.
+// Le rôle est fixé à la connexion : un changement de groupe s'applique à la connexion suivante.
+type Session struct {
+	Subject   string   `json:"sub"`
+	Name      string   `json:"name"`
+	Email     string   `json:"email,omitempty"`
+	Groups    []string `json:"groups,omitempty"`
+	Role      Role     `json:"role"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// Principal retourne l'identité correspondant à la session.
+func (s Session) Principal() Principal {
+	return Principal{Name: s.Name, Role: s.Role, Method: "oidc", Subject: s.Subject}
+}
+
+// signer signe des valeurs JSON (base64url(json).base64url(hmac)). purpose est inclus dans la
+// signature pour qu'une valeur signée pour un usage (état de connexion) ne serve pas à un autre.
+type signer struct {
+	key []byte
+}
+
+func (s signer) seal(purpose string, value any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.mac(purpose, payload)), nil
+}
+
+func (s signer) open(purpose, sealed string, value any) error {
+	payload, signature, found := strings.Cut(sealed, ".")
+	if !found {
+		return ErrInvalidSession
+	}
+	expected, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, s.mac(purpose, payload)) {
+		return ErrInvalidSession
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ErrInvalidSession
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return ErrInvalidSession
+	}
+	return nil
+}
+
+func (s signer) mac(purpose, payload string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(purpose + "\x00" + payload))
+	return mac.Sum(nil)
+}
+
+// LoadSessionKey retourne la clé de signature des sessions : secret s'il est défini, sinon la
+// clé du fichier path, générée au premier démarrage (droits 0600) pour survivre aux redémarrages.
+func LoadSessionKey(secret, path string) ([]byte, error) {
+	if secret != "" {
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("auth.oidc.session_secret must be at least 32 characters long")
+		}
+		return []byte(secret), nil
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) < 32 {
+			return nil, fmt.Errorf("invalid session key file %s", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	key, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(key+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(key)
+}
+
+func expired(unix int64) bool {
+	return time.Now().Unix() >= unix
+}
@@ -1,17 +1,28 @@
 package backup
 
 import (
+	"context"
 	"fmt"
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/hooks"
+	"mini-backup/pkg/jobs"
 	"mini-backup/pkg/utils"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 var logger = utils.LoggerFunc()
 
-func backupProcess(path []string, config utils.Backup, backupName string, glacierMode bool) error {
+func backupProcess(ctx context.Context, path []string, config utils.Backup, backupName string, glacierMode bool) error {
+	logger := runLogger(ctx)
 	compressedPath := []string{}
+	failedUploads := []string{}
+	publish(ctx, backupName, config, events.Event{Type: events.BackupDumped, Paths: path})
 	for _, p := range path {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		var compressed string
 		if filepath.Ext(p) == ".gz" {
 			logger.Info(fmt.Sprintf("File %s is already compressed, skipping compression.", path))
@@ -19,6 +30,7 @@ func backupProcess(path []string, config utils.Backup, backupName string, glacie
 			compressedPath = append(compressedPath, p)
 		} else {
 			// Compresser le fichier
+			jobs.SetStep(ctx, "compress")
 			cp, err := utils.Compress(p)
 			if err != nil {
 				logger.Error(fmt.Sprintf("Failed to compress %s: %v", path, err))
@@ -28,8 +40,14 @@ func backupProcess(path []string, config utils.Backup, backupName string, glacie
 			compressed = cp
 			compressedPath = append(compressedPath, cp)
 		}
+		publish(ctx, backupName, config, events.Event{Type: events.BackupCompressed, Path: compressed, RawBytes: utils.PathSize(p), Bytes: utils.PathSize(compressed)})
 		encryptedPath := compressed + ".enc"
-		utils.EncryptFile(compressed, encryptedPath)
+		jobs.SetStep(ctx, "encrypt")
+		if err := utils.EncryptFile(compressed, encryptedPath); err != nil {
+			logger.Error(fmt.Sprintf("Failed to encrypt %s: %v", compressed, err))
+			return err
+		}
+		publish(ctx, backupName, config, events.Event{Type: events.BackupEncrypted, Path: encryptedPath, Bytes: utils.PathSize(encryptedPath)})
 		logger.Info(fmt.Sprintf("Successfully compressed %s", p))
 		logger.Debug(fmt.Sprintf("Compressed paths: %v", compressedPath))
 		configServer, err := utils.GetConfigServer()
@@ -38,25 +56,43 @@ func backupProcess(path []string, config utils.Backup, backupName string, glacie
 			return err
 		}
 		for name, configServer := range configServer.RStorage {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			jobs.SetStep(ctx, "upload:"+name)
 			s3client, err := utils.RstorageManager(name, &configServer)
 			if err != nil {
 				logger.Error(fmt.Sprintf("Failed to get storage manager: %v", err))
+				failedUploads = append(failedUploads, fmt.Sprintf("%s (%v)", name, err))
+				publish(ctx, backupName, config, events.Event{Type: events.BackupUploadFailed, Storage: name, Error: err.Error()})
 				continue
 			}
-			s3client.ManageRetention(filepath.Join(config.Path.S3, filepath.Base(encryptedPath)), config.Retention.Standard.Days, glacierMode)
+			if deleted, err := s3client.ManageRetention(filepath.Join(config.Path.S3, filepath.Base(encryptedPath)), config.Retention.Standard.Days, glacierMode); err == nil {
+				publish(ctx, backupName, config, events.Event{Type: events.RetentionApplied, Storage: name, Deleted: deleted})
+			}
 			s3FilePath := filepath.Join(config.Path.S3, filepath.Base(encryptedPath))
-			err = s3client.Upload(encryptedPath, s3FilePath, glacierMode)
+			err = s3client.UploadContext(ctx, encryptedPath, s3FilePath, glacierMode)
 			if err != nil {
 				logger.Error(fmt.Sprintf("Failed to upload %s to %s: %v", encryptedPath, configServer.BucketName, err))
+				failedUploads = append(failedUploads, fmt.Sprintf("%s (%v)", name, err))
+				publish(ctx, backupName, config, events.Event{Type: events.BackupUploadFailed, Storage: name, Bucket: configServer.BucketName, Key: s3FilePath, Error: err.Error()})
 				continue
 			}
+			artifact := jobs.Artifact{Storage: name, Bucket: configServer.BucketName, Key: s3FilePath}
+			if info, err := os.Stat(encryptedPath); err == nil {
+				artifact.Size = info.Size()
+			}
+			jobs.AddArtifact(ctx, artifact)
+			publish(ctx, backupName, config, events.Event{Type: events.BackupUploaded, Storage: name, Bucket: artifact.Bucket, Key: artifact.Key, Bytes: artifact.Size})
 			logger.Info(fmt.Sprintf("Successfully uploaded %s to %s", encryptedPath, configServer.BucketName))
 		}
 		deleteFile(p)
 		deleteFile(compressed)
 		deleteFile(encryptedPath)
 	}
-	fmt.Println(compressedPath)
+	if len(failedUploads) > 0 {
+		return fmt.Errorf("upload failed for %s", strings.Join(failedUploads, ", "))
+	}
 	logger.Info(fmt.Sprintf("[TRACING] : Backup OK : %s ", backupName), "BACKUP PROCESS")
 	return nil
 }
@@ -69,82 +105,119 @@ func deleteFile(path string) error {
 	return nil
 }
 
+// CoreBackup exécute le backup name puis envoie le résultat vers les stockages distants.
+// L'erreur retournée reflète l'issue complète du backup, envoi compris.
 func CoreBackup(name string, glacierMode bool) error {
+	return CoreBackupContext(context.Background(), name, glacierMode)
+}
+
+// CoreBackupContext exécute le backup comme CoreBackup. L'annulation du contexte interrompt
+// les outils externes (mysqldump, mongodump, sqlite3) et les étapes suivantes.
+func CoreBackupContext(ctx context.Context, name string, glacierMode bool) (err error) {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting backup for: %s", name))
-	config, err := utils.GetConfig()
+	config, err := utils.GetActiveConfig()
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to load config: %v", err))
 		return err
 	}
+	if _, exists := config.Backups[name]; !exists {
+		return fmt.Errorf("%w: %s", utils.ErrBackupNotFound, name)
+	}
+
+	// Les hooks post_backup et on_failure s'exécutent selon l'issue, panique comprise
+	backupHooks := config.Backups[name].Hooks
+	hookRun := hooks.NewRun(ctx, jobs.KindBackup, name, config.Backups[name].Type, "")
+	defer func() {
+		err = hooks.Finish(ctx, backupHooks, utils.HookPostBackup, hookRun, err)
+	}()
 
 	defer func() {
 		if r := recover(); r != nil {
 			logger.Error(fmt.Sprintf("Panic occurred during backup for %s: %v", name, r))
+			err = fmt.Errorf("panic during backup for %s: %v", name, r)
 		}
 	}()
 
+	if err := hooks.Start(ctx, backupHooks, utils.HookPreBackup, hookRun); err != nil {
+		return err
+	}
+
+	jobs.SetStep(ctx, "dump")
 	switch config.Backups[name].Type {
 	case "mysql":
 		logger.Info(fmt.Sprintf("Detected MySQL backup for %s", name))
-		result, err := BackupMySQL(name, config.Backups[name])
+		result, err := BackupMySQL(ctx, name, config.Backups[name])
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to backup MySQL for %s: %v", name, err))
 			return err
 		}
 		logger.Info(fmt.Sprintf("Successfully backed up MySQL for %s: %v", name, result))
-		backupProcess(result, config.Backups[name], name, glacierMode)
+		if err := backupProcess(ctx, result, config.Backups[name], name, glacierMode); err != nil {
+			return err
+		}
 		return nil
 	case "folder":
 		logger.Info(fmt.Sprintf("Detected folder backup for %s", name))
-		result, err := CopyFolder(name, config.Backups[name])
+		result, err := CopyFolder(ctx, name, config.Backups[name])
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to backup folder for %s: %v", name, err))
 			return err
 		}
 		logger.Debug(fmt.Sprintln("Resultat de la copie de dossier:", result))
-		backupProcess(result, config.Backups[name], name, glacierMode)
+		if err := backupProcess(ctx, result, config.Backups[name], name, glacierMode); err != nil {
+			return err
+		}
 		logger.Info(fmt.Sprintf("Successfully backed up folder for %s", name))
 		return nil
 	case "s3":
 		logger.Info(fmt.Sprintf("Detected S3 backup for %s", name))
-		result, err := BackupRemoteS3(name, config.Backups[name])
+		result, err := BackupRemoteS3(ctx, name, config.Backups[name])
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to backup S3 for %s: %v", name, err))
 			return err
 		}
-		backupProcess(result, config.Backups[name], name, glacierMode)
+		if err := backupProcess(ctx, result, config.Backups[name], name, glacierMode); err != nil {
+			return err
+		}
 		logger.Info(fmt.Sprintf("Successfully backed up S3 for %s: %v", name, result))
 		return nil
 	case "mongo":
 		logger.Info(fmt.Sprintf("Detected MongoDB backup for %s", name))
-		result, err := BackupMongoDB(name, config.Backups[name])
+		result, err := BackupMongoDB(ctx, name, config.Backups[name])
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to backup MongoDB for %s: %v", name, err))
 			return err
 		}
 		resultArray := []string{result}
-		backupProcess(resultArray, config.Backups[name], name, glacierMode)
+		if err := backupProcess(ctx, resultArray, config.Backups[name], name, glacierMode); err != nil {
+			return err
+		}
 		logger.Info(fmt.Sprintf("Successfully backed up MongoDB for %s: %v", name, result))
 		return nil
 	case "sqlite":
 		logger.Info(fmt.Sprintf("Detected SQLite backup for %s", name))
-		result, err := BackupSqlite(name, config.Backups[name])
+		result, err := BackupSqlite(ctx, name, config.Backups[name])
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to backup SQLite for %s: %v", name, err))
 			return err
 		}
 		resultArray := []string{result}
-		backupProcess(resultArray, config.Backups[name], name, glacierMode)
+		if err := backupProcess(ctx, resultArray, config.Backups[name], name, glacierMode); err != nil {
+			return err
+		}
 		logger.Info(fmt.Sprintf("Successfully backed up SQLite for %s: %v", name, result))
 		return nil
 	case "kubernetes":
 		logger.Info(fmt.Sprintf("Detected Kubernetes backup for %s", name))
-		result, err := BackupKube(name, config.Backups[name])
+		result, err := BackupKube(ctx, name, config.Backups[name])
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to backup Kubernetes for %s: %v", name, err))
 			return err
 		}
-		backupProcess(result, config.Backups[name], name, glacierMode)
+		if err := backupProcess(ctx, result, config.Backups[name], name, glacierMode); err != nil {
+			return err
+		}
 		logger.Info(fmt.Sprintf("Successfully backed up Kubernetes for %s", name))
 		return nil
 	default:
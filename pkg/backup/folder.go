@@ -1,6 +1,7 @@
 package backup
 
 import (
+	"context"
 	"fmt"
 	"mini-backup/pkg/utils"
 	"os"
@@ -9,8 +10,8 @@ import (
 )
 
 // CopyFolder copie un ou plusieurs dossiers et leur contenu vers un dossier de destination.
-func CopyFolder(name string, config utils.Backup) ([]string, error) {
-	logger := utils.LoggerFunc()
+func CopyFolder(ctx context.Context, name string, config utils.Backup) ([]string, error) {
+	logger := utils.LoggerFunc().ForJob(ctx)
 
 	paths := config.Folder
 	destination := config.Path.Local
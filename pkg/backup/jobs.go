@@ -0,0 +1,61 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/utils"
+	"strconv"
+)
+
+// SubmitBackup enregistre le backup dans le gestionnaire de jobs et l'exécute en arrière-plan.
+func SubmitBackup(name string, glacierMode bool, trigger string) jobs.Job {
+	job := jobs.Default.Submit(backupSpec(name, glacierMode, trigger), func(ctx context.Context) error {
+		return CoreBackupContext(ctx, name, glacierMode)
+	})
+	if job.State == jobs.StateSkipped {
+		logger.Info(fmt.Sprintf("Backup job %s for %s (%s) %s", job.ID, name, trigger, job.Error))
+		return job
+	}
+	logger.Info(fmt.Sprintf("Backup job %s submitted for %s (%s)", job.ID, name, trigger))
+	return job
+}
+
+// RunBackup exécute le backup comme un job et attend sa fin.
+func RunBackup(name string, glacierMode bool, trigger string) (jobs.Job, error) {
+	job := SubmitBackup(name, glacierMode, trigger)
+	return jobs.Default.Wait(job.ID)
+}
+
+// runLogger retourne le logger du package lié au job du contexte (champs de corrélation et journal de l'exécution).
+func runLogger(ctx context.Context) *utils.Logger {
+	return logger.ForJob(ctx)
+}
+
+// publish publie une étape du backup sur le bus d'événements.
+func publish(ctx context.Context, name string, config utils.Backup, event events.Event) {
+	event.Kind, event.Job, event.BackupType = jobs.KindBackup, name, config.Type
+	events.PublishContext(ctx, event)
+}
+
+// backupSpec décrit le job d'un backup : politique de chevauchement et verrous d'hôte
+// selon la section concurrency de server.yaml.
+func backupSpec(name string, glacierMode bool, trigger string) jobs.Spec {
+	spec := jobs.Spec{
+		Kind:    jobs.KindBackup,
+		Name:    name,
+		Trigger: trigger,
+		Params:  map[string]string{"glacier": strconv.FormatBool(glacierMode)},
+	}
+	concurrency := utils.GetConcurrencyConfig()
+	spec.Overlap = concurrency.OverlapFor(utils.Backup{})
+	if config, err := utils.GetActiveConfig(); err == nil {
+		if backupConfig, exists := config.Backups[name]; exists {
+			spec.Overlap = concurrency.OverlapFor(backupConfig)
+			spec.Params["type"] = backupConfig.Type
+			spec.Locks = concurrency.Locks(backupConfig)
+		}
+	}
+	return spec
+}
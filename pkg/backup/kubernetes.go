@@ -14,15 +14,14 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-func BackupPVCData(name string, config utils.Backup, baseVolumesDir string) (string, error) {
-	logger := utils.LoggerFunc()
+func BackupPVCData(ctx context.Context, name string, config utils.Backup, baseVolumesDir string) (string, error) {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting PVC data backup for %s", name))
 
 	if config.Kubernetes == nil {
 		return "", fmt.Errorf("kubernetes configuration is missing")
 	}
 
-	ctx := context.TODO()
 	clientset, err := kubernetes.GetKubernetesClient(config.Kubernetes.KubeConfig)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to create Kubernetes client: %v", err))
@@ -87,8 +86,8 @@ func BackupPVCData(name string, config utils.Backup, baseVolumesDir string) (str
 	return baseVolumesDir, nil
 }
 
-func BackupKube(name string, config utils.Backup) ([]string, error) {
-	logger := utils.LoggerFunc()
+func BackupKube(ctx context.Context, name string, config utils.Backup) ([]string, error) {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting Kubernetes backup for %s", name))
 
 	baseBackupDir := filepath.Join(config.Path.Local, name+"-kubernetes-all-"+time.Now().Format("20060102_150405"))
@@ -103,7 +102,7 @@ func BackupKube(name string, config utils.Backup) ([]string, error) {
 	}
 
 	if config.Kubernetes.Volumes.Enabled {
-		if _, err := BackupPVCData(name, config, volumesBackupDir); err != nil {
+		if _, err := BackupPVCData(ctx, name, config, volumesBackupDir); err != nil {
 			logger.Error(fmt.Sprintf("Failed to backup PVC data: %v", err))
 			return nil, err
 		}
@@ -111,7 +110,7 @@ func BackupKube(name string, config utils.Backup) ([]string, error) {
 	}
 
 	if config.Kubernetes.Cluster.Backup == "auto" {
-		if _, err := BackupClusterState(name, config, clusterBackupDir); err != nil {
+		if _, err := BackupClusterState(ctx, name, config, clusterBackupDir); err != nil {
 			logger.Error(fmt.Sprintf("Failed to backup cluster state: %v", err))
 			return nil, err
 		}
@@ -122,8 +121,8 @@ func BackupKube(name string, config utils.Backup) ([]string, error) {
 	return []string{baseBackupDir}, nil
 }
 
-func BackupClusterState(name string, config utils.Backup, clusterBackupDir string) (string, error) {
-	logger := utils.LoggerFunc()
+func BackupClusterState(ctx context.Context, name string, config utils.Backup, clusterBackupDir string) (string, error) {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting cluster state backup for %s", name))
 
 	if config.Kubernetes == nil || config.Kubernetes.Cluster.Backup != "auto" {
@@ -131,7 +130,6 @@ func BackupClusterState(name string, config utils.Backup, clusterBackupDir strin
 	}
 
 	backupFile := filepath.Join(clusterBackupDir, "cluster-state.json")
-	ctx := context.TODO()
 	clientset, err := kubernetes.GetKubernetesClient(config.Kubernetes.KubeConfig)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to create Kubernetes client: %v", err))
@@ -1,7 +1,9 @@
 package backup
 
 import (
+	"context"
 	"fmt"
+	"mini-backup/pkg/jobs"
 	"mini-backup/pkg/utils"
 	"os"
 	"os/exec"
@@ -10,7 +12,8 @@ import (
 )
 
 // BackupMongoDB sauvegarde une ou toutes les bases de données MongoDB.
-func BackupMongoDB(name string, config utils.Backup) (string, error) {
+func BackupMongoDB(ctx context.Context, name string, config utils.Backup) (string, error) {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting MongoDB backup for: %s", name))
 
 	// Construire le chemin de sauvegarde local
@@ -36,8 +39,9 @@ func BackupMongoDB(name string, config utils.Backup) (string, error) {
 	}
 
 	// Exécuter la commande
-	cmd := exec.Command("mongodump", cmdArgs...)
+	cmd := exec.CommandContext(ctx, "mongodump", cmdArgs...)
 	output, err := cmd.CombinedOutput()
+	jobs.WriteToolOutput(ctx, "mongodump", output)
 	if err != nil {
 		logger.Error(fmt.Sprintf("mongodump failed: %s", string(output)))
 		return "", fmt.Errorf("mongodump failed: %w", err)
@@ -1,7 +1,9 @@
 package backup
 
 import (
+	"context"
 	"fmt"
+	"mini-backup/pkg/jobs"
 	"mini-backup/pkg/utils"
 	"os"
 	"os/exec"
@@ -10,7 +12,7 @@ import (
 )
 
 // BackupMySQL performs a MySQL dump using the mysqldump command-line tool.
-func BackupMySQL(name string, config utils.Backup) ([]string, error) {
+func BackupMySQL(ctx context.Context, name string, config utils.Backup) ([]string, error) {
 	if config.Mysql.Host == "" || config.Mysql.User == "" {
 		return []string{}, fmt.Errorf("invalid MySQL configuration: missing required fields (Host: %s, User: %s)", config.Mysql.Host, config.Mysql.User)
 	}
@@ -29,7 +31,7 @@ func BackupMySQL(name string, config utils.Backup) ([]string, error) {
 	if config.Mysql.All {
 		outputFile := filepath.Join(parentDir, fmt.Sprintf("%s-all_databases.sql", name))
 
-		result, err := dumpAllDatabases(name, config, outputFile)
+		result, err := dumpAllDatabases(ctx, name, config, outputFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to dump all databases: %w", err)
 		}
@@ -39,7 +41,7 @@ func BackupMySQL(name string, config utils.Backup) ([]string, error) {
 		for _, database := range config.Mysql.Databases {
 			outputFile := filepath.Join(parentDir, fmt.Sprintf("%s-%s.sql", name, database))
 
-			result, err := dumpFunc(name, config, database, outputFile)
+			result, err := dumpFunc(ctx, name, config, database, outputFile)
 			if err != nil {
 				fmt.Printf("Failed to dump database %s: %v\n", database, err)
 				continue
@@ -52,8 +54,8 @@ func BackupMySQL(name string, config utils.Backup) ([]string, error) {
 }
 
 // dumpAllDatabases executes mysqldump for all databases
-func dumpAllDatabases(name string, config utils.Backup, outputFile string) (string, error) {
-	cmd := exec.Command(
+func dumpAllDatabases(ctx context.Context, name string, config utils.Backup, outputFile string) (string, error) {
+	cmd := exec.CommandContext(ctx,
 		"mysqldump",
 		"-h", config.Mysql.Host,
 		"-P", config.Mysql.Port,
@@ -71,6 +73,7 @@ func dumpAllDatabases(name string, config utils.Backup, outputFile string) (stri
 	defer file.Close()
 
 	cmd.Stdout = file
+	cmd.Stderr = jobs.ToolWriter(ctx, "mysqldump")
 
 	// Exécuter la commande
 	if err := cmd.Run(); err != nil {
@@ -82,8 +85,8 @@ func dumpAllDatabases(name string, config utils.Backup, outputFile string) (stri
 }
 
 // dumpFunc executes mysqldump for a single database
-func dumpFunc(name string, config utils.Backup, database, outputFile string) (string, error) {
-	cmd := exec.Command(
+func dumpFunc(ctx context.Context, name string, config utils.Backup, database, outputFile string) (string, error) {
+	cmd := exec.CommandContext(ctx,
 		"mysqldump",
 		"-h", config.Mysql.Host,
 		"-P", config.Mysql.Port,
@@ -101,6 +104,7 @@ func dumpFunc(name string, config utils.Backup, database, outputFile string) (st
 	defer file.Close()
 
 	cmd.Stdout = file
+	cmd.Stderr = jobs.ToolWriter(ctx, "mysqldump")
 
 	// Exécuter la commande
 	if err := cmd.Run(); err != nil {
@@ -1,14 +1,17 @@
 package backup
 
 import (
+	"context"
 	"fmt"
+	"mini-backup/pkg/redact"
 	"mini-backup/pkg/utils"
 	"path/filepath"
 	"time"
 )
 
-func BackupRemoteS3(name string, config utils.Backup) ([]string, error) {
-	logger.Debug(fmt.Sprintf("Information de connexion S3 : %v", config.S3))
+func BackupRemoteS3(ctx context.Context, name string, config utils.Backup) ([]string, error) {
+	logger := runLogger(ctx)
+	logger.Debug(fmt.Sprintf("Information de connexion S3 : %+v", redact.Value(config.S3)))
 
 	// Création du fichier credentials AWS
 	err := utils.AwsCredentialFileCreateFunc(config.S3.ACCESS_KEY, config.S3.SECRET_KEY, name)
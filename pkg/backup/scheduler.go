@@ -0,0 +1,177 @@
+package backup
+
+import (
+	"fmt"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/utils"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReloadResult résume les changements appliqués au scheduler lors d'un rechargement.
+type ReloadResult struct {
+	Added     []string `json:"added"`
+	Changed   []string `json:"changed"`
+	Removed   []string `json:"removed"`
+	Unchanged []string `json:"unchanged"`
+}
+
+var (
+	schedulerMu     sync.Mutex
+	activeScheduler *utils.Scheduler
+	appliedJobs     = make(map[string]string)
+	// reloadMu sérialise les rechargements (watcher, API, CRUD, synchronisation git) : la configuration
+	// lue en premier ne doit pas être appliquée après une plus récente
+	reloadMu sync.Mutex
+)
+
+// StartScheduler applique la configuration initiale et démarre le scheduler.
+// Les backups sans planification sont exécutés immédiatement, comme au démarrage du serveur.
+func StartScheduler(config *utils.BackupConfig) (*utils.Scheduler, error) {
+	schedulerMu.Lock()
+	activeScheduler = utils.NewScheduler()
+	schedulerMu.Unlock()
+
+	if _, err := ApplyConfig(config); err != nil {
+		return nil, err
+	}
+	activeScheduler.Start()
+	return activeScheduler, nil
+}
+
+// ReloadConfig relit la configuration depuis le disque et l'applique au scheduler.
+// Si la nouvelle configuration est invalide, la configuration précédente reste active.
+func ReloadConfig() (*ReloadResult, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	config, err := utils.GetConfigStrict()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Configuration reload rejected: %v", err), utils.Bootstrap_server)
+		return nil, err
+	}
+	result, err := ApplyConfig(config)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Configuration reload rejected: %v", err), utils.Bootstrap_server)
+		return nil, err
+	}
+	logger.Info(fmt.Sprintf("Configuration reloaded: %d added, %d changed, %d removed", len(result.Added), len(result.Changed), len(result.Removed)), utils.Bootstrap_server)
+	return result, nil
+}
+
+// ApplyConfig compare la configuration avec celle déjà planifiée et reprogramme uniquement
+// les jobs ajoutés, modifiés ou supprimés. Le changement est atomique : en cas d'erreur,
+// ni le scheduler ni la configuration active ne sont modifiés.
+func ApplyConfig(config *utils.BackupConfig) (*ReloadResult, error) {
+	schedulerMu.Lock()
+	defer schedulerMu.Unlock()
+
+	if activeScheduler == nil {
+		return nil, fmt.Errorf("scheduler is not started")
+	}
+
+	result := &ReloadResult{}
+	fingerprints := make(map[string]string, len(config.Backups))
+	remove := []string{}
+	add := make(map[string][]utils.ScheduledJob)
+	runNow := []string{}
+
+	for name, backupConfig := range config.Backups {
+		fingerprint, err := jobFingerprint(backupConfig)
+		if err != nil {
+			return nil, fmt.Errorf("backup %s: %w", name, err)
+		}
+		fingerprints[name] = fingerprint
+
+		previous, exists := appliedJobs[name]
+		switch {
+		case !exists:
+			result.Added = append(result.Added, name)
+		case previous != fingerprint:
+			result.Changed = append(result.Changed, name)
+			remove = append(remove, name)
+		default:
+			result.Unchanged = append(result.Unchanged, name)
+			continue
+		}
+
+		entries := scheduledEntries(name, backupConfig)
+		if len(entries) > 0 {
+			add[name] = entries
+		}
+		if backupConfig.Schedule.Standard == "" && !exists {
+			runNow = append(runNow, name)
+		}
+	}
+	for name := range appliedJobs {
+		if _, exists := config.Backups[name]; !exists {
+			result.Removed = append(result.Removed, name)
+			remove = append(remove, name)
+		}
+	}
+
+	if err := activeScheduler.ReplaceJobs(remove, add); err != nil {
+		return nil, err
+	}
+	appliedJobs = fingerprints
+	utils.SetActiveConfig(config)
+
+	for _, name := range runNow {
+		logger.Info(fmt.Sprintf("No schedule found for %s, executing backup immediately", name), utils.Bootstrap_server)
+		SubmitBackup(name, false, jobs.TriggerStartup)
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Changed)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Unchanged)
+	return result, nil
+}
+
+// NextRuns retourne la prochaine exécution planifiée de chaque backup du scheduler.
+func NextRuns() map[string]time.Time {
+	schedulerMu.Lock()
+	scheduler := activeScheduler
+	schedulerMu.Unlock()
+	if scheduler == nil {
+		return map[string]time.Time{}
+	}
+	return scheduler.NextRuns()
+}
+
+// scheduledEntries construit les entrées cron (standard et glacier) d'un backup.
+func scheduledEntries(name string, backupConfig utils.Backup) []utils.ScheduledJob {
+	entries := []utils.ScheduledJob{}
+	if backupConfig.Schedule.Standard != "" {
+		logger.Info(fmt.Sprintf("Scheduling standard backup for %s: %s", name, backupConfig.Schedule.Standard), utils.Bootstrap_server)
+		entries = append(entries, utils.ScheduledJob{
+			Spec: backupConfig.Schedule.Standard,
+			Run: func() {
+				logger.Info(fmt.Sprintf("Executing standard backup for %s", name), utils.Bootstrap_server)
+				RunBackup(name, false, jobs.TriggerSchedule)
+			},
+		})
+	}
+	if backupConfig.Schedule.Glacier != "" {
+		logger.Info(fmt.Sprintf("Scheduling Glacier backup for %s: %s", name, backupConfig.Schedule.Glacier), utils.Bootstrap_server)
+		entries = append(entries, utils.ScheduledJob{
+			Spec: backupConfig.Schedule.Glacier,
+			Run: func() {
+				logger.Info(fmt.Sprintf("Executing Glacier backup for %s", name), utils.Bootstrap_server)
+				RunBackup(name, true, jobs.TriggerSchedule)
+			},
+		})
+	}
+	return entries
+}
+
+// jobFingerprint sérialise la définition d'un job pour détecter ses modifications.
+func jobFingerprint(backupConfig utils.Backup) (string, error) {
+	data, err := yaml.Marshal(backupConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize backup definition: %w", err)
+	}
+	return string(data), nil
+}
@@ -1,7 +1,9 @@
 package backup
 
 import (
+	"context"
 	"fmt"
+	"mini-backup/pkg/jobs"
 	"mini-backup/pkg/utils"
 	"os"
 	"os/exec"
@@ -10,7 +12,8 @@ import (
 	"time"
 )
 
-func BackupSqlite(name string, config utils.Backup) (string, error) {
+func BackupSqlite(ctx context.Context, name string, config utils.Backup) (string, error) {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting SQLite backup for: %s", name))
 
 	// Ouvrir le fichier de base en lecture seule
@@ -36,8 +39,9 @@ func BackupSqlite(name string, config utils.Backup) (string, error) {
 
 	// Construction de la commande sqlite3 pour réaliser la sauvegarde via la commande ".backup"
 	backupCmd := fmt.Sprintf(".backup '%s'", destinationPath)
-	cmd := exec.Command("sqlite3", dbPath, backupCmd)
+	cmd := exec.CommandContext(ctx, "sqlite3", dbPath, backupCmd)
 	output, err := cmd.CombinedOutput()
+	jobs.WriteToolOutput(ctx, "sqlite3", output)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Error executing sqlite3 backup command: %v, output: %s", err, string(output)))
 		return "", err
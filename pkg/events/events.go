@@ -0,0 +1,261 @@
+// Package events diffuse les étapes du cycle de vie des backups et restaurations (démarrage, dump,
+// compression, chiffrement, envoi par stockage, rétention, issue...) aux sous-systèmes abonnés :
+// historique, métriques, notifications, heartbeats, flux /api/events, ou code tiers utilisant
+// mini-backup comme bibliothèque. Les hooks sont exécutés dans le déroulement du backup, car un
+// hook pre_* doit pouvoir l'interrompre.
+package events
+
+import (
+	"context"
+	"fmt"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/utils"
+	"sync"
+	"time"
+)
+
+// Type identifie un événement ; le préfixe indique son origine (job, backup ou restore).
+type Type string
+
+// Événements des jobs, publiés pour chaque changement d'état (voir Attach).
+const (
+	JobQueued    Type = "job.queued"
+	JobStarted   Type = "job.started"
+	JobSucceeded Type = "job.succeeded"
+	JobFailed    Type = "job.failed"
+	JobCancelled Type = "job.cancelled"
+	JobSkipped   Type = "job.skipped"
+)
+
+// JobStates liste les types des changements d'état des jobs, pour s'y abonner (Run est renseigné).
+var JobStates = []Type{JobQueued, JobStarted, JobSucceeded, JobFailed, JobCancelled, JobSkipped}
+
+// Suivi en direct des jobs en cours (voir Attach).
+const (
+	// JobProgress : nouvelle étape, octets transférés ou avancement d'un transfert (Run.Progress).
+	JobProgress Type = "job.progress"
+	// JobLog : une ligne du journal de l'exécution (Line).
+	JobLog Type = "job.log"
+)
+
+// Étapes d'un backup.
+const (
+	// BackupDumped : l'export est terminé (Paths : fichiers ou dossiers produits).
+	BackupDumped Type = "backup.dumped"
+	// BackupCompressed : un fichier est compressé (Path, RawBytes avant et Bytes après compression).
+	BackupCompressed Type = "backup.compressed"
+	// BackupEncrypted : un fichier est chiffré (Path, Bytes).
+	BackupEncrypted Type = "backup.encrypted"
+	// BackupUploaded : un fichier est envoyé vers un stockage (Storage, Bucket, Key, Bytes).
+	BackupUploaded Type = "backup.uploaded"
+	// BackupUploadFailed : l'envoi vers un stockage a échoué (Storage, Error).
+	BackupUploadFailed Type = "backup.upload_failed"
+	// RetentionApplied : la rétention d'un stockage est appliquée (Storage, Deleted).
+	RetentionApplied Type = "backup.retention_applied"
+)
+
+// Étapes d'une restauration.
+const (
+	// RestoreDownloaded : le fichier est téléchargé (Storage, Key, Path, Bytes).
+	RestoreDownloaded Type = "restore.downloaded"
+	// RestoreDecrypted : le fichier est déchiffré (Path).
+	RestoreDecrypted Type = "restore.decrypted"
+	// RestoreDecompressed : le fichier est décompressé (Path).
+	RestoreDecompressed Type = "restore.decompressed"
+)
+
+// Event décrit une étape. Seuls les champs utiles à son type sont renseignés.
+type Event struct {
+	Type       Type      `json:"type"`
+	Time       time.Time `json:"time"`
+	RunID      string    `json:"run_id,omitempty"`
+	Kind       string    `json:"kind,omitempty"`
+	Job        string    `json:"job,omitempty"`
+	BackupType string    `json:"backup_type,omitempty"`
+	Storage    string    `json:"storage,omitempty"`
+	Bucket     string    `json:"bucket,omitempty"`
+	Key        string    `json:"key,omitempty"`
+	Path       string    `json:"path,omitempty"`
+	Paths      []string  `json:"paths,omitempty"`
+	RawBytes   int64     `json:"raw_bytes,omitempty"`
+	Bytes      int64     `json:"bytes,omitempty"`
+	Deleted    int       `json:"deleted,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Line       string    `json:"line,omitempty"`
+	// Run est l'état du job pour les événements job.* (sauf job.log).
+	Run *jobs.Job `json:"run,omitempty"`
+}
+
+// Handler reçoit les événements. Il est appelé de manière synchrone et ne doit pas bloquer.
+type Handler func(Event)
+
+type subscription struct {
+	id      int
+	handler Handler
+	types   map[Type]bool
+}
+
+// Bus distribue les événements publiés à ses abonnés.
+type Bus struct {
+	mu          sync.Mutex
+	next        int
+	subscribers []subscription
+}
+
+// Default est le bus utilisé par les packages backup et restore.
+var Default = NewBus()
+
+var logger = utils.LoggerFunc()
+
+// NewBus crée un bus sans abonné.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe abonne fn aux événements des types donnés (tous si aucun) et retourne
+// la fonction qui annule l'abonnement.
+func (b *Bus) Subscribe(fn Handler, types ...Type) func() {
+	sub := subscription{handler: fn}
+	if len(types) > 0 {
+		sub.types = map[Type]bool{}
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	b.mu.Lock()
+	sub.id = b.next
+	b.next++
+	b.subscribers = append(b.subscribers, sub)
+	b.mu.Unlock()
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, existing := range b.subscribers {
+			if existing.id == sub.id {
+				b.subscribers = append(b.subscribers[:i:i], b.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish transmet l'événement aux abonnés, dans l'ordre d'abonnement. La panique d'un abonné
+// est journalisée sans interrompre le backup ni les autres abonnés.
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.mu.Lock()
+	handlers := []Handler{}
+	for _, sub := range b.subscribers {
+		if sub.types == nil || sub.types[event.Type] {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mu.Unlock()
+	for _, handler := range handlers {
+		deliver(handler, event)
+	}
+}
+
+// PublishContext complète l'événement avec le job du contexte (identifiant, nature, nom et type
+// du backup) puis le publie.
+func (b *Bus) PublishContext(ctx context.Context, event Event) {
+	if job, ok := jobs.FromContext(ctx); ok {
+		if event.RunID == "" {
+			event.RunID = job.ID
+		}
+		if event.Kind == "" {
+			event.Kind = job.Kind
+		}
+		if event.Job == "" {
+			event.Job = job.Name
+		}
+		if event.BackupType == "" {
+			event.BackupType = job.Params["type"]
+		}
+	}
+	b.Publish(event)
+}
+
+// Subscribe abonne fn au bus par défaut.
+func Subscribe(fn Handler, types ...Type) func() {
+	return Default.Subscribe(fn, types...)
+}
+
+// Publish publie l'événement sur le bus par défaut.
+func Publish(event Event) {
+	Default.Publish(event)
+}
+
+// PublishContext publie l'événement sur le bus par défaut, complété par le job du contexte.
+func PublishContext(ctx context.Context, event Event) {
+	Default.PublishContext(ctx, event)
+}
+
+type attachment struct {
+	manager *jobs.Manager
+	bus     *Bus
+}
+
+var (
+	attachedMu sync.Mutex
+	attached   = map[attachment]bool{}
+)
+
+// Attach publie sur le bus un événement job.* à chaque changement d'état d'un job du gestionnaire,
+// ainsi que sa progression (job.progress) et les lignes de son journal (job.log). Un gestionnaire
+// n'est relié qu'une fois à un même bus : les appels suivants sont sans effet.
+func Attach(manager *jobs.Manager, bus *Bus) {
+	attachedMu.Lock()
+	defer attachedMu.Unlock()
+	if attached[attachment{manager, bus}] {
+		return
+	}
+	attached[attachment{manager, bus}] = true
+	manager.Subscribe(func(job jobs.Job) {
+		if event := StateEvent(job); event.Type != "" {
+			bus.Publish(event)
+		}
+	})
+	manager.Watch(func(job jobs.Job) {
+		event := StateEvent(job)
+		event.Type = JobProgress
+		bus.Publish(event)
+	})
+	manager.WatchLogs(func(job jobs.Job, line string) {
+		bus.Publish(Event{Type: JobLog, RunID: job.ID, Kind: job.Kind, Job: job.Name, BackupType: job.Params["type"], Line: line})
+	})
+}
+
+// StateEvent retourne l'événement job.* correspondant à l'état du job (Type vide pour un état inconnu).
+func StateEvent(job jobs.Job) Event {
+	return Event{
+		Type:       jobTypes[job.State],
+		RunID:      job.ID,
+		Kind:       job.Kind,
+		Job:        job.Name,
+		BackupType: job.Params["type"],
+		Bytes:      job.BytesTransferred,
+		Error:      job.Error,
+		Run:        &job,
+	}
+}
+
+var jobTypes = map[jobs.State]Type{
+	jobs.StateQueued:    JobQueued,
+	jobs.StateRunning:   JobStarted,
+	jobs.StateSucceeded: JobSucceeded,
+	jobs.StateFailed:    JobFailed,
+	jobs.StateCancelled: JobCancelled,
+	jobs.StateSkipped:   JobSkipped,
+}
+
+func deliver(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error(fmt.Sprintf("Event subscriber panicked on %s: %v", event.Type, r), "EVENTS")
+		}
+	}()
+	handler(event)
+}
@@ -0,0 +1,236 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// runsBucket associe l'identifiant de chaque exécution à son JSON.
+	runsBucket = []byte("runs")
+	// startedBucket indexe les exécutions par date de début : clé = début (8 octets big-endian) + identifiant.
+	startedBucket = []byte("started")
+)
+
+// openTimeout borne l'attente du verrou de la base, détenu par le processus qui l'a ouverte.
+const openTimeout = 5 * time.Second
+
+// BoltStore conserve l'historique dans une base bbolt. Les listes parcourent l'index par date de
+// début, des plus récentes aux plus anciennes, sans relire tout l'historique. La base n'est ouverte
+// que par un processus à la fois : lorsque le serveur tourne, la CLI l'interroge avec --server.
+type BoltStore struct {
+	db        *bolt.DB
+	retention time.Duration
+}
+
+// OpenBoltStore ouvre (ou crée) la base d'historique. Les exécutions terminées depuis plus de
+// retention sont retirées par Prune (aucune si retention vaut 0).
+func OpenBoltStore(path string, retention time.Duration) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0640, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, fmt.Errorf("history database %s is in use by another process (use --server to query a running server)", path)
+		}
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{runsBucket, startedBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize history database: %w", err)
+	}
+	return &BoltStore{db: db, retention: retention}, nil
+}
+
+// Recover marque « interrupted » les exécutions restées « running » lors d'un arrêt du processus.
+// Il est appelé par le serveur au démarrage, avant tout nouveau job.
+func (s *BoltStore) Recover() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(runsBucket)
+		now := time.Now()
+		interrupted := []Run{}
+		err := runs.ForEach(func(_, data []byte) error {
+			var run Run
+			if err := json.Unmarshal(data, &run); err != nil {
+				return err
+			}
+			if !run.Finished() {
+				run.Status = StatusInterrupted
+				run.Error = "interrupted: the process stopped before the run finished"
+				run.FinishedAt = &now
+				interrupted = append(interrupted, run)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Les écritures sont faites après le parcours, qu'elles invalideraient
+		for _, run := range interrupted {
+			if err := putRun(tx, run); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Prune retire les exécutions terminées depuis plus que la rétention et retourne leur nombre.
+func (s *BoltStore) Prune() (int, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	limit := time.Now().Add(-s.retention)
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		runs, started := tx.Bucket(runsBucket), tx.Bucket(startedBucket)
+		expired := []Run{}
+		cursor := started.Cursor()
+		// Une exécution démarrée après la limite ne peut pas être terminée avant
+		for key, _ := cursor.First(); key != nil && startedAt(key).Before(limit); key, _ = cursor.Next() {
+			run, err := decodeRun(runs.Get(key[8:]))
+			if err != nil {
+				return err
+			}
+			if run.Finished() && run.FinishedAt != nil && run.FinishedAt.Before(limit) {
+				expired = append(expired, run)
+			}
+		}
+		for _, run := range expired {
+			if err := started.Delete(startedKey(run)); err != nil {
+				return err
+			}
+			if err := runs.Delete([]byte(run.ID)); err != nil {
+				return err
+			}
+		}
+		removed = len(expired)
+		return nil
+	})
+	return removed, err
+}
+
+// Record ajoute ou met à jour une exécution.
+func (s *BoltStore) Record(run Run) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putRun(tx, run)
+	})
+}
+
+// Get retourne une exécution par identifiant.
+func (s *BoltStore) Get(id string) (Run, error) {
+	var run Run
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(runsBucket).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("%w: %s", ErrRunNotFound, id)
+		}
+		var err error
+		run, err = decodeRun(data)
+		return err
+	})
+	return run, err
+}
+
+// List retourne une page d'exécutions, des plus récentes aux plus anciennes. Sans filtre sur le
+// backup, la nature ou le statut, seules les exécutions de la page sont décodées.
+func (s *BoltStore) List(query Query) (Page, error) {
+	result := newPage(query)
+	start := (result.Page - 1) * result.PerPage
+	filtered := query.Backup != "" || query.Kind != "" || query.Status != ""
+	err := s.db.View(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(runsBucket)
+		cursor := tx.Bucket(startedBucket).Cursor()
+		for key, _ := cursor.Last(); key != nil; key, _ = cursor.Prev() {
+			if !query.Since.IsZero() && startedAt(key).Before(query.Since) {
+				break
+			}
+			inPage := result.Total >= start && result.Total < start+result.PerPage
+			if !filtered && !inPage {
+				result.Total++
+				continue
+			}
+			run, err := decodeRun(runs.Get(key[8:]))
+			if err != nil {
+				return err
+			}
+			if !query.matches(run) {
+				continue
+			}
+			if inPage {
+				result.Runs = append(result.Runs, run)
+			}
+			result.Total++
+		}
+		return nil
+	})
+	if err != nil {
+		return Page{}, err
+	}
+	result.Pages = (result.Total + result.PerPage - 1) / result.PerPage
+	return result, nil
+}
+
+// Close ferme la base et libère son verrou.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// putRun enregistre l'exécution et remplace son entrée d'index si sa date de début a changé.
+func putRun(tx *bolt.Tx, run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	runs, started := tx.Bucket(runsBucket), tx.Bucket(startedBucket)
+	if previous := runs.Get([]byte(run.ID)); previous != nil {
+		if old, err := decodeRun(previous); err == nil && !old.StartedAt.Equal(run.StartedAt) {
+			if err := started.Delete(startedKey(old)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := started.Put(startedKey(run), []byte(run.ID)); err != nil {
+		return err
+	}
+	return runs.Put([]byte(run.ID), data)
+}
+
+func decodeRun(data []byte) (Run, error) {
+	var run Run
+	if data == nil {
+		return run, errors.New("history index references a missing run")
+	}
+	if err := json.Unmarshal(data, &run); err != nil {
+		return run, fmt.Errorf("failed to decode run: %w", err)
+	}
+	return run, nil
+}
+
+// startedKey retourne la clé d'index de l'exécution, triée par date de début.
+func startedKey(run Run) []byte {
+	key := make([]byte, 8, 8+len(run.ID))
+	binary.BigEndian.PutUint64(key, uint64(run.StartedAt.UnixNano()))
+	return append(key, run.ID...)
+}
+
+// startedAt retourne la date de début encodée dans une clé d'index.
+func startedAt(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key[:8])))
+}
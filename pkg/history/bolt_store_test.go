@@ -0,0 +1,150 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, retention time.Duration) *BoltStore {
+	t.Helper()
+	store, err := OpenBoltStore(filepath.Join(t.TempDir(), "runs.db"), retention)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// testRun retourne une exécution terminée démarrée il y a ago.
+func testRun(id, backup, status string, ago time.Duration) Run {
+	started := time.Now().Add(-ago)
+	finished := started.Add(time.Minute)
+	return Run{ID: id, Kind: "backup", Backup: backup, Status: status, StartedAt: started, FinishedAt: &finished}
+}
+
+func ids(runs []Run) string {
+	result := ""
+	for i, run := range runs {
+		if i > 0 {
+			result += ","
+		}
+		result += run.ID
+	}
+	return result
+}
+
+func TestBoltStoreRecordAndGet(t *testing.T) {
+	store := openTestStore(t, 0)
+	run := testRun("a", "db", StatusRunning, time.Hour)
+	run.FinishedAt = nil
+	if err := store.Record(run); err != nil {
+		t.Fatal(err)
+	}
+	// La mise à jour d'une exécution remplace l'entrée, même si sa date de début change
+	run.StartedAt = run.StartedAt.Add(time.Second)
+	run.Status = StatusSucceeded
+	if err := store.Record(run); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get("a")
+	if err != nil || got.Status != StatusSucceeded || !got.StartedAt.Equal(run.StartedAt) {
+		t.Fatalf("got %+v (%v)", got, err)
+	}
+	if page, _ := store.List(Query{}); page.Total != 1 {
+		t.Fatalf("expected one run, got %+v", page)
+	}
+	if _, err := store.Get("missing"); !errors.Is(err, ErrRunNotFound) {
+		t.Fatalf("got %v, want ErrRunNotFound", err)
+	}
+}
+
+func TestBoltStoreList(t *testing.T) {
+	store := openTestStore(t, 0)
+	for i, run := range []Run{
+		testRun("1", "db", StatusSucceeded, 5*time.Hour),
+		testRun("2", "files", StatusFailed, 4*time.Hour),
+		testRun("3", "db", StatusFailed, 3*time.Hour),
+		testRun("4", "files", StatusSucceeded, 2*time.Hour),
+		testRun("5", "db", StatusSucceeded, time.Hour),
+	} {
+		if i == 2 {
+			run.Kind = "restore"
+		}
+		if err := store.Record(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		query Query
+		runs  string
+		total int
+		pages int
+	}{
+		{Query{}, "5,4,3,2,1", 5, 1},
+		{Query{PerPage: 2}, "5,4", 5, 3},
+		{Query{PerPage: 2, Page: 3}, "1", 5, 3},
+		{Query{PerPage: 2, Page: 4}, "", 5, 3},
+		{Query{Backup: "db"}, "5,3,1", 3, 1},
+		{Query{Backup: "db", PerPage: 1, Page: 2}, "3", 3, 3},
+		{Query{Status: StatusFailed}, "3,2", 2, 1},
+		{Query{Kind: "restore"}, "3", 1, 1},
+		{Query{Since: time.Now().Add(-150 * time.Minute)}, "5,4", 2, 1},
+		{Query{Backup: "files", Since: time.Now().Add(-270 * time.Minute)}, "4,2", 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt.query), func(t *testing.T) {
+			page, err := store.List(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids(page.Runs) != tt.runs || page.Total != tt.total || page.Pages != tt.pages {
+				t.Fatalf("got runs %q, total %d, pages %d; want %q, %d, %d", ids(page.Runs), page.Total, page.Pages, tt.runs, tt.total, tt.pages)
+			}
+		})
+	}
+}
+
+func TestBoltStoreRecover(t *testing.T) {
+	store := openTestStore(t, 0)
+	running := testRun("running", "db", StatusRunning, time.Minute)
+	running.FinishedAt = nil
+	for _, run := range []Run{running, testRun("done", "db", StatusSucceeded, time.Hour)} {
+		if err := store.Record(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if run, _ := store.Get("running"); run.Status != StatusInterrupted || run.FinishedAt == nil {
+		t.Fatalf("expected an interrupted run, got %+v", run)
+	}
+	if run, _ := store.Get("done"); run.Status != StatusSucceeded {
+		t.Fatalf("a finished run should be kept as is, got %+v", run)
+	}
+}
+
+func TestBoltStorePrune(t *testing.T) {
+	store := openTestStore(t, 24*time.Hour)
+	stale := testRun("stale", "db", StatusRunning, 48*time.Hour)
+	stale.FinishedAt = nil
+	for _, run := range []Run{
+		testRun("expired", "db", StatusSucceeded, 72*time.Hour),
+		stale,
+		testRun("recent", "db", StatusFailed, time.Hour),
+	} {
+		if err := store.Record(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	removed, err := store.Prune()
+	if err != nil || removed != 1 {
+		t.Fatalf("got %d removed (%v), want 1", removed, err)
+	}
+	if page, _ := store.List(Query{}); ids(page.Runs) != "recent,stale" {
+		t.Fatalf("got runs %q, want recent,stale", ids(page.Runs))
+	}
+}
@@ -0,0 +1,225 @@
+// Package history conserve l'historique des exécutions (backups et restaurations) : job, type,
+// début et fin, statut, erreur, fichiers envoyés, tailles et destinations.
+package history
+
+import (
+	"errors"
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/jobs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Statuts possibles d'une exécution. Les statuts finaux reprennent ceux des jobs.
+const (
+	StatusRunning     = "running"
+	StatusSucceeded   = string(jobs.StateSucceeded)
+	StatusFailed      = string(jobs.StateFailed)
+	StatusCancelled   = string(jobs.StateCancelled)
+	StatusSkipped     = string(jobs.StateSkipped)
+	StatusInterrupted = "interrupted"
+)
+
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 200
+)
+
+var ErrRunNotFound = errors.New("run not found")
+
+// Run est une exécution enregistrée dans l'historique. Son identifiant est celui du job.
+type Run struct {
+	ID           string            `json:"id"`
+	Kind         string            `json:"kind"`
+	Backup       string            `json:"backup"`
+	Type         string            `json:"type,omitempty"`
+	Trigger      string            `json:"trigger,omitempty"`
+	Status       string            `json:"status"`
+	Error        string            `json:"error,omitempty"`
+	StartedAt    time.Time         `json:"started_at"`
+	FinishedAt   *time.Time        `json:"finished_at,omitempty"`
+	DurationMs   int64             `json:"duration_ms"`
+	Bytes        int64             `json:"bytes"`
+	Artifacts    []jobs.Artifact   `json:"artifacts,omitempty"`
+	Destinations []string          `json:"destinations,omitempty"`
+	Params       map[string]string `json:"params,omitempty"`
+	// Log est le chemin du journal de l'exécution, relatif au répertoire des données.
+	Log string `json:"log,omitempty"`
+}
+
+// Finished indique si l'exécution est terminée.
+func (r Run) Finished() bool {
+	return r.Status != StatusRunning
+}
+
+// Query filtre et pagine la liste des exécutions (page à partir de 1, plus récentes d'abord).
+type Query struct {
+	Backup  string
+	Kind    string
+	Status  string
+	Since   time.Time
+	Page    int
+	PerPage int
+}
+
+// Page est une page de résultats.
+type Page struct {
+	Runs    []Run `json:"runs"`
+	Total   int   `json:"total"`
+	Page    int   `json:"page"`
+	PerPage int   `json:"per_page"`
+	Pages   int   `json:"pages"`
+}
+
+// Store est le stockage de l'historique.
+type Store interface {
+	Record(run Run) error
+	Get(id string) (Run, error)
+	List(query Query) (Page, error)
+	// Prune retire les exécutions expirées et retourne leur nombre.
+	Prune() (int, error)
+	Close() error
+}
+
+// FromJob convertit l'état d'un job en exécution. Un job pas encore démarré n'a pas d'exécution.
+func FromJob(job jobs.Job) (Run, bool) {
+	if job.State == jobs.StateQueued {
+		return Run{}, false
+	}
+	run := Run{
+		ID:        job.ID,
+		Kind:      job.Kind,
+		Backup:    job.Name,
+		Type:      job.Params["type"],
+		Trigger:   job.Trigger,
+		Status:    StatusRunning,
+		Error:     job.Error,
+		StartedAt: job.CreatedAt,
+		Bytes:     job.BytesTransferred,
+		Artifacts: job.Artifacts,
+		Params:    job.Params,
+	}
+	if job.StartedAt != nil {
+		run.StartedAt = *job.StartedAt
+	}
+	if job.State.Finished() {
+		run.Status = string(job.State)
+		run.FinishedAt = job.FinishedAt
+		if run.FinishedAt != nil {
+			run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+		}
+	}
+	seen := map[string]bool{}
+	for _, artifact := range job.Artifacts {
+		if !seen[artifact.Storage] {
+			seen[artifact.Storage] = true
+			run.Destinations = append(run.Destinations, artifact.Storage)
+		}
+	}
+	return run, true
+}
+
+var (
+	defaultMu    sync.Mutex
+	defaultStore Store
+)
+
+// DefaultPath retourne le chemin de la base d'historique dans le répertoire des données.
+func DefaultPath(dataDir string) string {
+	return filepath.Join(dataDir, "runs.db")
+}
+
+// Open ouvre l'historique par défaut et y enregistre les jobs du gestionnaire, ainsi que leur
+// journal dans le répertoire des données. Avec recoverRuns (serveur), les exécutions interrompues par un arrêt sont clôturées
+// et les exécutions terminées depuis plus de retention sont retirées. Les appels suivants retournent le même stockage.
+func Open(dataDir string, retention time.Duration, manager *jobs.Manager, recoverRuns bool) (Store, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultStore != nil {
+		return defaultStore, nil
+	}
+	store, err := OpenBoltStore(DefaultPath(dataDir), retention)
+	if err != nil {
+		return nil, err
+	}
+	if recoverRuns {
+		if err := store.Recover(); err != nil {
+			store.Close()
+			return nil, err
+		}
+		if _, err := store.Prune(); err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
+	Attach(store, manager, events.Default, dataDir)
+	defaultStore = store
+	return store, nil
+}
+
+// Default retourne l'historique ouvert par Open, ou nil.
+func Default() Store {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return defaultStore
+}
+
+// Attach enregistre dans le stockage chaque démarrage et chaque fin de job publiés sur le bus, auquel
+// le gestionnaire est relié (voir events.Attach). Avec un répertoire des données, le journal de
+// chaque job du gestionnaire y est aussi conservé.
+func Attach(store Store, manager *jobs.Manager, bus *events.Bus, dataDir string) {
+	if dataDir != "" {
+		manager.SetLogFactory(logFactory(dataDir))
+	}
+	events.Attach(manager, bus)
+	bus.Subscribe(func(event events.Event) {
+		run, ok := FromJob(*event.Run)
+		if !ok {
+			return
+		}
+		if dataDir != "" {
+			if _, err := os.Stat(LogPath(dataDir, run.ID)); err == nil {
+				run.Log = filepath.Join(filepath.Base(LogDir(dataDir)), run.ID+".log")
+			}
+		}
+		store.Record(run)
+	})
+}
+
+// RetainRuns retire les exécutions expirées une fois par jour (le serveur les retire déjà au
+// démarrage). La fonction ne rend pas la main : elle est lancée dans une goroutine par le serveur.
+func RetainRuns(store Store, onPrune func(removed int, err error)) {
+	for {
+		time.Sleep(24 * time.Hour)
+		removed, err := store.Prune()
+		if onPrune != nil {
+			onPrune(removed, err)
+		}
+	}
+}
+
+// matches indique si l'exécution passe les filtres de la requête.
+func (q Query) matches(run Run) bool {
+	return (q.Backup == "" || run.Backup == q.Backup) &&
+		(q.Kind == "" || run.Kind == q.Kind) &&
+		(q.Status == "" || run.Status == q.Status) &&
+		(q.Since.IsZero() || !run.StartedAt.Before(q.Since))
+}
+
+// newPage retourne une page vide avec la pagination normalisée de la requête.
+func newPage(query Query) Page {
+	perPage := query.PerPage
+	if perPage <= 0 {
+		perPage = DefaultPerPage
+	}
+	if perPage > MaxPerPage {
+		perPage = MaxPerPage
+	}
+	page := query.Page
+	if page <= 0 {
+		page = 1
+	}
+	return Page{Runs: []Run{}, Page: page, PerPage: perPage}
+}
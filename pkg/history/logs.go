@@ -0,0 +1,94 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mini-backup/pkg/jobs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrLogNotFound = errors.New("run log not found")
+
+// LogDir retourne le répertoire des journaux d'exécution dans le répertoire des données.
+func LogDir(dataDir string) string {
+	return filepath.Join(dataDir, "runs")
+}
+
+// LogPath retourne le chemin du journal d'une exécution.
+func LogPath(dataDir string, id string) string {
+	return filepath.Join(LogDir(dataDir), id+".log")
+}
+
+// logFactory crée le fichier journal de chaque job dans le répertoire des données.
+func logFactory(dataDir string) jobs.LogFactory {
+	return func(job jobs.Job) (io.WriteCloser, error) {
+		if err := os.MkdirAll(LogDir(dataDir), 0750); err != nil {
+			return nil, fmt.Errorf("failed to create run log directory: %w", err)
+		}
+		return os.OpenFile(LogPath(dataDir, job.ID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	}
+}
+
+// ReadLog retourne le journal d'une exécution, limité à ses tail dernières lignes si tail > 0.
+func ReadLog(dataDir string, id string, tail int) ([]byte, error) {
+	if id == "" || filepath.Base(id) != id || strings.HasPrefix(id, ".") {
+		return nil, fmt.Errorf("%w: %s", ErrLogNotFound, id)
+	}
+	data, err := os.ReadFile(LogPath(dataDir, id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrLogNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read run log: %w", err)
+	}
+	if tail > 0 {
+		lines := bytes.SplitAfter(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+		if len(lines) > tail {
+			data = append(bytes.Join(lines[len(lines)-tail:], nil), '\n')
+		}
+	}
+	return data, nil
+}
+
+// PruneLogs supprime les journaux d'exécution plus anciens que maxAge et retourne leur nombre.
+func PruneLogs(dataDir string, maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(LogDir(dataDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	limit := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".log" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(limit) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(LogDir(dataDir), entry.Name())); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// RetainLogs applique la rétention des journaux au démarrage puis une fois par jour.
+// La fonction ne rend pas la main : elle est lancée dans une goroutine par le serveur.
+func RetainLogs(dataDir string, maxAge time.Duration, onPrune func(removed int, err error)) {
+	for {
+		removed, err := PruneLogs(dataDir, maxAge)
+		if onPrune != nil {
+			onPrune(removed, err)
+		}
+		time.Sleep(24 * time.Hour)
+	}
+}
@@ -0,0 +1,180 @@
+// Package hooks exécute les hooks des jobs (commandes shell ou appels HTTP) avant et après
+// les backups et restaurations, et en cas d'échec.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/utils"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var logger = utils.LoggerFunc()
+
+// Run décrit l'exécution pour laquelle les hooks sont lancés. Les hooks shell la reçoivent
+// dans les variables MINIBACKUP_*, les hooks HTTP en JSON.
+type Run struct {
+	Stage     string   `json:"stage"`
+	Kind      string   `json:"kind"`
+	Job       string   `json:"job"`
+	Type      string   `json:"type,omitempty"`
+	RunID     string   `json:"run_id,omitempty"`
+	Trigger   string   `json:"trigger,omitempty"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error,omitempty"`
+	File      string   `json:"file,omitempty"`
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// NewRun décrit l'exécution du job du contexte. file est le fichier restauré (vide pour un backup).
+func NewRun(ctx context.Context, kind, name, backupType, file string) Run {
+	run := Run{Kind: kind, Job: name, Type: backupType, File: file}
+	if job, ok := jobs.FromContext(ctx); ok {
+		run.RunID, run.Trigger = job.ID, job.Trigger
+	}
+	return run
+}
+
+// Env retourne les variables d'environnement décrivant l'exécution.
+func (r Run) Env() []string {
+	env := []string{
+		"MINIBACKUP_HOOK=" + r.Stage,
+		"MINIBACKUP_KIND=" + r.Kind,
+		"MINIBACKUP_JOB=" + r.Job,
+		"MINIBACKUP_TYPE=" + r.Type,
+		"MINIBACKUP_RUN_ID=" + r.RunID,
+		"MINIBACKUP_TRIGGER=" + r.Trigger,
+		"MINIBACKUP_STATUS=" + r.Status,
+		"MINIBACKUP_ERROR=" + r.Error,
+		"MINIBACKUP_FILE=" + r.File,
+		"MINIBACKUP_ARTIFACTS=" + strings.Join(r.Artifacts, " "),
+		"MINIBACKUP_ARTIFACT=",
+	}
+	if len(r.Artifacts) > 0 {
+		env[len(env)-1] += r.Artifacts[0]
+	}
+	return env
+}
+
+// Execute exécute dans l'ordre les hooks de l'étape. Il s'arrête au premier hook en échec dont
+// la politique est abort et retourne son erreur ; les autres échecs sont seulement journalisés.
+func Execute(ctx context.Context, hooks *utils.Hooks, stage string, run Run) error {
+	list := hooks.Stage(stage)
+	if len(list) == 0 {
+		return nil
+	}
+	log := logger.ForJob(ctx)
+	jobs.SetStep(ctx, "hook:"+stage)
+	run.Stage = stage
+	if job, ok := jobs.FromContext(ctx); ok {
+		run.Artifacts = nil
+		for _, artifact := range job.Artifacts {
+			run.Artifacts = append(run.Artifacts, artifact.Key)
+		}
+	}
+	for i, hook := range list {
+		log.Info(fmt.Sprintf("Running %s hook %d: %s", stage, i+1, hook), "HOOK")
+		if err := execute(ctx, hook, run); err != nil {
+			err = fmt.Errorf("%s hook %d (%s) failed: %w", stage, i+1, hook, err)
+			if hook.Aborts(stage) {
+				log.Error(err.Error(), "HOOK")
+				return err
+			}
+			log.Warn(err.Error(), "HOOK")
+		}
+	}
+	return nil
+}
+
+// Finish exécute les hooks de fin : post (post_backup ou post_restore) si l'opération a réussi,
+// puis on_failure si elle a échoué, y compris à cause d'un hook post. Il retourne l'issue finale.
+func Finish(ctx context.Context, hooks *utils.Hooks, post string, run Run, err error) error {
+	if hooks == nil {
+		return err
+	}
+	if err == nil {
+		run.Status = string(jobs.StateSucceeded)
+		err = Execute(ctx, hooks, post, run)
+	}
+	if err != nil {
+		run.Status, run.Error = string(jobs.StateFailed), err.Error()
+		if ctx.Err() != nil {
+			run.Status = string(jobs.StateCancelled)
+		}
+		// Les hooks on_failure s'exécutent aussi après l'annulation du job
+		Execute(context.WithoutCancel(ctx), hooks, utils.HookOnFailure, run)
+	}
+	return err
+}
+
+// Start exécute les hooks pre (pre_backup ou pre_restore).
+func Start(ctx context.Context, hooks *utils.Hooks, pre string, run Run) error {
+	run.Status = string(jobs.StateRunning)
+	return Execute(ctx, hooks, pre, run)
+}
+
+func execute(ctx context.Context, hook utils.Hook, run Run) error {
+	timeout := hook.TimeoutDuration()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var err error
+	if hook.Command != "" {
+		err = runCommand(ctx, hook, run)
+	} else {
+		err = callURL(ctx, hook, run)
+	}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+func runCommand(ctx context.Context, hook utils.Hook, run Run) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Command)
+	cmd.Env = append(os.Environ(), run.Env()...)
+	killProcessGroup(cmd)
+	// Sans WaitDelay, un processus détaché qui garde la sortie ouverte bloquerait au-delà du timeout
+	cmd.WaitDelay = 5 * time.Second
+	output, err := cmd.CombinedOutput()
+	jobs.WriteToolOutput(ctx, "hook", output)
+	return err
+}
+
+func callURL(ctx context.Context, hook utils.Hook, run Run) error {
+	method := hook.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), hook.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range hook.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	jobs.WriteToolOutput(ctx, "hook", message)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package hooks
+
+import "os/exec"
+
+// killProcessGroup est sans effet hors Unix : seul le shell est tué à l'expiration du délai.
+func killProcessGroup(cmd *exec.Cmd) {}
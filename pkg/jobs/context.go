@@ -0,0 +1,65 @@
+package jobs
+
+import "context"
+
+type contextKey struct{}
+
+// progress relie le contexte d'exécution au job qu'il représente.
+type progress struct {
+	manager *Manager
+	entry   *entry
+}
+
+func fromContext(ctx context.Context) *progress {
+	p, _ := ctx.Value(contextKey{}).(*progress)
+	return p
+}
+
+// SetStep indique l'étape en cours du job associé au contexte (sans effet hors d'un job).
+func SetStep(ctx context.Context, step string) {
+	if p := fromContext(ctx); p != nil {
+		p.manager.progressed(p.manager.update(p.entry, func(job *Job) {
+			job.Step = step
+			job.Steps = append(job.Steps, step)
+			job.Progress = nil
+		}))
+	}
+}
+
+// AddBytes ajoute n au nombre d'octets transférés par le job associé au contexte.
+func AddBytes(ctx context.Context, n int64) {
+	if p := fromContext(ctx); p != nil {
+		p.manager.progressed(p.manager.update(p.entry, func(job *Job) {
+			job.BytesTransferred += n
+		}))
+	}
+}
+
+// AddArtifact enregistre un fichier envoyé vers un stockage et ajoute sa taille aux octets transférés.
+func AddArtifact(ctx context.Context, artifact Artifact) {
+	if p := fromContext(ctx); p != nil {
+		p.manager.progressed(p.manager.update(p.entry, func(job *Job) {
+			job.Artifacts = append(job.Artifacts, artifact)
+			job.BytesTransferred += artifact.Size
+		}))
+	}
+}
+
+// FromContext retourne l'état du job associé au contexte.
+func FromContext(ctx context.Context) (Job, bool) {
+	p := fromContext(ctx)
+	if p == nil {
+		return Job{}, false
+	}
+	p.manager.mu.Lock()
+	defer p.manager.mu.Unlock()
+	return p.entry.job.clone(), true
+}
+
+// IDFromContext retourne l'identifiant du job associé au contexte, ou une chaîne vide.
+func IDFromContext(ctx context.Context) string {
+	if p := fromContext(ctx); p != nil {
+		return p.entry.job.ID
+	}
+	return ""
+}
@@ -0,0 +1,492 @@
+// Package jobs suit l'exécution des backups et des restaurations : chaque exécution reçoit
+// un identifiant, un état, une progression par étape et peut être annulée via son contexte.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mini-backup/pkg/redact"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State est l'état d'un job.
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StateSucceeded State = "succeeded"
+	StateFailed    State = "failed"
+	StateCancelled State = "cancelled"
+	// StateSkipped : le job n'a pas été exécuté car un job du même backup était déjà actif.
+	StateSkipped State = "skipped"
+)
+
+// Finished indique si le job est terminé (succès, échec, annulation ou saut).
+func (s State) Finished() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateCancelled || s == StateSkipped
+}
+
+const (
+	KindBackup  = "backup"
+	KindRestore = "restore"
+)
+
+// Origines possibles d'un job.
+const (
+	TriggerSchedule = "schedule"
+	TriggerStartup  = "startup"
+	TriggerAPI      = "api"
+	TriggerCLI      = "cli"
+)
+
+// Politiques appliquées lorsqu'un job démarre alors qu'un autre job du même backup est actif.
+const (
+	OverlapSkip  = "skip"  // le nouveau job est enregistré comme sauté
+	OverlapQueue = "queue" // le nouveau job attend la fin du job actif
+	OverlapAllow = "allow" // les deux jobs s'exécutent en parallèle
+)
+
+// IsValidOverlap indique si la politique de chevauchement est reconnue.
+func IsValidOverlap(policy string) bool {
+	return policy == OverlapSkip || policy == OverlapQueue || policy == OverlapAllow
+}
+
+// maxFinishedJobs limite le nombre de jobs terminés conservés en mémoire.
+const maxFinishedJobs = 500
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobFinished = errors.New("job is already finished")
+)
+
+// Spec décrit le job à exécuter.
+type Spec struct {
+	Kind    string            `json:"kind"`
+	Name    string            `json:"name"`
+	Trigger string            `json:"trigger"`
+	Params  map[string]string `json:"params,omitempty"`
+	// Overlap s'applique aux autres jobs actifs portant le même Name (backups et restaurations).
+	// Sans valeur, le job attend comme avec OverlapQueue.
+	Overlap string `json:"overlap,omitempty"`
+	// Locks sont les verrous supplémentaires à obtenir avant de démarrer (hôte d'une base...).
+	Locks []Lock `json:"-"`
+}
+
+// Lock est un verrou nommé. Un verrou exclusif n'a qu'un détenteur ; un verrou partagé
+// accepte Limit détenteurs (sans limite si Limit <= 0) tant qu'aucun job ne le détient en exclusif.
+type Lock struct {
+	Key    string
+	Shared bool
+	Limit  int
+}
+
+// Job est l'état observable d'un job. Les valeurs retournées par le Manager sont des copies.
+type Job struct {
+	ID string `json:"id"`
+	Spec
+	State            State      `json:"state"`
+	Step             string     `json:"step,omitempty"`
+	Steps            []string   `json:"steps"`
+	BytesTransferred int64      `json:"bytes_transferred"`
+	Artifacts        []Artifact `json:"artifacts,omitempty"`
+	Error            string     `json:"error,omitempty"`
+	// Progress est l'avancement de l'étape en cours lorsqu'elle transfère un volume connu.
+	Progress *Progress `json:"progress,omitempty"`
+	// Waiting indique ce qu'attend un job encore en file (slot global ou verrou).
+	Waiting    string     `json:"waiting,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Artifact est un fichier produit par un job et envoyé vers un stockage distant.
+type Artifact struct {
+	Storage string `json:"storage"`
+	Bucket  string `json:"bucket,omitempty"`
+	Key     string `json:"key"`
+	Size    int64  `json:"size"`
+}
+
+// Duration retourne la durée d'exécution du job (jusqu'à maintenant s'il est en cours).
+func (j Job) Duration() time.Duration {
+	if j.StartedAt == nil {
+		return 0
+	}
+	if j.FinishedAt == nil {
+		return time.Since(*j.StartedAt)
+	}
+	return j.FinishedAt.Sub(*j.StartedAt)
+}
+
+// RunFunc exécute le travail d'un job. Le contexte est annulé lorsque le job est annulé ;
+// il permet aussi de signaler la progression avec SetStep et AddBytes.
+type RunFunc func(ctx context.Context) error
+
+type entry struct {
+	job    Job
+	locks  []Lock
+	log    *runLog
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// lockState compte les détenteurs d'un verrou nommé.
+type lockState struct {
+	holders   int
+	exclusive bool
+}
+
+// Manager enregistre et exécute les jobs en respectant la limite globale de parallélisme
+// et les verrous des jobs.
+type Manager struct {
+	mu          sync.Mutex
+	subscribers []func(Job)
+	// watchers reçoivent la progression des jobs en cours, logWatchers les lignes de leurs journaux.
+	watchers    []func(Job)
+	logWatchers []func(Job, string)
+	jobs        map[string]*entry
+	locks       map[string]*lockState
+	running     int
+	maxParallel int
+	logFactory  LogFactory
+	// wake est fermé puis remplacé à chaque libération pour réveiller les jobs en attente.
+	wake chan struct{}
+}
+
+// NewManager crée un gestionnaire de jobs vide, sans limite de parallélisme.
+func NewManager() *Manager {
+	return &Manager{
+		jobs:  make(map[string]*entry),
+		locks: make(map[string]*lockState),
+		wake:  make(chan struct{}),
+	}
+}
+
+// Subscribe enregistre une fonction appelée à chaque changement d'état d'un job
+// (queued, running puis état final). Elle ne doit pas bloquer.
+func (m *Manager) Subscribe(fn func(Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// notify transmet l'état du job aux abonnés, hors du verrou du gestionnaire.
+func (m *Manager) notify(job Job) {
+	m.mu.Lock()
+	subscribers := append([]func(Job){}, m.subscribers...)
+	m.mu.Unlock()
+	for _, fn := range subscribers {
+		fn(job)
+	}
+}
+
+// SetMaxParallel limite le nombre de jobs exécutés simultanément (sans limite si n <= 0).
+func (m *Manager) SetMaxParallel(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxParallel = n
+	m.wakeLocked()
+}
+
+// Default est le gestionnaire utilisé par le serveur et la CLI.
+var Default = NewManager()
+
+// Submit enregistre un job et l'exécute en arrière-plan. Le job retourné est son état initial.
+// Avec OverlapSkip, le job est immédiatement terminé (StateSkipped) si un job du même nom est actif.
+func (m *Manager) Submit(spec Spec, run RunFunc) Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &entry{
+		job: Job{
+			ID:        newID(),
+			Spec:      spec,
+			State:     StateQueued,
+			Steps:     []string{},
+			CreatedAt: time.Now(),
+		},
+		locks:  spec.Locks,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	e.ctx = context.WithValue(ctx, contextKey{}, &progress{manager: m, entry: e})
+	// Le verrou du nom empêche une restauration et un backup du même job de s'exécuter ensemble
+	nameLock := Lock{Key: "job:" + spec.Name, Shared: spec.Overlap == OverlapAllow && spec.Kind != KindRestore}
+	e.locks = append([]Lock{nameLock}, e.locks...)
+
+	m.mu.Lock()
+	if spec.Overlap == OverlapSkip {
+		if active := m.activeLocked(spec.Name); active != nil {
+			now := time.Now()
+			e.job.State = StateSkipped
+			e.job.FinishedAt = &now
+			e.job.Error = fmt.Sprintf("skipped: %s job %s of %s is still %s", active.job.Kind, active.job.ID, spec.Name, active.job.State)
+			m.jobs[e.job.ID] = e
+			m.pruneLocked()
+			job := e.job.clone()
+			m.mu.Unlock()
+			cancel()
+			close(e.done)
+			m.notify(job)
+			return job
+		}
+	}
+	m.jobs[e.job.ID] = e
+	m.pruneLocked()
+	job := e.job.clone()
+	m.mu.Unlock()
+
+	m.notify(job)
+	go m.execute(e, run)
+	return job
+}
+
+// activeLocked retourne un job en attente ou en cours portant ce nom.
+func (m *Manager) activeLocked(name string) *entry {
+	for _, e := range m.jobs {
+		if e.job.Name == name && !e.job.State.Finished() {
+			return e
+		}
+	}
+	return nil
+}
+
+// Run enregistre un job, l'exécute et attend sa fin.
+func (m *Manager) Run(spec Spec, run RunFunc) (Job, error) {
+	job := m.Submit(spec, run)
+	return m.Wait(job.ID)
+}
+
+// Wait attend la fin du job et retourne son état final ; l'erreur est celle du job.
+func (m *Manager) Wait(id string) (Job, error) {
+	m.mu.Lock()
+	e, exists := m.jobs[id]
+	m.mu.Unlock()
+	if !exists {
+		return Job{}, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	<-e.done
+	// L'état final est lu sur l'entrée : elle a pu être retirée de m.jobs entre-temps (voir pruneLocked)
+	m.mu.Lock()
+	job := e.job.clone()
+	m.mu.Unlock()
+	if job.Error != "" {
+		return job, errors.New(job.Error)
+	}
+	return job, nil
+}
+
+// Get retourne l'état courant d'un job.
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, exists := m.jobs[id]
+	if !exists {
+		return Job{}, false
+	}
+	return e.job.clone(), true
+}
+
+// List retourne les jobs, du plus récent au plus ancien.
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, e := range m.jobs {
+		jobs = append(jobs, e.job.clone())
+	}
+	m.mu.Unlock()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+	return jobs
+}
+
+// Cancel annule un job en attente ou en cours. Le contexte du job est annulé, ce qui
+// interrompt les outils externes lancés avec exec.CommandContext.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, exists := m.jobs[id]
+	if !exists {
+		return Job{}, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	if e.job.State.Finished() {
+		return e.job.clone(), fmt.Errorf("%w: %s", ErrJobFinished, id)
+	}
+	e.cancel()
+	return e.job.clone(), nil
+}
+
+func (m *Manager) execute(e *entry, run RunFunc) {
+	defer close(e.done)
+	defer e.cancel()
+
+	var err error
+	if m.acquire(e) == nil {
+		m.openLog(e)
+		m.notify(m.update(e, func(job *Job) {
+			now := time.Now()
+			job.State = StateRunning
+			job.StartedAt = &now
+		}))
+		err = safeRun(e.ctx, run)
+		m.release(e)
+	}
+
+	final := m.update(e, func(job *Job) {
+		now := time.Now()
+		job.FinishedAt = &now
+		job.Step = ""
+		job.Progress = nil
+		switch {
+		case e.ctx.Err() != nil:
+			job.State = StateCancelled
+			job.Error = "cancelled"
+		case err != nil:
+			job.State = StateFailed
+			job.Error = redact.String(err.Error())
+		default:
+			job.State = StateSucceeded
+		}
+	})
+	m.closeLog(e, final)
+	m.notify(final)
+}
+
+// acquire attend un slot global et les verrous du job. Le job reste en file (queued)
+// pendant l'attente ; l'annulation de son contexte interrompt l'attente.
+func (m *Manager) acquire(e *entry) error {
+	for {
+		m.mu.Lock()
+		if err := e.ctx.Err(); err != nil {
+			e.job.Waiting = ""
+			m.mu.Unlock()
+			return err
+		}
+		reason := m.blockedLocked(e)
+		if reason == "" {
+			m.running++
+			for _, lock := range e.locks {
+				state := m.locks[lock.Key]
+				if state == nil {
+					state = &lockState{}
+					m.locks[lock.Key] = state
+				}
+				state.holders++
+				state.exclusive = !lock.Shared
+			}
+			e.job.Waiting = ""
+			m.mu.Unlock()
+			return nil
+		}
+		e.job.Waiting = reason
+		wake := m.wake
+		m.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-e.ctx.Done():
+		}
+	}
+}
+
+// blockedLocked retourne la raison pour laquelle le job ne peut pas démarrer, ou une chaîne vide.
+func (m *Manager) blockedLocked(e *entry) string {
+	if m.maxParallel > 0 && m.running >= m.maxParallel {
+		return fmt.Sprintf("max_parallel (%d jobs running)", m.running)
+	}
+	for _, lock := range e.locks {
+		state := m.locks[lock.Key]
+		if state == nil || state.holders == 0 {
+			continue
+		}
+		if !lock.Shared || state.exclusive || (lock.Limit > 0 && state.holders >= lock.Limit) {
+			return "lock " + lock.Key
+		}
+	}
+	return ""
+}
+
+// release libère le slot et les verrous du job puis réveille les jobs en attente.
+func (m *Manager) release(e *entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running--
+	for _, lock := range e.locks {
+		if state := m.locks[lock.Key]; state != nil {
+			state.holders--
+			if state.holders <= 0 {
+				delete(m.locks, lock.Key)
+			}
+		}
+	}
+	m.wakeLocked()
+}
+
+func (m *Manager) wakeLocked() {
+	close(m.wake)
+	m.wake = make(chan struct{})
+}
+
+// safeRun exécute run en convertissant une panique en erreur.
+func safeRun(ctx context.Context, run RunFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+// update modifie le job sous verrou et retourne une copie de son nouvel état.
+func (m *Manager) update(e *entry, change func(job *Job)) Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	change(&e.job)
+	return e.job.clone()
+}
+
+// pruneLocked supprime les jobs terminés les plus anciens au-delà de maxFinishedJobs.
+func (m *Manager) pruneLocked() {
+	finished := []*entry{}
+	for _, e := range m.jobs {
+		if e.job.State.Finished() {
+			finished = append(finished, e)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].job.CreatedAt.Before(finished[j].job.CreatedAt) })
+	for _, e := range finished[:len(finished)-maxFinishedJobs] {
+		delete(m.jobs, e.job.ID)
+	}
+}
+
+func (j Job) clone() Job {
+	j.Steps = append([]string{}, j.Steps...)
+	j.Artifacts = append([]Artifact(nil), j.Artifacts...)
+	if j.Progress != nil {
+		progress := *j.Progress
+		j.Progress = &progress
+	}
+	if j.Params != nil {
+		params := make(map[string]string, len(j.Params))
+		for key, value := range j.Params {
+			params[key] = value
+		}
+		j.Params = params
+	}
+	return j
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,302 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blocker est un RunFunc qui signale son démarrage puis attend d'être libéré (ou annulé).
+type blocker struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlocker() *blocker {
+	return &blocker{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (b *blocker) run(ctx context.Context) error {
+	close(b.started)
+	select {
+	case <-b.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitStarted échoue si le job bloquant n'a pas démarré dans le délai.
+func (b *blocker) waitStarted(t *testing.T) {
+	t.Helper()
+	select {
+	case <-b.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not start")
+	}
+}
+
+// waitFor attend que la condition soit vraie.
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waiting retourne le motif d'attente du job s'il est encore en file.
+func waiting(m *Manager, id string) string {
+	job, _ := m.Get(id)
+	if job.State != StateQueued {
+		return ""
+	}
+	return job.Waiting
+}
+
+func TestManagerRunSucceeded(t *testing.T) {
+	m := NewManager()
+	var mu sync.Mutex
+	states := []State{}
+	m.Subscribe(func(job Job) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, job.State)
+	})
+
+	job, err := m.Run(Spec{Kind: KindBackup, Name: "db", Trigger: TriggerCLI}, func(ctx context.Context) error {
+		SetStep(ctx, "dump")
+		AddBytes(ctx, 10)
+		SetStep(ctx, "upload")
+		AddBytes(ctx, 5)
+		AddArtifact(ctx, Artifact{Storage: "s3", Key: "db/dump.sql.enc", Size: 5})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.State != StateSucceeded || job.StartedAt == nil || job.FinishedAt == nil {
+		t.Fatalf("unexpected final state: %+v", job)
+	}
+	if strings.Join(job.Steps, ",") != "dump,upload" || job.BytesTransferred != 20 || len(job.Artifacts) != 1 {
+		t.Fatalf("progress not recorded: steps %v, bytes %d, artifacts %v", job.Steps, job.BytesTransferred, job.Artifacts)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []State{StateQueued, StateRunning, StateSucceeded}; len(states) != len(want) || states[0] != want[0] || states[1] != want[1] || states[2] != want[2] {
+		t.Fatalf("got states %v, want %v", states, want)
+	}
+}
+
+func TestManagerRunFailed(t *testing.T) {
+	tests := []struct {
+		name  string
+		run   RunFunc
+		error string
+	}{
+		{"error", func(ctx context.Context) error { return errors.New("dump failed") }, "dump failed"},
+		{"panic", func(ctx context.Context) error { panic("boom") }, "panic: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := NewManager().Run(Spec{Kind: KindBackup, Name: "db"}, tt.run)
+			if err == nil || err.Error() != tt.error {
+				t.Fatalf("got error %v, want %q", err, tt.error)
+			}
+			if job.State != StateFailed || job.Error != tt.error {
+				t.Fatalf("unexpected final state: %+v", job)
+			}
+		})
+	}
+}
+
+func TestManagerCancel(t *testing.T) {
+	m := NewManager()
+	b := newBlocker()
+	job := m.Submit(Spec{Kind: KindBackup, Name: "db"}, b.run)
+	b.waitStarted(t)
+
+	if _, err := m.Cancel(job.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	final, err := m.Wait(job.ID)
+	if err == nil || final.State != StateCancelled {
+		t.Fatalf("expected a cancelled job, got %+v (%v)", final, err)
+	}
+	if _, err := m.Cancel(job.ID); !errors.Is(err, ErrJobFinished) {
+		t.Fatalf("cancelling a finished job: got %v, want ErrJobFinished", err)
+	}
+}
+
+func TestManagerUnknownJob(t *testing.T) {
+	m := NewManager()
+	if _, err := m.Wait("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("Wait: got %v, want ErrJobNotFound", err)
+	}
+	if _, err := m.Cancel("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("Cancel: got %v, want ErrJobNotFound", err)
+	}
+	if _, exists := m.Get("missing"); exists {
+		t.Fatal("Get: unexpected job")
+	}
+}
+
+func TestManagerPrunesFinishedJobs(t *testing.T) {
+	m := NewManager()
+	first, _ := m.Run(Spec{Kind: KindBackup, Name: "db"}, func(ctx context.Context) error { return nil })
+	for i := 0; i <= maxFinishedJobs; i++ {
+		m.Run(Spec{Kind: KindBackup, Name: "db"}, func(ctx context.Context) error { return nil })
+	}
+	// Les jobs sont purgés à chaque soumission : le dernier job terminé s'ajoute à la limite
+	if got := len(m.List()); got > maxFinishedJobs+1 {
+		t.Fatalf("got %d jobs, want at most %d", got, maxFinishedJobs+1)
+	}
+	if _, exists := m.Get(first.ID); exists {
+		t.Fatal("the oldest finished job should be pruned")
+	}
+}
+
+func TestManagerOverlap(t *testing.T) {
+	tests := []struct {
+		name       string
+		first      Spec
+		second     Spec
+		state      State  // état du second job pendant que le premier s'exécute
+		waitingFor string // motif d'attente attendu du second job
+	}{
+		{"skip", Spec{Kind: KindBackup, Name: "db"}, Spec{Kind: KindBackup, Name: "db", Overlap: OverlapSkip}, StateSkipped, ""},
+		{"queue", Spec{Kind: KindBackup, Name: "db"}, Spec{Kind: KindBackup, Name: "db", Overlap: OverlapQueue}, StateQueued, "lock job:db"},
+		{"default queues", Spec{Kind: KindBackup, Name: "db"}, Spec{Kind: KindBackup, Name: "db"}, StateQueued, "lock job:db"},
+		{"allow", Spec{Kind: KindBackup, Name: "db", Overlap: OverlapAllow}, Spec{Kind: KindBackup, Name: "db", Overlap: OverlapAllow}, StateRunning, ""},
+		{"allow excludes restores", Spec{Kind: KindBackup, Name: "db", Overlap: OverlapAllow}, Spec{Kind: KindRestore, Name: "db", Overlap: OverlapAllow}, StateQueued, "lock job:db"},
+		{"other backup", Spec{Kind: KindBackup, Name: "db"}, Spec{Kind: KindBackup, Name: "files", Overlap: OverlapSkip}, StateRunning, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			first, second := newBlocker(), newBlocker()
+			firstJob := m.Submit(tt.first, first.run)
+			first.waitStarted(t)
+			secondJob := m.Submit(tt.second, second.run)
+
+			switch tt.state {
+			case StateSkipped:
+				if secondJob.State != StateSkipped || !strings.HasPrefix(secondJob.Error, "skipped:") {
+					t.Fatalf("expected a skipped job, got %+v", secondJob)
+				}
+			case StateQueued:
+				waitFor(t, "the job to wait for "+tt.waitingFor, func() bool { return waiting(m, secondJob.ID) == tt.waitingFor })
+			case StateRunning:
+				second.waitStarted(t)
+			}
+
+			close(first.release)
+			if _, err := m.Wait(firstJob.ID); err != nil {
+				t.Fatalf("first job: %v", err)
+			}
+			if tt.state == StateSkipped {
+				return
+			}
+			close(second.release)
+			if job, err := m.Wait(secondJob.ID); err != nil || job.State != StateSucceeded {
+				t.Fatalf("second job: got %+v (%v)", job, err)
+			}
+		})
+	}
+}
+
+func TestManagerLocks(t *testing.T) {
+	exclusive := Lock{Key: "host:db"}
+	shared := Lock{Key: "host:db", Shared: true, Limit: 2}
+	tests := []struct {
+		name    string
+		locks   [][]Lock // verrous des jobs, soumis dans l'ordre
+		running int      // jobs exécutés simultanément, le dernier attendant s'il n'en fait pas partie
+	}{
+		{"exclusive", [][]Lock{{exclusive}, {exclusive}}, 1},
+		{"shared", [][]Lock{{shared}, {shared}}, 2},
+		{"shared limit", [][]Lock{{shared}, {shared}, {shared}}, 2},
+		{"shared after exclusive", [][]Lock{{exclusive}, {shared}}, 1},
+		{"exclusive after shared", [][]Lock{{shared}, {exclusive}}, 1},
+		{"other keys", [][]Lock{{exclusive}, {{Key: "host:other"}}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			blockers := []*blocker{}
+			ids := []string{}
+			for i, locks := range tt.locks {
+				b := newBlocker()
+				job := m.Submit(Spec{Kind: KindBackup, Name: fmt.Sprintf("job-%d", i), Locks: locks}, b.run)
+				if i < tt.running {
+					b.waitStarted(t)
+				}
+				blockers = append(blockers, b)
+				ids = append(ids, job.ID)
+			}
+			if tt.running < len(ids) {
+				last := ids[len(ids)-1]
+				waitFor(t, "the last job to wait for its lock", func() bool { return waiting(m, last) == "lock host:db" })
+			}
+			for i := range ids {
+				close(blockers[i].release)
+				if job, err := m.Wait(ids[i]); err != nil || job.State != StateSucceeded {
+					t.Fatalf("job %d: got %+v (%v)", i, job, err)
+				}
+			}
+		})
+	}
+}
+
+func TestManagerMaxParallel(t *testing.T) {
+	m := NewManager()
+	m.SetMaxParallel(1)
+	first, second := newBlocker(), newBlocker()
+	firstJob := m.Submit(Spec{Kind: KindBackup, Name: "db"}, first.run)
+	first.waitStarted(t)
+	secondJob := m.Submit(Spec{Kind: KindBackup, Name: "files"}, second.run)
+	waitFor(t, "the second job to wait for a slot", func() bool { return strings.HasPrefix(waiting(m, secondJob.ID), "max_parallel") })
+
+	// Relever la limite réveille les jobs en attente
+	m.SetMaxParallel(2)
+	second.waitStarted(t)
+	close(first.release)
+	close(second.release)
+	for _, id := range []string{firstJob.ID, secondJob.ID} {
+		if job, err := m.Wait(id); err != nil || job.State != StateSucceeded {
+			t.Fatalf("got %+v (%v)", job, err)
+		}
+	}
+}
+
+func TestManagerCancelQueued(t *testing.T) {
+	m := NewManager()
+	first := newBlocker()
+	firstJob := m.Submit(Spec{Kind: KindBackup, Name: "db"}, first.run)
+	first.waitStarted(t)
+	ran := false
+	queued := m.Submit(Spec{Kind: KindBackup, Name: "db"}, func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	waitFor(t, "the job to be queued", func() bool { return waiting(m, queued.ID) != "" })
+
+	if _, err := m.Cancel(queued.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job, _ := m.Wait(queued.ID); job.State != StateCancelled || job.StartedAt != nil {
+		t.Fatalf("expected a cancelled job that never started, got %+v", job)
+	}
+	close(first.release)
+	m.Wait(firstJob.ID)
+	if ran {
+		t.Fatal("a job cancelled while queued should not run")
+	}
+}
@@ -0,0 +1,187 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mini-backup/pkg/redact"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogFactory ouvre la destination du journal d'un job (un fichier par exécution).
+type LogFactory func(job Job) (io.WriteCloser, error)
+
+// SetLogFactory active la capture du journal de chaque job démarré ensuite.
+func (m *Manager) SetLogFactory(factory LogFactory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logFactory = factory
+}
+
+// maxPartialLine est la taille au-delà de laquelle une ligne sans fin (barre de progression d'un
+// outil...) est écrite sans attendre son retour à la ligne.
+const maxPartialLine = 64 * 1024
+
+// runLog sérialise les écritures dans le journal d'un job : le logger et les sorties
+// des outils externes y écrivent depuis plusieurs goroutines. Les secrets connus sont masqués
+// ligne par ligne, car un secret peut être coupé entre deux écritures d'un outil.
+// Chaque ligne complète est aussi transmise aux fonctions enregistrées par WatchLogs.
+type runLog struct {
+	mu      sync.Mutex
+	w       io.WriteCloser
+	closed  bool
+	manager *Manager
+	job     Job
+	// partial est le début d'une ligne pas encore terminée.
+	partial string
+}
+
+func (l *runLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return len(p), nil
+	}
+	lines := strings.Split(l.partial+string(p), "\n")
+	l.partial = lines[len(lines)-1]
+	lines = lines[:len(lines)-1]
+	for i, line := range lines {
+		lines[i] = redact.String(line)
+	}
+	text := ""
+	if len(lines) > 0 {
+		text = strings.Join(lines, "\n") + "\n"
+	}
+	if len(l.partial) > maxPartialLine {
+		line := redact.String(l.partial)
+		text += line
+		lines = append(lines, line)
+		l.partial = ""
+	}
+	if _, err := io.WriteString(l.w, text); err != nil {
+		l.mu.Unlock()
+		return 0, err
+	}
+	l.mu.Unlock()
+
+	for _, line := range lines {
+		l.manager.logged(l.job, line)
+	}
+	return len(p), nil
+}
+
+func (l *runLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	// Fin de sortie d'un outil sans retour à la ligne
+	if l.partial != "" {
+		io.WriteString(l.w, redact.String(l.partial)+"\n")
+		l.partial = ""
+	}
+	return l.w.Close()
+}
+
+// openLog ouvre le journal du job s'il existe une LogFactory ; une erreur désactive la capture.
+func (m *Manager) openLog(e *entry) {
+	m.mu.Lock()
+	factory := m.logFactory
+	job := e.job.clone()
+	m.mu.Unlock()
+	if factory == nil {
+		return
+	}
+	w, err := factory(job)
+	if err != nil {
+		return
+	}
+	log := &runLog{w: w, manager: m, job: job}
+	m.mu.Lock()
+	e.log = log
+	m.mu.Unlock()
+	fmt.Fprintf(log, "%s job %s: %s %s started (trigger: %s)\n", time.Now().Format(time.RFC3339), job.ID, job.Kind, job.Name, job.Trigger)
+}
+
+// closeLog écrit l'issue du job puis ferme son journal.
+func (m *Manager) closeLog(e *entry, job Job) {
+	m.mu.Lock()
+	log := e.log
+	m.mu.Unlock()
+	if log == nil {
+		return
+	}
+	if job.Error != "" {
+		fmt.Fprintf(log, "%s job %s: %s (%s)\n", time.Now().Format(time.RFC3339), job.ID, job.State, job.Error)
+	} else {
+		fmt.Fprintf(log, "%s job %s: %s\n", time.Now().Format(time.RFC3339), job.ID, job.State)
+	}
+	log.Close()
+}
+
+// LogWriter retourne le journal du job associé au contexte (io.Discard hors d'un job
+// ou sans capture des journaux).
+func LogWriter(ctx context.Context) io.Writer {
+	if p := fromContext(ctx); p != nil {
+		p.manager.mu.Lock()
+		log := p.entry.log
+		p.manager.mu.Unlock()
+		if log != nil {
+			return log
+		}
+	}
+	return io.Discard
+}
+
+// ToolWriter retourne un writer qui copie la sortie d'un outil externe (mysqldump, kubectl...)
+// dans le journal du job, chaque ligne étant préfixée par le nom de l'outil.
+func ToolWriter(ctx context.Context, tool string) io.Writer {
+	w := LogWriter(ctx)
+	if w == io.Discard {
+		return w
+	}
+	return &prefixWriter{w: w, prefix: []byte("[" + tool + "] "), lineStart: true}
+}
+
+// WriteToolOutput copie dans le journal du job la sortie complète d'un outil (CombinedOutput).
+func WriteToolOutput(ctx context.Context, tool string, output []byte) {
+	if len(output) == 0 {
+		return
+	}
+	w := ToolWriter(ctx, tool)
+	w.Write(output)
+	if output[len(output)-1] != '\n' {
+		w.Write([]byte("\n"))
+	}
+}
+
+type prefixWriter struct {
+	mu        sync.Mutex
+	w         io.Writer
+	prefix    []byte
+	lineStart bool
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]byte, 0, len(data)+len(p.prefix))
+	for _, b := range data {
+		if p.lineStart {
+			out = append(out, p.prefix...)
+			p.lineStart = false
+		}
+		out = append(out, b)
+		if b == '\n' {
+			p.lineStart = true
+		}
+	}
+	if _, err := p.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
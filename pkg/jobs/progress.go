@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"math"
+	"time"
+)
+
+// progressInterval limite la fréquence à laquelle un transfert signale son avancement.
+const progressInterval = 500 * time.Millisecond
+
+// Progress est l'avancement d'un transfert (envoi vers un stockage, téléchargement) de l'étape Step.
+type Progress struct {
+	Step  string `json:"step"`
+	Done  int64  `json:"done"`
+	Total int64  `json:"total"`
+	// Percent vaut 0 lorsque le total n'est pas connu.
+	Percent float64 `json:"percent"`
+	// BytesPerSecond est le débit moyen depuis le début du transfert.
+	BytesPerSecond float64   `json:"bytes_per_second"`
+	StartedAt      time.Time `json:"started_at"`
+}
+
+// Watch enregistre une fonction appelée à chaque progression d'un job en cours : nouvelle étape,
+// octets transférés, avancement d'un transfert. Elle ne doit pas bloquer.
+func (m *Manager) Watch(fn func(Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.watchers = append(m.watchers, fn)
+}
+
+// WatchLogs enregistre une fonction appelée pour chaque ligne écrite dans le journal d'un job
+// (voir SetLogFactory). Le job identifie l'exécution ; son état est celui du démarrage.
+func (m *Manager) WatchLogs(fn func(job Job, line string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logWatchers = append(m.logWatchers, fn)
+}
+
+// progressed transmet la progression du job aux fonctions enregistrées par Watch.
+func (m *Manager) progressed(job Job) {
+	m.mu.Lock()
+	watchers := append([]func(Job){}, m.watchers...)
+	m.mu.Unlock()
+	for _, fn := range watchers {
+		fn(job)
+	}
+}
+
+// logged transmet une ligne du journal d'un job aux fonctions enregistrées par WatchLogs.
+func (m *Manager) logged(job Job, line string) {
+	m.mu.Lock()
+	watchers := append([]func(Job, string){}, m.logWatchers...)
+	m.mu.Unlock()
+	for _, fn := range watchers {
+		fn(job, line)
+	}
+}
+
+// SetProgress indique l'avancement du transfert de l'étape en cours : done octets sur total
+// (0 si inconnu). Sans effet hors d'un job.
+func SetProgress(ctx context.Context, done, total int64) {
+	p := fromContext(ctx)
+	if p == nil {
+		return
+	}
+	p.manager.progressed(p.manager.update(p.entry, func(job *Job) {
+		if job.Progress == nil || job.Progress.Step != job.Step {
+			job.Progress = &Progress{Step: job.Step, StartedAt: time.Now()}
+		}
+		progress := job.Progress
+		progress.Done, progress.Total = done, total
+		if total > 0 {
+			progress.Percent = math.Round(float64(done)*1000/float64(total)) / 10
+		}
+		if elapsed := time.Since(progress.StartedAt).Seconds(); elapsed > 0 {
+			progress.BytesPerSecond = math.Round(float64(done) / elapsed)
+		}
+	}))
+}
+
+// ProgressReader retourne un reader qui signale avec SetProgress l'avancement de la lecture de r,
+// dont la taille est total. Si r implémente io.Seeker, le reader retourné aussi : le SDK S3 relit
+// le corps d'une requête pour la signer. Hors d'un job, r est retourné tel quel.
+func ProgressReader(ctx context.Context, r io.Reader, total int64) io.Reader {
+	if fromContext(ctx) == nil {
+		return r
+	}
+	reader := &progressReader{ctx: ctx, r: r, total: total}
+	if seeker, ok := r.(io.Seeker); ok {
+		return &progressReadSeeker{progressReader: reader, seeker: seeker}
+	}
+	return reader
+}
+
+type progressReader struct {
+	ctx      context.Context
+	r        io.Reader
+	done     int64
+	total    int64
+	reported time.Time
+	// last est la dernière valeur signalée : une lecture vide ne produit pas de nouvelle progression.
+	last int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if p.done != p.last && (err == io.EOF || p.done == p.total || time.Since(p.reported) >= progressInterval) {
+		p.reported, p.last = time.Now(), p.done
+		SetProgress(p.ctx, p.done, p.total)
+	}
+	return n, err
+}
+
+type progressReadSeeker struct {
+	*progressReader
+	seeker io.Seeker
+}
+
+func (p *progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	position, err := p.seeker.Seek(offset, whence)
+	if err == nil {
+		p.done = position
+	}
+	return position, err
+}
@@ -0,0 +1,100 @@
+// Package metrics expose l'activité des backups et restaurations au format texte de Prometheus
+// (route /metrics). Les labels communs sont job (nom du backup), type et storage.
+package metrics
+
+import (
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/history"
+	"mini-backup/pkg/jobs"
+	"sync"
+)
+
+var (
+	RunsTotal = NewCounter("minibackup_runs_total",
+		"Number of finished backup and restore runs by outcome.", "kind", "job", "type", "status")
+	LastSuccess = NewGauge("minibackup_last_success_timestamp_seconds",
+		"Unix time of the last successful run.", "kind", "job", "type")
+	RunDuration = NewHistogram("minibackup_run_duration_seconds",
+		"Duration of the finished runs.", []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200, 14400}, "kind", "job", "type")
+	ArtifactSize = NewGauge("minibackup_artifact_size_bytes",
+		"Size of the last backup artifacts before (raw) and after (compressed) compression.", "job", "type", "stage")
+	UploadedBytes = NewCounter("minibackup_uploaded_bytes_total",
+		"Bytes uploaded to each remote storage.", "job", "type", "storage")
+	RetentionDeletions = NewCounter("minibackup_retention_deletions_total",
+		"Remote objects deleted by the retention policy.", "job", "type", "storage")
+	NextRun = NewGauge("minibackup_scheduler_next_run_timestamp_seconds",
+		"Unix time of the next scheduled run of each backup.", "job", "type")
+)
+
+// Attach met à jour les métriques à la fin de chaque job et à chaque étape publiées sur le bus
+// d'événements.
+func Attach(bus *events.Bus) {
+	bus.Subscribe(artifactSizes(), events.BackupCompressed)
+	bus.Subscribe(func(event events.Event) {
+		RetentionDeletions.Add(float64(event.Deleted), event.Job, event.BackupType, event.Storage)
+	}, events.RetentionApplied)
+
+	bus.Subscribe(func(event events.Event) {
+		job := *event.Run
+		jobType := job.Params["type"]
+		RunsTotal.Inc(job.Kind, job.Name, jobType, string(job.State))
+		if job.State == jobs.StateSkipped {
+			return
+		}
+		if job.State == jobs.StateSucceeded && job.FinishedAt != nil {
+			LastSuccess.Set(float64(job.FinishedAt.Unix()), job.Kind, job.Name, jobType)
+		}
+		if job.StartedAt != nil {
+			RunDuration.Observe(job.Duration().Seconds(), job.Kind, job.Name, jobType)
+		}
+		if job.Kind == jobs.KindBackup {
+			for _, artifact := range job.Artifacts {
+				UploadedBytes.Add(float64(artifact.Size), job.Name, jobType, artifact.Storage)
+			}
+		}
+	}, events.JobSucceeded, events.JobFailed, events.JobCancelled, events.JobSkipped)
+}
+
+// artifactSizes additionne les tailles des fichiers compressés de l'exécution en cours de chaque backup.
+func artifactSizes() events.Handler {
+	type sizes struct {
+		run             string
+		raw, compressed int64
+	}
+	var mu sync.Mutex
+	current := map[string]*sizes{}
+	return func(event events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		total := current[event.Job]
+		if total == nil || total.run != event.RunID {
+			total = &sizes{run: event.RunID}
+			current[event.Job] = total
+		}
+		total.raw += event.RawBytes
+		total.compressed += event.Bytes
+		ArtifactSize.Set(float64(total.raw), event.Job, event.BackupType, "raw")
+		ArtifactSize.Set(float64(total.compressed), event.Job, event.BackupType, "compressed")
+	}
+}
+
+// LoadHistory initialise les dates de dernier succès depuis l'historique, pour qu'elles
+// survivent à un redémarrage du serveur.
+func LoadHistory(store history.Store) error {
+	for page := 1; ; page++ {
+		result, err := store.List(history.Query{Status: history.StatusSucceeded, Page: page, PerPage: history.MaxPerPage})
+		if err != nil {
+			return err
+		}
+		// Les exécutions sont triées des plus récentes aux plus anciennes : la première l'emporte
+		for _, run := range result.Runs {
+			if run.FinishedAt == nil || LastSuccess.has(run.Kind, run.Backup, run.Type) {
+				continue
+			}
+			LastSuccess.Set(float64(run.FinishedAt.Unix()), run.Kind, run.Backup, run.Type)
+		}
+		if page >= result.Pages {
+			return nil
+		}
+	}
+}
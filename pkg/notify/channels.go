@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mini-backup/pkg/utils"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Modèles par défaut du titre et du corps des messages.
+const (
+	DefaultTitleTemplate = `[mini-backup] {{.Kind}} {{.Job}}: {{.Event}}`
+	DefaultBodyTemplate  = `{{.Kind}} {{.Job}}{{if .Type}} ({{.Type}}){{end}} {{.Status}}{{if .Trigger}}, triggered by {{.Trigger}}{{end}}
+{{- if .Duration}}
+Duration: {{.Duration}}{{end}}
+{{- if .Bytes}}
+Size: {{.Size}}{{end}}
+{{- range .Storages}}
+Storage {{.Name}}: {{if .Uploaded}}uploaded {{.Key}} ({{.Size}}){{else}}not uploaded{{end}}{{end}}
+{{- if .Error}}
+Error: {{.Error}}{{end}}
+Run: {{.RunID}}{{if .Server}} on {{.Server}}{{end}}`
+)
+
+// discordMaxLength est la taille maximale du contenu d'un message Discord.
+const discordMaxLength = 2000
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// Send envoie l'événement vers un canal, avec jusqu'à trois tentatives.
+func Send(channel utils.NotificationChannel, templates utils.NotificationTemplates, event Event) error {
+	title, body, err := render(templates, event)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		err = deliver(channel, title, body, event)
+		if err == nil || attempt == 3 {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
+}
+
+func deliver(channel utils.NotificationChannel, title, body string, event Event) error {
+	switch channel.Type {
+	case "webhook":
+		return postJSON(channel, struct {
+			Event
+			Title string `json:"title"`
+			Text  string `json:"text"`
+		}{event, title, body})
+	case "slack":
+		return postJSON(channel, map[string]string{"text": "*" + title + "*\n" + body})
+	case "teams":
+		return postJSON(channel, map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  title,
+			"title":    title,
+			// Les cartes Teams interprètent le Markdown : deux espaces forcent le retour à la ligne
+			"text": strings.ReplaceAll(body, "\n", "  \n"),
+		})
+	case "discord":
+		content := "**" + title + "**\n" + body
+		if runes := []rune(content); len(runes) > discordMaxLength {
+			content = string(runes[:discordMaxLength-3]) + "..."
+		}
+		return postJSON(channel, map[string]string{"content": content})
+	case "email":
+		return sendMail(channel.SMTP, title, body)
+	}
+	return fmt.Errorf("unsupported channel type %q", channel.Type)
+}
+
+// render applique les modèles (ou ceux par défaut) à l'événement.
+func render(templates utils.NotificationTemplates, event Event) (string, string, error) {
+	titleTemplate, bodyTemplate := templates.Title, templates.Body
+	if titleTemplate == "" {
+		titleTemplate = DefaultTitleTemplate
+	}
+	if bodyTemplate == "" {
+		bodyTemplate = DefaultBodyTemplate
+	}
+	title, err := execute("title", titleTemplate, event)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := execute("body", bodyTemplate, event)
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(title), strings.TrimSpace(body), nil
+}
+
+func execute(name, text string, event Event) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+func postJSON(channel utils.NotificationChannel, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, channel.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range channel.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// sendMail envoie un email en texte brut : TLS implicite avec tls, sinon STARTTLS si proposé.
+func sendMail(config *utils.SMTPConfig, subject, body string) error {
+	port := config.Port
+	if port == 0 {
+		port = 587
+		if config.TLS {
+			port = 465
+		}
+	}
+	address := net.JoinHostPort(config.Host, strconv.Itoa(port))
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n",
+		config.From, strings.Join(config.To, ", "), mime.QEncoding.Encode("utf-8", strings.Join(strings.Fields(subject), " ")), time.Now().Format(time.RFC1123Z))
+	message.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+	if !config.TLS {
+		return smtp.SendMail(address, auth, config.From, config.To, message.Bytes())
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 15 * time.Second}, "tcp", address, &tls.Config{ServerName: config.Host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(config.From); err != nil {
+		return err
+	}
+	for _, to := range config.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message.Bytes()); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
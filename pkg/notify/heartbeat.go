@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AttachHeartbeats pingue les URLs heartbeat des backups à chaque démarrage, succès et échec,
+// pour qu'un moniteur externe alerte lorsqu'un backup ne tourne plus ou échoue. Les changements
+// d'état des jobs sont lus sur le bus d'événements.
+func AttachHeartbeats(bus *events.Bus) {
+	var mu sync.Mutex
+	// started associe chaque exécution en cours au ping de démarrage, que le ping final doit attendre
+	// pour que le moniteur les reçoive dans l'ordre
+	started := map[string]chan struct{}{}
+
+	bus.Subscribe(func(event events.Event) {
+		job := *event.Run
+		if job.Kind != jobs.KindBackup {
+			return
+		}
+		mu.Lock()
+		previous := started[job.ID]
+		done := make(chan struct{})
+		if job.State.Finished() {
+			delete(started, job.ID)
+		} else {
+			started[job.ID] = done
+		}
+		mu.Unlock()
+
+		var method, target, body string
+		if config, err := utils.GetActiveConfig(); err == nil {
+			if backupConfig, exists := config.Backups[job.Name]; exists && backupConfig.Heartbeat != nil {
+				method, target, body = heartbeatRequest(*backupConfig.Heartbeat, job)
+			}
+		}
+		go func() {
+			defer close(done)
+			if previous != nil {
+				<-previous
+			}
+			if target == "" {
+				return
+			}
+			if err := ping(method, target, body); err != nil {
+				logger.Error(fmt.Sprintf("Failed to ping heartbeat of backup %s (%s): %v", job.Name, job.State, err), "HEARTBEAT")
+			}
+		}()
+	})
+}
+
+// heartbeatRequest retourne la requête correspondant à l'état du job (URL vide si aucun ping).
+// Une exécution annulée est signalée comme un échec ; une exécution ignorée n'est pas signalée.
+func heartbeatRequest(heartbeat utils.Heartbeat, job jobs.Job) (string, string, string) {
+	var explicit, suffix, status string
+	switch job.State {
+	case jobs.StateRunning:
+		explicit, suffix, status = heartbeat.Start, "/start", "start"
+	case jobs.StateSucceeded:
+		explicit, suffix, status = heartbeat.Success, "", "up"
+	case jobs.StateFailed, jobs.StateCancelled:
+		explicit, suffix, status = heartbeat.Failure, "/fail", "down"
+	default:
+		return "", "", ""
+	}
+
+	message := fmt.Sprintf("%s %s %s", job.Kind, job.Name, job.State)
+	var duration time.Duration
+	if job.State.Finished() && job.StartedAt != nil {
+		duration = job.Duration().Round(time.Millisecond)
+		message += " in " + duration.String()
+	}
+	if job.Error != "" {
+		message += ": " + job.Error
+	}
+
+	if heartbeat.Style == "uptime-kuma" {
+		target := explicit
+		if target == "" {
+			// Uptime Kuma n'a pas de notion de démarrage : seule une URL start explicite est pinguée
+			if status == "start" || heartbeat.URL == "" {
+				return "", "", ""
+			}
+			target = heartbeat.URL
+		}
+		if status == "start" {
+			return http.MethodGet, target, ""
+		}
+		query := url.Values{"status": {status}, "msg": {message}}
+		if duration > 0 {
+			query.Set("ping", strconv.FormatInt(duration.Milliseconds(), 10))
+		}
+		return http.MethodGet, appendQuery(target, query), ""
+	}
+
+	target := explicit
+	if target == "" {
+		if heartbeat.URL == "" {
+			return "", "", ""
+		}
+		target = strings.TrimRight(heartbeat.URL, "/") + suffix
+	}
+	// Healthchecks.io conserve le corps du ping (jusqu'à 100 Ko) et l'affiche avec l'événement
+	body := fmt.Sprintf("status: %s\nrun_id: %s\n", job.State, job.ID)
+	if job.State.Finished() {
+		body += fmt.Sprintf("duration: %s\nduration_ms: %d\n", duration, duration.Milliseconds())
+	}
+	if job.Error != "" {
+		body += "error: " + job.Error + "\n"
+	}
+	return http.MethodPost, target, body
+}
+
+// appendQuery ajoute les paramètres à ceux déjà présents dans l'URL.
+func appendQuery(target string, query url.Values) string {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	values := parsed.Query()
+	for key, value := range query {
+		values[key] = value
+	}
+	parsed.RawQuery = values.Encode()
+	return parsed.String()
+}
+
+// ping envoie la requête, avec jusqu'à trois tentatives.
+func ping(method, target, body string) error {
+	for attempt := 1; ; attempt++ {
+		err := pingOnce(method, target, body)
+		if err == nil || attempt == 3 {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
+}
+
+func pingOnce(method, target, body string) error {
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+// Package notify envoie les issues des backups et restaurations vers les canaux configurés
+// dans la section notifications de server.yaml (webhook, email, Slack, Teams, Discord).
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/history"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/utils"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Événements de notification.
+const (
+	EventStarted   = "started"
+	EventSuccess   = "success"
+	EventFailure   = "failure"
+	EventRecovery  = "recovery"
+	EventCancelled = "cancelled"
+	EventSkipped   = "skipped"
+)
+
+var logger = utils.LoggerFunc()
+
+var ErrChannelNotFound = errors.New("notification channel not found")
+
+// Event est la donnée transmise aux modèles et envoyée telle quelle aux webhooks génériques.
+type Event struct {
+	Event      string          `json:"event"`
+	Kind       string          `json:"kind"`
+	Job        string          `json:"job"`
+	Type       string          `json:"type,omitempty"`
+	Status     string          `json:"status"`
+	Trigger    string          `json:"trigger,omitempty"`
+	RunID      string          `json:"run_id"`
+	Server     string          `json:"server,omitempty"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Duration   string          `json:"duration,omitempty"`
+	Bytes      int64           `json:"bytes"`
+	Size       string          `json:"size"`
+	Error      string          `json:"error,omitempty"`
+	Storages   []StorageResult `json:"storages,omitempty"`
+}
+
+// StorageResult indique si le backup a été envoyé vers un stockage.
+type StorageResult struct {
+	Name     string `json:"name"`
+	Uploaded bool   `json:"uploaded"`
+	Key      string `json:"key,omitempty"`
+	Bytes    int64  `json:"bytes,omitempty"`
+	Size     string `json:"size,omitempty"`
+}
+
+// Notifier transforme les changements d'état des jobs en événements et les envoie.
+type Notifier struct {
+	mu sync.Mutex
+	// failing retient les jobs (kind/nom) dont la dernière exécution a échoué, pour détecter les rétablissements
+	failing map[string]bool
+	send    func(channel utils.NotificationChannel, templates utils.NotificationTemplates, event Event) error
+}
+
+// Attach envoie les notifications des changements d'état des jobs publiés sur le bus. L'historique
+// (facultatif) indique les jobs en échec au démarrage, pour signaler leur rétablissement.
+func Attach(bus *events.Bus, store history.Store) *Notifier {
+	n := &Notifier{failing: map[string]bool{}, send: Send}
+	if store != nil {
+		n.loadHistory(store)
+	}
+	bus.Subscribe(func(event events.Event) {
+		n.handle(*event.Run)
+	}, events.JobStates...)
+	return n
+}
+
+func (n *Notifier) loadHistory(store history.Store) {
+	seen := map[string]bool{}
+	for page := 1; ; page++ {
+		result, err := store.List(history.Query{Page: page, PerPage: history.MaxPerPage})
+		if err != nil {
+			return
+		}
+		// Les exécutions sont triées des plus récentes aux plus anciennes : seule la dernière issue compte
+		for _, run := range result.Runs {
+			key := run.Kind + "/" + run.Backup
+			if !run.Finished() || run.Status == history.StatusSkipped || seen[key] {
+				continue
+			}
+			seen[key] = true
+			n.failing[key] = run.Status == history.StatusFailed || run.Status == history.StatusInterrupted
+		}
+		if page >= result.Pages {
+			return
+		}
+	}
+}
+
+// handle calcule l'événement d'un changement d'état et l'envoie selon les règles.
+func (n *Notifier) handle(job jobs.Job) {
+	event := n.eventFor(job)
+	if event == "" {
+		return
+	}
+	config := utils.GetNotificationsConfig()
+	if len(config.Rules) == 0 {
+		return
+	}
+	data := newEvent(event, job)
+	for name, channel := range config.Channels {
+		if !routed(config.Rules, name, data) {
+			continue
+		}
+		templates := channelTemplates(config, channel)
+		go func(name string, channel utils.NotificationChannel) {
+			if err := n.send(channel, templates, data); err != nil {
+				logger.Error(fmt.Sprintf("Failed to send %s notification for %s %s to %s: %v", data.Event, data.Kind, data.Job, name, err), "NOTIFY")
+			}
+		}(name, channel)
+	}
+}
+
+// SendTest envoie un message de test vers un canal configuré.
+func SendTest(name string) error {
+	config := utils.GetNotificationsConfig()
+	channel, exists := config.Channels[name]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrChannelNotFound, name)
+	}
+	templates := channelTemplates(config, channel)
+	now := time.Now()
+	event := Event{Event: "test", Kind: jobs.KindBackup, Job: "test", Status: "test", Trigger: jobs.TriggerAPI, RunID: "test", StartedAt: &now, FinishedAt: &now, Size: FormatBytes(0)}
+	event.Server, _ = os.Hostname()
+	return Send(channel, templates, event)
+}
+
+// channelTemplates retourne les modèles globaux, remplacés par ceux du canal s'il en définit.
+func channelTemplates(config utils.NotificationsConfig, channel utils.NotificationChannel) utils.NotificationTemplates {
+	templates := config.Templates
+	if channel.Templates.Title != "" {
+		templates.Title = channel.Templates.Title
+	}
+	if channel.Templates.Body != "" {
+		templates.Body = channel.Templates.Body
+	}
+	return templates
+}
+
+// eventFor retourne l'événement d'un changement d'état (vide si aucun) et met à jour les échecs connus.
+func (n *Notifier) eventFor(job jobs.Job) string {
+	key := job.Kind + "/" + job.Name
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch job.State {
+	case jobs.StateRunning:
+		return EventStarted
+	case jobs.StateSucceeded:
+		wasFailing := n.failing[key]
+		n.failing[key] = false
+		if wasFailing {
+			return EventRecovery
+		}
+		return EventSuccess
+	case jobs.StateFailed:
+		n.failing[key] = true
+		return EventFailure
+	case jobs.StateCancelled:
+		return EventCancelled
+	case jobs.StateSkipped:
+		return EventSkipped
+	}
+	return ""
+}
+
+// routed indique si une règle envoie l'événement vers le canal. Une règle « success »
+// reçoit aussi les rétablissements.
+func routed(rules []utils.NotificationRule, channel string, event Event) bool {
+	for _, rule := range rules {
+		if !contains(rule.Channels, channel) ||
+			(len(rule.Jobs) > 0 && !contains(rule.Jobs, event.Job)) ||
+			(len(rule.Kinds) > 0 && !contains(rule.Kinds, event.Kind)) {
+			continue
+		}
+		if contains(rule.Events, event.Event) || (event.Event == EventRecovery && contains(rule.Events, EventSuccess)) {
+			return true
+		}
+	}
+	return false
+}
+
+func newEvent(event string, job jobs.Job) Event {
+	data := Event{
+		Event:      event,
+		Kind:       job.Kind,
+		Job:        job.Name,
+		Type:       job.Params["type"],
+		Status:     string(job.State),
+		Trigger:    job.Trigger,
+		RunID:      job.ID,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+		Bytes:      job.BytesTransferred,
+		Size:       FormatBytes(job.BytesTransferred),
+		Error:      job.Error,
+	}
+	data.Server, _ = os.Hostname()
+	if job.State.Finished() && job.StartedAt != nil {
+		data.Duration = job.Duration().Round(time.Millisecond).String()
+	}
+	if job.Kind == jobs.KindBackup && job.State.Finished() {
+		data.Storages = storageResults(job)
+	}
+	return data
+}
+
+// storageResults liste les stockages du backup avec le fichier envoyé, ou non envoyé.
+func storageResults(job jobs.Job) []StorageResult {
+	results := []StorageResult{}
+	uploaded := map[string]bool{}
+	for _, artifact := range job.Artifacts {
+		uploaded[artifact.Storage] = true
+		results = append(results, StorageResult{Name: artifact.Storage, Uploaded: true, Key: artifact.Key, Bytes: artifact.Size, Size: FormatBytes(artifact.Size)})
+	}
+	if job.State == jobs.StateSkipped {
+		return results
+	}
+	for _, name := range configuredStorages() {
+		if !uploaded[name] {
+			results = append(results, StorageResult{Name: name})
+		}
+	}
+	return results
+}
+
+// configuredStorages retourne les stockages vers lesquels les backups sont envoyés.
+func configuredStorages() []string {
+	storages := []string{}
+	if serverConfig, err := utils.GetConfigServer(); err == nil {
+		for storage := range serverConfig.RStorage {
+			storages = append(storages, storage)
+		}
+	}
+	sort.Strings(storages)
+	return storages
+}
+
+// FormatBytes formate une taille en octets pour un humain (1.5 MiB).
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
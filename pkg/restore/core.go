@@ -1,8 +1,13 @@
 package restore
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/hooks"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/redact"
 	"mini-backup/pkg/utils"
 	"os"
 	"path/filepath"
@@ -13,10 +18,17 @@ var logger = utils.LoggerFunc()
 
 // CoreRestore gère la logique de restauration
 func CoreRestore(name string, backupFile string, restoreName string, restoreParams any) error {
+	return CoreRestoreContext(context.Background(), name, backupFile, restoreName, restoreParams)
+}
+
+// CoreRestoreContext restaure comme CoreRestore. L'annulation du contexte interrompt
+// les outils externes (mysql, mongorestore, sqlite3, kubectl) et les étapes suivantes.
+func CoreRestoreContext(ctx context.Context, name string, backupFile string, restoreName string, restoreParams any) (err error) {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting restore process for: %s, backupFile: %s", name, backupFile), "[RESTORE] [CORE]")
 
 	// Charger la configuration principale
-	config, err := utils.GetConfig()
+	config, err := utils.GetActiveConfig()
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to load main config: %v", err), "[RESTORE] [CORE]")
 		return err
@@ -25,6 +37,7 @@ func CoreRestore(name string, backupFile string, restoreName string, restorePara
 	defer func() {
 		if r := recover(); r != nil {
 			logger.Error(fmt.Sprintf("Panic occurred during restore for %s: %v", name, r), "[RESTORE] [CORE]")
+			err = fmt.Errorf("panic during restore for %s: %v", name, r)
 		}
 	}()
 
@@ -50,52 +63,65 @@ func CoreRestore(name string, backupFile string, restoreName string, restorePara
 	// 		return err
 	// 	}
 	// }
-	logger.Info(fmt.Sprintf("Restore configuration (final): %+v", restoreConfig), "[RESTORE] [CORE]")
+	logger.Info(fmt.Sprintf("Restore configuration (final): %+v", redact.Value(restoreConfig)), "[RESTORE] [CORE]")
 	// Identifier le type de sauvegarde
 	backupConfig, ok := config.Backups[name]
 	if !ok {
-		err := fmt.Errorf("no backup configuration found for: %s", name)
+		err := fmt.Errorf("%w: %s", utils.ErrBackupNotFound, name)
 		logger.Error(err.Error(), "[RESTORE] [CORE]")
 		return err
 	}
 
+	// Les hooks post_restore et on_failure s'exécutent selon l'issue, panique comprise
+	hookRun := hooks.NewRun(ctx, jobs.KindRestore, name, backupConfig.Type, backupFile)
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error(fmt.Sprintf("Panic occurred during restore for %s: %v", name, r), "[RESTORE] [CORE]")
+			err = fmt.Errorf("panic during restore for %s: %v", name, r)
+		}
+		err = hooks.Finish(ctx, backupConfig.Hooks, utils.HookPostRestore, hookRun, err)
+	}()
+	if err := hooks.Start(ctx, backupConfig.Hooks, utils.HookPreRestore, hookRun); err != nil {
+		return err
+	}
+
 	// Gestion des types de restauration
 	switch backupConfig.Type {
 	case "mysql":
 		logger.Info(fmt.Sprintf("Detected MySQL restore for %s", name), "[RESTORE] [CORE]")
-		result, err := restoreProcess(name, backupConfig, backupFile)
+		result, err := restoreProcess(ctx, name, backupConfig, backupFile)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to restore MySQL for %s: %v", name, err), "[RESTORE] [CORE]")
 			return err
 		}
-		return RestoreMySQL(name, backupConfig, result, restoreParams)
+		return RestoreMySQL(ctx, name, backupConfig, result, restoreParams)
 	case "folder":
 		logger.Info(fmt.Sprintf("Detected folder restore for %s", name), "[RESTORE] [CORE]")
-		result, err := restoreProcess(name, backupConfig, backupFile)
+		result, err := restoreProcess(ctx, name, backupConfig, backupFile)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to restore folder for %s: %v", name, err), "[RESTORE] [CORE]")
 			return err
 		}
-		return RestoreFolder(result, backupConfig)
+		return RestoreFolder(ctx, result, backupConfig)
 	case "s3":
 		logger.Info(fmt.Sprintf("Detected S3 restore for %s", name), "[RESTORE] [CORE]")
-		result, err := restoreProcess(name, backupConfig, backupFile)
+		result, err := restoreProcess(ctx, name, backupConfig, backupFile)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to restore S3 for %s: %v", name, err), "[RESTORE] [CORE]")
 			return err
 		}
-		return RestoreS3(result, backupConfig, name)
+		return RestoreS3(ctx, result, backupConfig, name)
 	case "mongo":
 		logger.Info(fmt.Sprintf("Detected MongoDB restore for %s", name), "[RESTORE] [CORE]")
-		result, err := restoreProcess(name, backupConfig, backupFile)
+		result, err := restoreProcess(ctx, name, backupConfig, backupFile)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to restore MongoDB for %s: %v", name, err), "[RESTORE] [CORE]")
 			return err
 		}
-		return RestoreMongoDB(result, backupConfig)
+		return RestoreMongoDB(ctx, result, backupConfig)
 	case "kubernetes":
 		logger.Info(fmt.Sprintf("Detected Kubernetes restore for %s", name), "[RESTORE] [CORE]")
-		logger.Info(fmt.Sprintf("Restore configuration (raw): %+v", restoreConfig), "[RESTORE] [CORE]")
+		logger.Info(fmt.Sprintf("Restore configuration (raw): %+v", redact.Value(restoreConfig)), "[RESTORE] [CORE]")
 
 		restoreConfigMap, ok := restoreConfig.(map[string]interface{})
 		if !ok {
@@ -108,24 +134,24 @@ func CoreRestore(name string, backupFile string, restoreName string, restorePara
 			return fmt.Errorf("failed to convert restoreConfig to KubernetesRestore: %v", err)
 		}
 
-		logger.Info(fmt.Sprintf("Converted Kubernetes restore configuration: %+v", kubeRestoreConfig), "[RESTORE] [CORE]")
+		logger.Info(fmt.Sprintf("Converted Kubernetes restore configuration: %+v", redact.Value(kubeRestoreConfig)), "[RESTORE] [CORE]")
 
 		// Effectuer le processus de restauration
-		result, err := restoreProcess(name, backupConfig, backupFile)
+		result, err := restoreProcess(ctx, name, backupConfig, backupFile)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to restore Kubernetes for %s: %v", name, err), "[RESTORE] [CORE]")
 			return err
 		}
-		return RestoreKube(result, backupConfig, kubeRestoreConfig)
+		return RestoreKube(ctx, result, backupConfig, kubeRestoreConfig)
 
 	case "sqlite":
 		logger.Info("Restoring sqlite database", "[RESTORE] [CORE]")
-		result, err := restoreProcess(name, backupConfig, backupFile)
+		result, err := restoreProcess(ctx, name, backupConfig, backupFile)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to restore sqlite for %s: %v", name, err), "[RESTORE] [CORE]")
 			return err
 		}
-		return RestoreSqlite(name, backupConfig, result)
+		return RestoreSqlite(ctx, name, backupConfig, result)
 	default:
 		err := fmt.Errorf("unsupported restore type: %s", backupConfig.Type)
 		logger.Error(err.Error(), "[RESTORE] [CORE]")
@@ -146,8 +172,8 @@ func mapToStruct(input map[string]interface{}, output interface{}) error {
 }
 
 // restoreProcess gère le téléchargement, le déchiffrement et la décompression d'un fichier de sauvegarde.
-func restoreProcess(name string, config utils.Backup, backupFile string) (string, error) {
-	logger := utils.LoggerFunc()
+func restoreProcess(ctx context.Context, name string, config utils.Backup, backupFile string) (string, error) {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting restore process for: %s, backupFile: %s", name, backupFile), "[RESTORE] [CORE]")
 
 	// Charger la configuration du serveur
@@ -214,16 +240,31 @@ func restoreProcess(name string, config utils.Backup, backupFile string) (string
 		targetFile = backupFile
 	}
 
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	// Télécharger le fichier chiffré
+	jobs.SetStep(ctx, "download")
 	localEncryptedPath := filepath.Join(config.Path.Local, filepath.Base(targetFile))
-	err = s3client.Download(targetFile, localEncryptedPath)
+	err = s3client.DownloadContext(ctx, targetFile, localEncryptedPath)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to download %s: %v", targetFile, err), "[RESTORE] [CORE]")
 		return "", err
 	}
 	logger.Info(fmt.Sprintf("Downloaded encrypted file to: %s", localEncryptedPath), "[RESTORE] [CORE]")
+	downloaded := events.Event{Type: events.RestoreDownloaded, Storage: firstStorageName, Bucket: firstStorageConfig.BucketName, Key: targetFile, Path: localEncryptedPath}
+	if info, err := os.Stat(localEncryptedPath); err == nil {
+		jobs.AddBytes(ctx, info.Size())
+		downloaded.Bytes = info.Size()
+	}
+	publish(ctx, name, config, downloaded)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 
 	// Déchiffrer le fichier
+	jobs.SetStep(ctx, "decrypt")
 	localDecryptedPath := strings.TrimSuffix(localEncryptedPath, ".enc")
 	err = utils.DecryptFile(localEncryptedPath, localDecryptedPath)
 	if err != nil {
@@ -231,6 +272,7 @@ func restoreProcess(name string, config utils.Backup, backupFile string) (string
 		return "", err
 	}
 	logger.Info(fmt.Sprintf("Decrypted file to: %s", localDecryptedPath), "[RESTORE] [CORE]")
+	publish(ctx, name, config, events.Event{Type: events.RestoreDecrypted, Path: localDecryptedPath})
 
 	// Supprimer le fichier chiffré local
 	err = deleteFile(localEncryptedPath)
@@ -248,12 +290,14 @@ func restoreProcess(name string, config utils.Backup, backupFile string) (string
 			finalPath = strings.TrimSuffix(localDecryptedPath, ".gz")
 		}
 
+		jobs.SetStep(ctx, "decompress")
 		output, err := utils.Decompress(localDecryptedPath, finalPath)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to decompress file %s: %v", localDecryptedPath, err), "[RESTORE] [CORE]")
 			return "", err
 		}
 		logger.Info(fmt.Sprintf("Decompressed file to: %s", output), "[RESTORE] [CORE]")
+		publish(ctx, name, config, events.Event{Type: events.RestoreDecompressed, Path: output})
 		finalPath = output
 		deleteFile(localDecryptedPath)
 	} else {
@@ -261,6 +305,11 @@ func restoreProcess(name string, config utils.Backup, backupFile string) (string
 		logger.Info(fmt.Sprintf("No decompression needed for: %s", finalPath), "[RESTORE] [CORE]")
 	}
 
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	// Étape suivante : la restauration propre au type de backup
+	jobs.SetStep(ctx, "restore")
 	return finalPath, nil
 }
 
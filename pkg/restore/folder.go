@@ -1,6 +1,7 @@
 package restore
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"mini-backup/pkg/utils"
@@ -9,8 +10,8 @@ import (
 )
 
 // RestoreFolder restaure un ou plusieurs fichiers/dossiers depuis un chemin donné vers `config.Folder[0]`.
-func RestoreFolder(restorePath string, config utils.Backup) error {
-	logger := utils.LoggerFunc()
+func RestoreFolder(ctx context.Context, restorePath string, config utils.Backup) error {
+	logger := runLogger(ctx)
 
 	// Assurez-vous que config.Folder contient au moins une destination valide
 	if len(config.Folder) == 0 || config.Folder[0] == "" {
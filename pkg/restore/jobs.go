@@ -0,0 +1,49 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"mini-backup/pkg/events"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/utils"
+)
+
+// SubmitRestore enregistre la restauration dans le gestionnaire de jobs et l'exécute en arrière-plan.
+func SubmitRestore(name string, backupFile string, trigger string) jobs.Job {
+	// Une restauration attend toujours la fin des jobs du même backup et respecte les verrous d'hôte
+	spec := jobs.Spec{
+		Kind:    jobs.KindRestore,
+		Name:    name,
+		Trigger: trigger,
+		Params:  map[string]string{"file": backupFile},
+		Overlap: jobs.OverlapQueue,
+	}
+	if config, err := utils.GetActiveConfig(); err == nil {
+		if backupConfig, exists := config.Backups[name]; exists {
+			spec.Locks = utils.GetConcurrencyConfig().Locks(backupConfig)
+			spec.Params["type"] = backupConfig.Type
+		}
+	}
+	job := jobs.Default.Submit(spec, func(ctx context.Context) error {
+		return CoreRestoreContext(ctx, name, backupFile, "", "")
+	})
+	logger.Info(fmt.Sprintf("Restore job %s submitted for %s (%s)", job.ID, name, trigger), "[RESTORE] [CORE]")
+	return job
+}
+
+// runLogger retourne le logger du package lié au job du contexte (champs de corrélation et journal de l'exécution).
+func runLogger(ctx context.Context) *utils.Logger {
+	return logger.ForJob(ctx)
+}
+
+// publish publie une étape de la restauration sur le bus d'événements.
+func publish(ctx context.Context, name string, config utils.Backup, event events.Event) {
+	event.Kind, event.Job, event.BackupType = jobs.KindRestore, name, config.Type
+	events.PublishContext(ctx, event)
+}
+
+// RunRestore exécute la restauration comme un job et attend sa fin.
+func RunRestore(name string, backupFile string, trigger string) (jobs.Job, error) {
+	job := SubmitRestore(name, backupFile, trigger)
+	return jobs.Default.Wait(job.ID)
+}
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"path/filepath"
 
+	"mini-backup/pkg/jobs"
 	"mini-backup/pkg/utils"
 
 	corev1 "k8s.io/api/core/v1"
@@ -47,8 +48,8 @@ var resourceOrder = []string{
 	"pods",
 }
 
-func RestoreKube(backupFile string, config utils.Backup, restoreConfig utils.KubernetesRestore) error {
-	logger := utils.LoggerFunc()
+func RestoreKube(ctx context.Context, backupFile string, config utils.Backup, restoreConfig utils.KubernetesRestore) error {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting Kubernetes restore from file: %s", backupFile))
 
 	kubeConfigPath := restoreConfig.KubeConfig
@@ -82,8 +83,6 @@ func RestoreKube(backupFile string, config utils.Backup, restoreConfig utils.Kub
 		return fmt.Errorf("error creating dynamic client: %w", err)
 	}
 
-	ctx := context.TODO()
-
 	// Restore namespaces
 	namespacesToRestore := determineNamespaces(state.Namespaces, restoreConfig.Cluster, logger)
 	if err := restoreNamespaces(ctx, clientset, namespacesToRestore, logger); err != nil {
@@ -411,7 +410,7 @@ func copyPVCData(ctx context.Context, clientset *kubernetes.Clientset, res Resou
 
 	defer clientset.CoreV1().Pods(res.Namespace).Delete(ctx, targetPod, metav1.DeleteOptions{})
 
-	cmd := exec.Command(
+	cmd := exec.CommandContext(ctx,
 		"kubectl", "exec", "-n", res.Namespace, targetPod, "--",
 		"sh", "-c", fmt.Sprintf("tar xf - -C %s", targetMountPath),
 	)
@@ -426,6 +425,7 @@ func copyPVCData(ctx context.Context, clientset *kubernetes.Clientset, res Resou
 	cmd.Stdin = tarFile
 
 	output, err := cmd.CombinedOutput()
+	jobs.WriteToolOutput(ctx, "kubectl", output)
 	if err != nil {
 		return fmt.Errorf("failed to copy data to PVC %s/%s: %v, output: %s", res.Namespace, res.Name, err, string(output))
 	}
@@ -2,7 +2,10 @@ package restore
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"mini-backup/pkg/jobs"
 	"mini-backup/pkg/utils"
 	"os"
 	"os/exec"
@@ -10,8 +13,8 @@ import (
 )
 
 // RestoreMongoDB restaure une base de données MongoDB à partir d'un fichier .bson.gz.
-func RestoreMongoDB(backupPath string, config utils.Backup) error {
-	logger := utils.LoggerFunc()
+func RestoreMongoDB(ctx context.Context, backupPath string, config utils.Backup) error {
+	logger := runLogger(ctx)
 
 	logger.Info(fmt.Sprintf("Starting MongoDB restore from: %s", backupPath))
 
@@ -43,12 +46,12 @@ func RestoreMongoDB(backupPath string, config utils.Backup) error {
 		"--archive=" + backupPath,
 	}
 
-	cmd := exec.Command("mongorestore", cmdArgs...)
+	cmd := exec.CommandContext(ctx, "mongorestore", cmdArgs...)
 
 	// Capturer la sortie standard et les erreurs
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	cmd.Stderr = io.MultiWriter(&stderr, jobs.ToolWriter(ctx, "mongorestore"))
 
 	// Exécuter la commande
 	err = cmd.Run()
@@ -1,8 +1,10 @@
 package restore
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"mini-backup/pkg/jobs"
 	"mini-backup/pkg/utils"
 	"os"
 	"os/exec"
@@ -10,8 +12,8 @@ import (
 )
 
 // RestoreMySQL restaure une ou plusieurs bases de données à partir d'un dossier de sauvegarde.
-func RestoreMySQL(name string, config utils.Backup, backupDir string, params any) error {
-	logger := utils.LoggerFunc()
+func RestoreMySQL(ctx context.Context, name string, config utils.Backup, backupDir string, params any) error {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting MySQL restore from directory: %s", backupDir))
 
 	// Vérifie la configuration MySQL
@@ -56,7 +58,7 @@ func RestoreMySQL(name string, config utils.Backup, backupDir string, params any
 		if config.Mysql.All {
 			// Restaurer toute la BDD si le fichier "all_databases.sql" existe
 			if hasAllDatabasesBackup {
-				return restoreAllDatabases(allDatabasesFile, config, logger)
+				return restoreAllDatabases(ctx, allDatabasesFile, config, logger)
 			}
 			return fmt.Errorf("all_databases.sql file not found in %s", backupDir)
 		} else {
@@ -71,14 +73,14 @@ func RestoreMySQL(name string, config utils.Backup, backupDir string, params any
 
 		if fileExists(dbBackupFile) {
 			// Restaurer une base de données avec son propre fichier SQL
-			err := restoreSingleDatabase(dbBackupFile, config, database, logger)
+			err := restoreSingleDatabase(ctx, dbBackupFile, config, database, logger)
 			if err != nil {
 				logger.Error(fmt.Sprintf("Failed to restore database %s: %v", database, err))
 				return err
 			}
 		} else if hasAllDatabasesBackup {
 			// Si "all_databases.sql" est présent, restaurer uniquement la base demandée
-			err := restoreDatabaseFromAllDatabases(allDatabasesFile, config, database, logger)
+			err := restoreDatabaseFromAllDatabases(ctx, allDatabasesFile, config, database, logger)
 			if err != nil {
 				logger.Error(fmt.Sprintf("Failed to restore database %s from all_databases.sql: %v", database, err))
 				return err
@@ -93,10 +95,10 @@ func RestoreMySQL(name string, config utils.Backup, backupDir string, params any
 }
 
 // restoreAllDatabases restaure l'ensemble des bases de données
-func restoreAllDatabases(backupFile string, config utils.Backup, logger *utils.Logger) error {
+func restoreAllDatabases(ctx context.Context, backupFile string, config utils.Backup, logger *utils.Logger) error {
 	logger.Info(fmt.Sprintf("Restoring all databases from backup file: %s", backupFile))
 
-	cmd := exec.Command(
+	cmd := exec.CommandContext(ctx,
 		"mysql",
 		"-h", config.Mysql.Host,
 		"-P", config.Mysql.Port,
@@ -115,6 +117,7 @@ func restoreAllDatabases(backupFile string, config utils.Backup, logger *utils.L
 
 	// Exécuter la restauration
 	output, err := cmd.CombinedOutput()
+	jobs.WriteToolOutput(ctx, "mysql", output)
 	if err != nil {
 		logger.Error(fmt.Sprintf("MySQL restore failed: %s", string(output)))
 		return fmt.Errorf("mysql restore failed: %w", err)
@@ -125,10 +128,10 @@ func restoreAllDatabases(backupFile string, config utils.Backup, logger *utils.L
 }
 
 // restoreDatabaseFromAllDatabases restaure une base spécifique à partir de all_databases.sql
-func restoreDatabaseFromAllDatabases(backupFile string, config utils.Backup, database string, logger *utils.Logger) error {
+func restoreDatabaseFromAllDatabases(ctx context.Context, backupFile string, config utils.Backup, database string, logger *utils.Logger) error {
 	logger.Info(fmt.Sprintf("Restoring database %s from all_databases.sql", database))
 
-	cmd := exec.Command(
+	cmd := exec.CommandContext(ctx,
 		"mysql",
 		"-h", config.Mysql.Host,
 		"-P", config.Mysql.Port,
@@ -148,6 +151,7 @@ func restoreDatabaseFromAllDatabases(backupFile string, config utils.Backup, dat
 
 	// Exécuter la restauration
 	output, err := cmd.CombinedOutput()
+	jobs.WriteToolOutput(ctx, "mysql", output)
 	if err != nil {
 		logger.Error(fmt.Sprintf("MySQL restore failed for database %s: %s", database, string(output)))
 		return fmt.Errorf("mysql restore failed: %w", err)
@@ -158,10 +162,10 @@ func restoreDatabaseFromAllDatabases(backupFile string, config utils.Backup, dat
 }
 
 // restoreSingleDatabase restaure une seule base de données depuis un fichier SQL dédié
-func restoreSingleDatabase(backupFile string, config utils.Backup, database string, logger *utils.Logger) error {
+func restoreSingleDatabase(ctx context.Context, backupFile string, config utils.Backup, database string, logger *utils.Logger) error {
 	logger.Info(fmt.Sprintf("Restoring database: %s from file: %s", database, backupFile))
 
-	cmd := exec.Command(
+	cmd := exec.CommandContext(ctx,
 		"mysql",
 		"-h", config.Mysql.Host,
 		"-P", config.Mysql.Port,
@@ -180,6 +184,7 @@ func restoreSingleDatabase(backupFile string, config utils.Backup, database stri
 
 	// Exécuter la restauration
 	output, err := cmd.CombinedOutput()
+	jobs.WriteToolOutput(ctx, "mysql", output)
 	if err != nil {
 		logger.Error(fmt.Sprintf("MySQL restore failed: %s", string(output)))
 		return fmt.Errorf("mysql restore failed: %w", err)
@@ -1,6 +1,7 @@
 package restore
 
 import (
+	"context"
 	"fmt"
 	"mini-backup/pkg/utils"
 	"os"
@@ -8,7 +9,8 @@ import (
 	"strings"
 )
 
-func RestoreS3(backupPath string, config utils.Backup, name string) error {
+func RestoreS3(ctx context.Context, backupPath string, config utils.Backup, name string) error {
+    logger := runLogger(ctx)
     logger.Info(fmt.Sprintf("Starting S3 restore process from: %s", backupPath), "[RESTORE] [S3]")
 
     err := utils.AwsCredentialFileCreateFunc(config.S3.ACCESS_KEY, config.S3.SECRET_KEY, name)
@@ -1,7 +1,9 @@
 package restore
 
 import (
+	"context"
 	"fmt"
+	"mini-backup/pkg/jobs"
 	"mini-backup/pkg/utils"
 	"os"
 	"os/exec"
@@ -11,7 +13,8 @@ import (
 
 // RestoreSqlite restaure une base SQLite à partir d'un fichier de backup.
 // La restauration s'effectue via la commande sqlite3 ".restore" et le fichier de base cible est verrouillé pendant l'opération.
-func RestoreSqlite(name string, config utils.Backup, backupFilePath string) error {
+func RestoreSqlite(ctx context.Context, name string, config utils.Backup, backupFilePath string) error {
+	logger := runLogger(ctx)
 	logger.Info(fmt.Sprintf("Starting SQLite restore for: %s", name))
 
 	// On suppose que le chemin de la base cible est défini dans la configuration dans Sqlite.Paths[0]
@@ -41,8 +44,9 @@ func RestoreSqlite(name string, config utils.Backup, backupFilePath string) erro
 	// Construction de la commande sqlite3 pour restaurer le backup.
 	// La commande exécutée sera : sqlite3 <dbPath> ".restore 'backupFilePath'"
 	restoreCmd := fmt.Sprintf(".restore '%s'", backupFilePath)
-	cmd := exec.Command("sqlite3", dbPath, restoreCmd)
+	cmd := exec.CommandContext(ctx, "sqlite3", dbPath, restoreCmd)
 	output, err := cmd.CombinedOutput()
+	jobs.WriteToolOutput(ctx, "sqlite3", output)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Error executing sqlite3 restore command: %v, output: %s", err, string(output)))
 		return err
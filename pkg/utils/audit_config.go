@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// AuditConfig configure le journal d'audit des actions sensibles (restaurations, téléchargements,
+// modifications de la configuration, clés d'API). Le journal est toujours tenu ; Syslog en
+// envoie en plus une copie hors du serveur.
+type AuditConfig struct {
+	// File est le journal d'audit (défaut : <data_dir>/audit.log).
+	File   string             `yaml:"file,omitempty"`
+	Syslog *AuditSyslogConfig `yaml:"syslog,omitempty"`
+}
+
+// AuditSyslogConfig décrit le serveur syslog qui reçoit les entrées d'audit.
+type AuditSyslogConfig struct {
+	// Network vaut udp, tcp ou unix ; vide avec Address vide : syslog local.
+	Network  string `yaml:"network,omitempty"`
+	Address  string `yaml:"address,omitempty"`
+	Tag      string `yaml:"tag,omitempty"`
+	Facility string `yaml:"facility,omitempty"`
+}
+
+var (
+	SyslogNetworks   = []string{"udp", "tcp", "unix"}
+	SyslogFacilities = []string{"auth", "authpriv", "daemon", "user", "local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7"}
+)
+
+// Path retourne le chemin du journal d'audit.
+func (c AuditConfig) Path(dataDir string) string {
+	if c.File != "" {
+		return c.File
+	}
+	return filepath.Join(dataDir, "audit.log")
+}
+
+// Validate vérifie la section audit.
+func (c AuditConfig) Validate() error {
+	if c.Syslog == nil {
+		return nil
+	}
+	if c.Syslog.Network != "" && !containsString(SyslogNetworks, c.Syslog.Network) {
+		return fmt.Errorf("audit.syslog.network: unsupported value %q (expected one of %s)", c.Syslog.Network, strings.Join(SyslogNetworks, ", "))
+	}
+	if (c.Syslog.Network == "") != (c.Syslog.Address == "") {
+		return fmt.Errorf("audit.syslog: network and address must be set together (both empty: local syslog)")
+	}
+	if c.Syslog.Facility != "" && !containsString(SyslogFacilities, c.Syslog.Facility) {
+		return fmt.Errorf("audit.syslog.facility: unsupported value %q (expected one of %s)", c.Syslog.Facility, strings.Join(SyslogFacilities, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AuthConfig protège l'API par des clés (voir `backup-cli apikey`) et, avec `oidc`, par une
+// connexion via le fournisseur d'identité. L'authentification est exigée par défaut ; seul
+// `enabled: false` rend toutes les routes accessibles sans authentification.
+type AuthConfig struct {
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// KeysFile est le fichier des clés d'API (défaut : <data_dir>/api_keys.json).
+	KeysFile string      `yaml:"keys_file,omitempty"`
+	OIDC     *OIDCConfig `yaml:"oidc,omitempty"`
+}
+
+// OIDCConfig configure la connexion OpenID Connect (flux authorization code avec PKCE).
+// Le rôle de l'utilisateur est déduit des groupes de son jeton d'identité.
+type OIDCConfig struct {
+	// Issuer est l'URL du fournisseur, qui expose /.well-known/openid-configuration.
+	Issuer       string `yaml:"issuer"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret,omitempty"`
+	// RedirectURL est l'URL publique de /auth/callback, déclarée auprès du fournisseur.
+	RedirectURL string `yaml:"redirect_url"`
+	// Scopes vaut [openid, profile, email] par défaut ; openid est toujours demandé.
+	Scopes []string `yaml:"scopes,omitempty"`
+	// GroupsClaim est le claim des groupes, éventuellement imbriqué (realm_access.roles). Défaut : groups.
+	GroupsClaim string `yaml:"groups_claim,omitempty"`
+	// UsernameClaim est le claim du nom affiché (défaut : preferred_username, puis email, puis sub).
+	UsernameClaim string `yaml:"username_claim,omitempty"`
+	// Roles associe un groupe à un rôle ; l'utilisateur reçoit le plus élevé de ses groupes.
+	Roles map[string]string `yaml:"roles,omitempty"`
+	// DefaultRole est attribué aux utilisateurs sans groupe associé (défaut : connexion refusée).
+	DefaultRole string `yaml:"default_role,omitempty"`
+	// SessionTTL est la durée de la session (défaut : 8h).
+	SessionTTL string `yaml:"session_ttl,omitempty"`
+	// SessionSecret signe les cookies de session (défaut : clé générée dans <data_dir>/session.key).
+	SessionSecret string `yaml:"session_secret,omitempty"`
+	// LoginRedirect est la page ouverte après la connexion, en général l'interface web (défaut : /).
+	LoginRedirect string `yaml:"login_redirect,omitempty"`
+}
+
+// DefaultSessionTTL est la durée d'une session OIDC sans `session_ttl`.
+const DefaultSessionTTL = 8 * time.Hour
+
+// ScopeList retourne les scopes demandés au fournisseur.
+func (c OIDCConfig) ScopeList() []string {
+	scopes := c.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+	if !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+	return scopes
+}
+
+// GroupsClaimName retourne le claim qui contient les groupes.
+func (c OIDCConfig) GroupsClaimName() string {
+	if c.GroupsClaim == "" {
+		return "groups"
+	}
+	return c.GroupsClaim
+}
+
+// SessionDuration retourne la durée d'une session.
+func (c OIDCConfig) SessionDuration() time.Duration {
+	if ttl, err := time.ParseDuration(c.SessionTTL); err == nil && ttl > 0 {
+		return ttl
+	}
+	return DefaultSessionTTL
+}
+
+// SessionKeyPath retourne le fichier de la clé de signature générée sans `session_secret`.
+func (c OIDCConfig) SessionKeyPath(dataDir string) string {
+	return filepath.Join(dataDir, "session.key")
+}
+
+// Validate vérifie la section auth.oidc. Les rôles sont vérifiés par le package auth.
+func (c AuthConfig) Validate() error {
+	if c.OIDC == nil {
+		return nil
+	}
+	oidc := c.OIDC
+	for _, field := range []struct{ name, value string }{
+		{"issuer", oidc.Issuer},
+		{"redirect_url", oidc.RedirectURL},
+	} {
+		parsed, err := url.Parse(field.value)
+		if field.value == "" || err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("auth.oidc.%s: expected an http(s) URL, got %q", field.name, field.value)
+		}
+	}
+	if oidc.ClientID == "" {
+		return fmt.Errorf("auth.oidc.client_id is required")
+	}
+	if oidc.SessionTTL != "" {
+		if ttl, err := time.ParseDuration(oidc.SessionTTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("auth.oidc.session_ttl: invalid duration %q (expected e.g. 8h)", oidc.SessionTTL)
+		}
+	}
+	if len(oidc.Roles) == 0 && oidc.DefaultRole == "" {
+		return fmt.Errorf("auth.oidc: roles or default_role is required, otherwise no user can log in")
+	}
+	return nil
+}
+
+// CORSConfig liste les origines autorisées à appeler l'API depuis un navigateur.
+type CORSConfig struct {
+	// AllowOrigins vaut ["*"] par défaut.
+	AllowOrigins []string `yaml:"allow_origins,omitempty"`
+}
+
+// IsEnabled indique si l'API exige une authentification (vrai sauf `enabled: false`).
+func (c AuthConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// KeysPath retourne le chemin du fichier des clés d'API.
+func (c AuthConfig) KeysPath(dataDir string) string {
+	if c.KeysFile != "" {
+		return c.KeysFile
+	}
+	return filepath.Join(dataDir, "api_keys.json")
+}
+
+// Origins retourne les origines autorisées au format attendu par le middleware CORS.
+func (c CORSConfig) Origins() string {
+	if len(c.AllowOrigins) == 0 {
+		return "*"
+	}
+	return strings.Join(c.AllowOrigins, ",")
+}
+
+// Validate vérifie les origines : "*" seul, ou des URLs scheme://hôte[:port] sans chemin.
+func (c CORSConfig) Validate() error {
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			if len(c.AllowOrigins) > 1 {
+				return fmt.Errorf("cors.allow_origins: \"*\" cannot be combined with other origins")
+			}
+			continue
+		}
+		scheme, host, found := strings.Cut(origin, "://")
+		if !found || (scheme != "http" && scheme != "https") || host == "" || strings.ContainsAny(host, "/?#") {
+			return fmt.Errorf("cors.allow_origins: invalid origin %q (expected e.g. https://backup.example.com)", origin)
+		}
+	}
+	return nil
+}
@@ -2,21 +2,21 @@ package utils
 
 import (
 	"fmt"
-	"io"
-	"net/http"
+	"mini-backup/examples"
 	"os"
 	"path/filepath"
 )
 
 const (
-	configDir  = "./config"
 	envFile    = "./.env"
 	serverYaml = "server.yaml"
 	configYaml = "config.yaml"
-	baseURL    = "https://raw.githubusercontent.com/aidalinfo/mini-backup/main/examples"
 )
 
+// AutoConfigurationFunc crée server.yaml et config.yaml à partir des exemples embarqués
+// dans le binaire lorsqu'ils n'existent pas. Aucun accès réseau n'est nécessaire.
 func AutoConfigurationFunc() error {
+	configDir := baseConfigDir()
 	// Vérifier et créer le dossier config si nécessaire
 	if _, err := os.Stat(configDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(configDir, 0750); err != nil {
@@ -24,16 +24,15 @@ func AutoConfigurationFunc() error {
 		}
 	}
 
-	// Télécharger les fichiers YAML si nécessaires
 	files := map[string]string{
-		filepath.Join(configDir, serverYaml): baseURL + "/" + serverYaml,
-		filepath.Join(configDir, configYaml): baseURL + "/" + configYaml,
+		serverConfigPath():                   serverYaml,
+		filepath.Join(configDir, configYaml): configYaml,
 	}
 
-	for localPath, url := range files {
+	for localPath, name := range files {
 		if _, err := os.Stat(localPath); os.IsNotExist(err) {
-			if err := downloadFile(localPath, url); err != nil {
-				return fmt.Errorf("erreur lors du téléchargement de %s: %w", localPath, err)
+			if err := WriteExampleFile(name, localPath); err != nil {
+				return fmt.Errorf("erreur lors de la création de %s: %w", localPath, err)
 			}
 		}
 	}
@@ -41,19 +40,14 @@ func AutoConfigurationFunc() error {
 	return nil
 }
 
-func downloadFile(filepath string, url string) error {
-	resp, err := http.Get(url)
+// WriteExampleFile écrit le fichier d'exemple embarqué name (server.yaml ou config.yaml) dans path.
+func WriteExampleFile(name, path string) error {
+	data, err := examples.Files.ReadFile(name)
 	if err != nil {
-		return err
+		return fmt.Errorf("unknown example file %s: %w", name, err)
 	}
-	defer resp.Body.Close()
-
-	out, err := os.Create(filepath)
-	if err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
 		return err
 	}
-	defer out.Close()
-
-	_, err = io.Copy(out, resp.Body)
-	return err
+	return os.WriteFile(path, data, 0640)
 }
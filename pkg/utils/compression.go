@@ -272,3 +272,15 @@ func DecompressTar(tarPath, outputPath string) error {
 	getLogger().Info(fmt.Sprintf("Successfully decompressed tar archive: %s", tarPath))
 	return nil
 }
+
+// PathSize retourne la taille d'un fichier ou la taille cumulée des fichiers d'un dossier (0 si illisible).
+func PathSize(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
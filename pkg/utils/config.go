@@ -2,18 +2,26 @@ package utils
 
 import (
 	"fmt"
+	"mini-backup/pkg/redact"
 	"os"
-	"path/filepath"
 	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
 type BackupConfig struct {
-	Backups map[string]Backup `yaml:"backups"`
+	Defaults  *BackupTemplate           `yaml:"defaults,omitempty"`
+	Templates map[string]BackupTemplate `yaml:"templates,omitempty"`
+	Backups   map[string]Backup         `yaml:"backups"`
 }
 
+// BackupTemplate est une définition partielle de backup, utilisée par `defaults:` et `templates:`.
+// Elle est fusionnée en profondeur dans les backups avant leur chargement (voir resolveBackups).
+type BackupTemplate Backup
+
 type Backup struct {
+	Extends    string      `yaml:"extends,omitempty"`
 	Type       string      `yaml:"type"`
 	Folder     []string    `yaml:"folder"`
 	S3         S3config    `yaml:"s3"`
@@ -24,8 +32,29 @@ type Backup struct {
 	Path       Path        `yaml:"path"`
 	Retention  Retention   `yaml:"retention,omitempty"`
 	Schedule   Schedule    `yaml:"schedule"`
+	Overlap    string      `yaml:"overlap,omitempty"`
+	Heartbeat  *Heartbeat  `yaml:"heartbeat,omitempty"`
+	Hooks      *Hooks      `yaml:"hooks,omitempty"`
+}
+
+// Heartbeat décrit les URLs pingées au début, au succès et à l'échec de chaque exécution du backup,
+// pour qu'un moniteur externe (Healthchecks.io, Uptime Kuma) alerte si le backup ne tourne plus.
+// Les URLs de ping suffisent à signaler un backup réussi : seul leur hôte est affiché par l'API.
+type Heartbeat struct {
+	// URL est l'URL de base : <url>/start, <url> et <url>/fail pour Healthchecks.io,
+	// <url>?status=up|down pour Uptime Kuma.
+	URL string `yaml:"url,omitempty" redact:"endpoint"`
+	// Style vaut healthchecks (défaut) ou uptime-kuma.
+	Style string `yaml:"style,omitempty"`
+	// Start, Success et Failure remplacent les URLs déduites de URL.
+	Start   string `yaml:"start,omitempty" redact:"endpoint"`
+	Success string `yaml:"success,omitempty" redact:"endpoint"`
+	Failure string `yaml:"failure,omitempty" redact:"endpoint"`
 }
 
+// Styles de heartbeat.
+var HeartbeatStyles = []string{"healthchecks", "uptime-kuma"}
+
 type Mongo struct {
 	Databases []string `yaml:"databases,omitempty"`
 	Host      string   `yaml:"host,omitempty"`
@@ -117,65 +146,143 @@ func LoadConfig(filepath string) (*BackupConfig, error) {
 	return &config, nil
 }
 
-func GetConfig() (*BackupConfig, error) {
-	var configDir string
-	if GetEnv[string]("GO_ENV") == "prod" {
-		configDir = "/etc/backup-tool"
-	} else {
-		configDir = "config"
+var (
+	activeConfigMu sync.RWMutex
+	activeConfig   *BackupConfig
+)
+
+// SetActiveConfig enregistre la configuration actuellement appliquée par le serveur.
+func SetActiveConfig(config *BackupConfig) {
+	activeConfigMu.Lock()
+	defer activeConfigMu.Unlock()
+	activeConfig = config
+}
+
+// GetActiveConfig retourne la configuration appliquée par le serveur. Si aucune configuration
+// n'a encore été appliquée (CLI par exemple), elle est chargée depuis le disque.
+func GetActiveConfig() (*BackupConfig, error) {
+	activeConfigMu.RLock()
+	config := activeConfig
+	activeConfigMu.RUnlock()
+	if config != nil {
+		return config, nil
 	}
+	return GetConfig()
+}
 
-	// Créer un BackupConfig vide pour stocker la configuration fusionnée pour renvoyer qu'un seul objet
-	mergedConfig := &BackupConfig{
-		Backups: make(map[string]Backup),
+var (
+	configDirMu       sync.RWMutex
+	configDirOverride string
+)
+
+// SetConfigDir remplace le répertoire de configuration des backups (clone git par exemple).
+func SetConfigDir(dir string) {
+	configDirMu.Lock()
+	defer configDirMu.Unlock()
+	configDirOverride = dir
+}
+
+func configDirOverrideValue() string {
+	configDirMu.RLock()
+	defer configDirMu.RUnlock()
+	return configDirOverride
+}
+
+// GetConfigDir retourne le répertoire principal des fichiers de configuration des backups
+// (voir GetConfigLocation pour la liste complète des répertoires chargés).
+func GetConfigDir() string {
+	if override := configDirOverrideValue(); override != "" {
+		return override
 	}
+	return baseConfigDir()
+}
 
-	// Lire tous les fichiers du répertoire de configuration
-	files, err := os.ReadDir(configDir)
+// GetConfig charge et fusionne la configuration des backups. Les fichiers invalides sont ignorés.
+func GetConfig() (*BackupConfig, error) {
+	paths, err := configFiles()
 	if err != nil {
 		getLogger().Error(fmt.Sprintf("Failed to read config directory: %v", err), source_utils)
-		return nil, fmt.Errorf("failed to read config directory: %v", err)
+		return nil, err
 	}
+	return loadConfigFiles(paths, false)
+}
 
-	// Charger d'abord le fichier config.yaml principal s'il existe
-	mainConfigPath := filepath.Join(configDir, "config.yaml")
-	if _, err := os.Stat(mainConfigPath); err == nil {
-		getLogger().Info(fmt.Sprintf("Loading main config from %s", mainConfigPath), source_utils)
-		mainConfig, err := LoadConfig(mainConfigPath)
-		if err != nil {
-			getLogger().Error(fmt.Sprintf("Failed to load main config: %v", err), source_utils)
-		} else {
-			for name, backup := range mainConfig.Backups {
-				mergedConfig.Backups[name] = backup
-			}
+// GetConfigStrict valide puis charge la configuration des backups et échoue au moindre fichier invalide.
+// Utilisé au démarrage et lors d'un rechargement à chaud pour ne jamais appliquer une configuration invalide.
+func GetConfigStrict() (*BackupConfig, error) {
+	report := ValidateConfig()
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityWarning {
+			getLogger().Info(fmt.Sprintf("Config warning: %s", issue), source_utils)
 		}
 	}
+	if err := report.Err(); err != nil {
+		return nil, err
+	}
+	return loadConfigFiles(report.Files, true)
+}
 
-	// Parcourir chaque fichier pour trouver les .backups.yaml/.backups.yml afin de créer un objet BackupConfig
-	for _, file := range files {
-		if !file.IsDir() && (strings.HasSuffix(file.Name(), ".backups.yaml") || strings.HasSuffix(file.Name(), ".backups.yml")) {
-			configPath := filepath.Join(configDir, file.Name())
-			getLogger().Info(fmt.Sprintf("Loading backup config from %s", configPath), source_utils)
+// loadConfigFiles charge les fichiers donnés, dans l'ordre (voir configFilesForEntries).
+func loadConfigFiles(paths []string, strict bool) (*BackupConfig, error) {
+	// Créer un BackupConfig vide pour stocker la configuration fusionnée pour renvoyer qu'un seul objet
+	mergedConfig := &BackupConfig{
+		Backups: make(map[string]Backup),
+	}
 
-			config, err := LoadConfig(configPath)
-			if err != nil {
-				getLogger().Error(fmt.Sprintf("Failed to load config from %s: %v", configPath, err), source_utils)
-				continue
+	report := &ValidationReport{}
+	files := []*configFile{}
+	for _, configPath := range paths {
+		getLogger().Info(fmt.Sprintf("Loading backup config from %s", configPath), source_utils)
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			getLogger().Error(fmt.Sprintf("Failed to load config from %s: %v", configPath, err), source_utils)
+			if strict {
+				return nil, fmt.Errorf("failed to load %s: %w", configPath, err)
+			}
+			continue
+		}
+		file := parseConfigFile(configPath, data, report)
+		if file == nil {
+			getLogger().Error(fmt.Sprintf("Failed to load config from %s: %s", configPath, report.FormatIssues()), source_utils)
+			if strict {
+				return nil, &ValidationError{Report: report}
 			}
+			continue
+		}
+		files = append(files, file)
+	}
 
-			// Fusionner les configurations
-			for name, backup := range config.Backups {
-				if _, exists := mergedConfig.Backups[name]; exists {
-					getLogger().Error(fmt.Sprintf("Backup configuration '%s' from %s overrides existing configuration", name, configPath), source_utils)
-				}
-				mergedConfig.Backups[name] = backup
+	// Appliquer defaults et templates puis décoder la définition effective de chaque backup
+	for _, resolved := range resolveBackups(files, report) {
+		var backup Backup
+		if err := resolved.Node.Decode(&backup); err != nil {
+			getLogger().Error(fmt.Sprintf("Failed to decode backup '%s' from %s: %v", resolved.Name, resolved.File, err), source_utils)
+			if strict {
+				return nil, fmt.Errorf("failed to decode backup '%s' from %s: %w", resolved.Name, resolved.File, err)
 			}
+			continue
+		}
+		if _, exists := mergedConfig.Backups[resolved.Name]; exists {
+			getLogger().Error(fmt.Sprintf("Backup configuration '%s' from %s overrides existing configuration", resolved.Name, resolved.File), source_utils)
+		}
+		mergedConfig.Backups[resolved.Name] = backup
+	}
+	if report.HasErrors() {
+		for _, issue := range report.Issues {
+			if issue.Severity == SeverityError {
+				getLogger().Error(issue.String(), source_utils)
+			}
+		}
+		if strict {
+			return nil, &ValidationError{Report: report}
 		}
 	}
 
 	if len(mergedConfig.Backups) == 0 {
-		return nil, fmt.Errorf("no valid backup configurations found in %s", configDir)
+		return nil, fmt.Errorf("no valid backup configurations found in %s", strings.Join(paths, ", "))
 	}
 
+	// Les secrets chargés sont masqués dans les journaux et les erreurs
+	redact.Register(redact.Secrets(mergedConfig)...)
 	return mergedConfig, nil
 }
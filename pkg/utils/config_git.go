@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGitBranch   = "main"
+	defaultGitInterval = time.Minute
+)
+
+// ErrConfigReadOnly est retournée lorsque la configuration est gérée par une source externe (git).
+var ErrConfigReadOnly = errors.New("backup configuration is managed by a git repository")
+
+// ConfigSourceStatus décrit l'état de la synchronisation de la configuration depuis git.
+type ConfigSourceStatus struct {
+	Type           string    `json:"type"`
+	Repository     string    `json:"repository"`
+	Branch         string    `json:"branch"`
+	Path           string    `json:"path,omitempty"`
+	Commit         string    `json:"commit"`
+	AppliedAt      time.Time `json:"applied_at,omitempty"`
+	LastCheck      time.Time `json:"last_check,omitempty"`
+	RejectedCommit string    `json:"rejected_commit,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+}
+
+// GitConfigSource synchronise les fichiers *.backups.yaml depuis un dépôt git.
+// Chaque nouveau commit est validé et appliqué comme un rechargement à chaud ;
+// s'il est rejeté, le clone revient au dernier commit appliqué.
+type GitConfigSource struct {
+	config   ConfigSourceConfig
+	interval time.Duration
+	checkout string
+	stop     chan struct{}
+
+	// syncMu sérialise les synchronisations (fetch, checkout et application)
+	syncMu sync.Mutex
+	// mu protège seulement status, pour que Status ne bloque pas pendant un fetch
+	mu     sync.Mutex
+	status ConfigSourceStatus
+}
+
+var (
+	gitSourceMu     sync.RWMutex
+	activeGitSource *GitConfigSource
+)
+
+// NewGitConfigSource vérifie la section config_source de server.yaml et prépare la source.
+func NewGitConfigSource(config ConfigSourceConfig) (*GitConfigSource, error) {
+	if config.Type != "git" {
+		return nil, fmt.Errorf("unsupported config_source type %q (expected git)", config.Type)
+	}
+	if config.Repository == "" {
+		return nil, fmt.Errorf("config_source: repository is required")
+	}
+	if config.Branch == "" {
+		config.Branch = defaultGitBranch
+	}
+	interval := defaultGitInterval
+	if config.Interval != "" {
+		parsed, err := time.ParseDuration(config.Interval)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("config_source: invalid interval %q", config.Interval)
+		}
+		interval = parsed
+	}
+	checkout := config.Checkout
+	if checkout == "" {
+		checkout = filepath.Join(baseConfigDir(), ".source")
+	}
+	return &GitConfigSource{
+		config:   config,
+		interval: interval,
+		checkout: checkout,
+		status: ConfigSourceStatus{
+			Type:       config.Type,
+			Repository: redactRepositoryURL(config.Repository),
+			Branch:     config.Branch,
+			Path:       config.Path,
+		},
+	}, nil
+}
+
+// Prepare clone le dépôt s'il n'existe pas encore puis utilise le clone comme répertoire de configuration.
+// Si le clone existe déjà, il est utilisé tel quel : il pointe sur le dernier commit appliqué.
+func (s *GitConfigSource) Prepare() error {
+	if _, err := os.Stat(filepath.Join(s.checkout, ".git")); os.IsNotExist(err) {
+		getLogger().Info(fmt.Sprintf("Cloning configuration repository %s (%s)", s.status.Repository, s.config.Branch), source_utils)
+		if err := os.MkdirAll(filepath.Dir(s.checkout), 0750); err != nil {
+			return fmt.Errorf("failed to create checkout directory: %w", err)
+		}
+		if _, err := runGit("", "clone", "--quiet", "--branch", s.config.Branch, "--single-branch", s.config.Repository, s.checkout); err != nil {
+			return err
+		}
+	}
+	SetConfigDir(filepath.Join(s.checkout, s.config.Path))
+
+	gitSourceMu.Lock()
+	activeGitSource = s
+	gitSourceMu.Unlock()
+	return nil
+}
+
+// MarkApplied enregistre le commit courant du clone comme appliqué (après le démarrage du scheduler).
+func (s *GitConfigSource) MarkApplied() error {
+	commit, err := runGit(s.checkout, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Commit = commit
+	s.status.AppliedAt = time.Now()
+	getLogger().Info(fmt.Sprintf("Configuration applied from commit %s", shortCommit(commit)), source_utils)
+	return nil
+}
+
+// Start interroge le dépôt à intervalle régulier. apply doit valider et appliquer la configuration
+// présente dans le répertoire de configuration, et retourner une erreur si elle est rejetée.
+func (s *GitConfigSource) Start(apply func() error) {
+	s.stop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Sync(apply); err != nil {
+					getLogger().Error(fmt.Sprintf("Configuration sync failed: %v", err), source_utils)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop arrête la synchronisation périodique.
+func (s *GitConfigSource) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+	}
+}
+
+// Sync récupère la branche suivie et applique le nouveau commit s'il y en a un.
+func (s *GitConfigSource) Sync(apply func() error) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	s.mu.Lock()
+	s.status.LastCheck = time.Now()
+	current, rejected := s.status.Commit, s.status.RejectedCommit
+	s.mu.Unlock()
+
+	if _, err := runGit(s.checkout, "fetch", "--quiet", "origin", s.config.Branch); err != nil {
+		s.setError(err.Error())
+		return err
+	}
+	candidate, err := runGit(s.checkout, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		s.setError(err.Error())
+		return err
+	}
+	if candidate == current || candidate == rejected {
+		return nil
+	}
+
+	getLogger().Info(fmt.Sprintf("New configuration commit %s, validating", shortCommit(candidate)), source_utils)
+	if _, err := runGit(s.checkout, "checkout", "--quiet", "--force", "--detach", candidate); err != nil {
+		s.setError(err.Error())
+		return err
+	}
+	if err := apply(); err != nil {
+		message := fmt.Sprintf("commit %s rejected: %v", shortCommit(candidate), err)
+		s.mu.Lock()
+		s.status.RejectedCommit = candidate
+		s.status.LastError = message
+		s.mu.Unlock()
+		if current != "" {
+			if _, restoreErr := runGit(s.checkout, "checkout", "--quiet", "--force", "--detach", current); restoreErr != nil {
+				return fmt.Errorf("%s (failed to restore %s: %v)", message, shortCommit(current), restoreErr)
+			}
+		}
+		return errors.New(message)
+	}
+
+	s.mu.Lock()
+	s.status.Commit = candidate
+	s.status.AppliedAt = time.Now()
+	s.status.RejectedCommit = ""
+	s.status.LastError = ""
+	s.mu.Unlock()
+	getLogger().Info(fmt.Sprintf("Configuration applied from commit %s", shortCommit(candidate)), source_utils)
+	return nil
+}
+
+func (s *GitConfigSource) setError(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.LastError = message
+}
+
+// Status retourne l'état courant de la synchronisation.
+func (s *GitConfigSource) Status() ConfigSourceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// GetConfigSourceStatus retourne l'état de la source git active, ou nil si la configuration est locale.
+func GetConfigSourceStatus() *ConfigSourceStatus {
+	gitSourceMu.RLock()
+	source := activeGitSource
+	gitSourceMu.RUnlock()
+	if source == nil {
+		return nil
+	}
+	status := source.Status()
+	return &status
+}
+
+// configManagedByGit indique si la configuration des backups provient d'un dépôt git.
+func configManagedByGit() bool {
+	gitSourceMu.RLock()
+	defer gitSourceMu.RUnlock()
+	return activeGitSource != nil
+}
+
+// runGit exécute une commande git et retourne sa sortie sans espaces superflus.
+func runGit(dir string, args ...string) (string, error) {
+	subcommand := args[0]
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", subcommand, err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// redactRepositoryURL masque les identifiants éventuellement présents dans l'URL du dépôt.
+func redactRepositoryURL(repository string) string {
+	parsed, err := url.Parse(repository)
+	if err != nil || parsed.User == nil {
+		return repository
+	}
+	parsed.User = url.User("***")
+	return parsed.String()
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConfigDirEnv est la variable d'environnement listant les répertoires (ou motifs glob) de configuration.
+const ConfigDirEnv = "MINI_BACKUP_CONFIG_DIR"
+
+// ConfigLocation décrit où la configuration des backups est lue.
+// Les entrées sont chargées dans l'ordre : en cas de conflit, la dernière définition l'emporte.
+type ConfigLocation struct {
+	Source  string   `json:"source"`
+	Entries []string `json:"entries"`
+	Dir     string   `json:"dir"`
+	Dirs    []string `json:"dirs"`
+	Files   []string `json:"files"`
+}
+
+var (
+	configFlagMu    sync.RWMutex
+	configFlagValue string
+)
+
+// SetConfigDirFlag enregistre la valeur du flag --config-dir (prioritaire sur l'environnement).
+// La valeur peut contenir plusieurs répertoires ou motifs glob séparés par des virgules.
+func SetConfigDirFlag(value string) {
+	configFlagMu.Lock()
+	defer configFlagMu.Unlock()
+	configFlagValue = value
+}
+
+// configEntries retourne les entrées de configuration et leur provenance :
+// flag --config-dir, puis MINI_BACKUP_CONFIG_DIR, puis le répertoire par défaut.
+func configEntries() (string, []string) {
+	configFlagMu.RLock()
+	flagValue := configFlagValue
+	configFlagMu.RUnlock()
+	if entries := splitConfigEntries(flagValue); len(entries) > 0 {
+		return "flag", entries
+	}
+	if entries := splitConfigEntries(os.Getenv(ConfigDirEnv)); len(entries) > 0 {
+		return "env", entries
+	}
+	if entries := splitConfigEntries(GetEnv[string](ConfigDirEnv)); len(entries) > 0 {
+		return "env", entries
+	}
+	if GetEnv[string]("GO_ENV") == "prod" {
+		return "default", []string{"/etc/backup-tool"}
+	}
+	return "default", []string{"config"}
+}
+
+// splitConfigEntries découpe une liste séparée par des virgules ou par le séparateur de PATH.
+func splitConfigEntries(value string) []string {
+	entries := []string{}
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == os.PathListSeparator }) {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// baseConfigDir retourne le répertoire principal de configuration (écritures de l'API, dépôt git synchronisé),
+// c'est-à-dire le premier répertoire des entrées résolues.
+func baseConfigDir() string {
+	_, entries := configEntries()
+	for _, entry := range entries {
+		if !isGlobPattern(entry) {
+			if info, err := os.Stat(entry); err == nil && !info.IsDir() {
+				return filepath.Dir(entry)
+			}
+			return entry
+		}
+	}
+	return globBase(entries[0])
+}
+
+// GetConfigLocation résout l'emplacement de la configuration des backups.
+func GetConfigLocation() (*ConfigLocation, error) {
+	source, entries := configEntries()
+	if dir := configDirOverrideValue(); dir != "" {
+		source, entries = "git", []string{dir}
+	}
+	files, err := configFilesForEntries(entries)
+	location := &ConfigLocation{
+		Source:  source,
+		Entries: entries,
+		Dir:     GetConfigDir(),
+		Dirs:    configDirsForEntries(entries, files),
+		Files:   files,
+	}
+	return location, err
+}
+
+// configFiles retourne les fichiers de configuration des backups, dans l'ordre de chargement.
+func configFiles() ([]string, error) {
+	location, err := GetConfigLocation()
+	if err != nil {
+		return nil, err
+	}
+	return location.Files, nil
+}
+
+// configFilesForEntries développe les répertoires, fichiers et motifs glob en liste de fichiers.
+// Un répertoire apporte config.yaml puis ses *.backups.yaml ; un même fichier n'est chargé qu'une fois.
+func configFilesForEntries(entries []string) ([]string, error) {
+	paths := []string{}
+	seen := map[string]bool{}
+	addPath := func(path string) {
+		key := path
+		if abs, err := filepath.Abs(path); err == nil {
+			key = abs
+		}
+		if !seen[key] {
+			seen[key] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, entry := range entries {
+		matches := []string{entry}
+		if isGlobPattern(entry) {
+			var err error
+			matches, err = filepath.Glob(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid config pattern %q: %w", entry, err)
+			}
+			sort.Strings(matches)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read config directory: %v", err)
+			}
+			if !info.IsDir() {
+				if isYAMLFile(match) {
+					addPath(match)
+				}
+				continue
+			}
+			dirFiles, err := configFilesInDir(match)
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range dirFiles {
+				addPath(path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// configDirsForEntries retourne les répertoires à surveiller pour détecter les modifications.
+func configDirsForEntries(entries, files []string) []string {
+	dirs := []string{}
+	add := func(dir string) {
+		if !containsString(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, entry := range entries {
+		if isGlobPattern(entry) {
+			add(globBase(entry))
+			continue
+		}
+		if info, err := os.Stat(entry); err == nil && !info.IsDir() {
+			add(filepath.Dir(entry))
+			continue
+		}
+		add(entry)
+	}
+	for _, file := range files {
+		add(filepath.Dir(file))
+	}
+	return dirs
+}
+
+func isGlobPattern(entry string) bool {
+	return strings.ContainsAny(entry, "*?[")
+}
+
+// globBase retourne la partie fixe (sans métacaractère) d'un motif glob.
+func globBase(pattern string) string {
+	dir := pattern
+	for isGlobPattern(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
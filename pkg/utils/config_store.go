@@ -0,0 +1,366 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ManagedConfigFile est le fichier dans lequel l'API enregistre les backups créés ou modifiés.
+	// Les autres fichiers du répertoire ne sont jamais réécrits, leurs commentaires sont donc préservés.
+	ManagedConfigFile = "api.backups.yaml"
+	configVersionsDir = ".versions"
+	configHistoryFile = "history.jsonl"
+)
+
+var (
+	ErrBackupExists     = errors.New("backup already exists")
+	ErrBackupNotFound   = errors.New("backup not found")
+	ErrBackupNotManaged = errors.New("backup is defined in a file not managed by the API")
+	ErrVersionNotFound  = errors.New("configuration version not found")
+)
+
+// ConfigVersion décrit une version enregistrée du fichier géré par l'API.
+type ConfigVersion struct {
+	Version    int       `json:"version"`
+	Timestamp  time.Time `json:"timestamp"`
+	Action     string    `json:"action"`
+	Backup     string    `json:"backup,omitempty"`
+	RollbackOf int       `json:"rollback_of,omitempty"`
+	File       string    `json:"file"`
+}
+
+var managedConfigMu sync.Mutex
+
+// ManagedConfigPath retourne le chemin du fichier de configuration géré par l'API.
+func ManagedConfigPath() string {
+	return filepath.Join(GetConfigDir(), ManagedConfigFile)
+}
+
+// SaveManagedBackup crée (create = true) ou remplace la définition d'un backup dans le fichier géré.
+// La définition (YAML ou JSON) est validée avec le reste de la configuration avant d'être écrite.
+func SaveManagedBackup(name string, definition []byte, create bool) (*ConfigVersion, error) {
+	if configManagedByGit() {
+		return nil, ErrConfigReadOnly
+	}
+	managedConfigMu.Lock()
+	defer managedConfigMu.Unlock()
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(definition, &node); err != nil {
+		return nil, fmt.Errorf("invalid backup definition: %w", err)
+	}
+	if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid backup definition: expected an object")
+	}
+	value := node.Content[0]
+	resetStyle(value)
+
+	location, exists := backupLocations()[name]
+	managedPath := ManagedConfigPath()
+	switch {
+	case create && exists:
+		return nil, fmt.Errorf("%w: %s is defined in %s", ErrBackupExists, name, location)
+	case !create && !exists:
+		return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, name)
+	case !create && location != managedPath:
+		return nil, fmt.Errorf("%w: %s is defined in %s", ErrBackupNotManaged, name, location)
+	}
+
+	doc, err := loadManagedDocument(managedPath)
+	if err != nil {
+		return nil, err
+	}
+	setMappingValue(managedBackups(doc), name, value)
+
+	action := "update"
+	if create {
+		action = "create"
+	}
+	return writeManagedDocument(managedPath, doc, ConfigVersion{Action: action, Backup: name})
+}
+
+// DeleteManagedBackup supprime un backup du fichier géré par l'API.
+func DeleteManagedBackup(name string) (*ConfigVersion, error) {
+	if configManagedByGit() {
+		return nil, ErrConfigReadOnly
+	}
+	managedConfigMu.Lock()
+	defer managedConfigMu.Unlock()
+
+	managedPath := ManagedConfigPath()
+	location, exists := backupLocations()[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, name)
+	}
+	if location != managedPath {
+		return nil, fmt.Errorf("%w: %s is defined in %s", ErrBackupNotManaged, name, location)
+	}
+
+	doc, err := loadManagedDocument(managedPath)
+	if err != nil {
+		return nil, err
+	}
+	removeMappingKey(managedBackups(doc), name)
+	return writeManagedDocument(managedPath, doc, ConfigVersion{Action: "delete", Backup: name})
+}
+
+// RollbackConfigVersion restaure le fichier géré tel qu'il était à la version demandée.
+// Le retour arrière est lui-même enregistré comme une nouvelle version.
+func RollbackConfigVersion(version int) (*ConfigVersion, error) {
+	if configManagedByGit() {
+		return nil, ErrConfigReadOnly
+	}
+	managedConfigMu.Lock()
+	defer managedConfigMu.Unlock()
+
+	versions, err := ListConfigVersions()
+	if err != nil {
+		return nil, err
+	}
+	var target *ConfigVersion
+	for i := range versions {
+		if versions[i].Version == version {
+			target = &versions[i]
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w: %d", ErrVersionNotFound, version)
+	}
+	data, err := os.ReadFile(filepath.Join(GetConfigDir(), configVersionsDir, target.File))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version %d: %w", version, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode version %d: %w", version, err)
+	}
+	if len(doc.Content) == 0 {
+		doc = *emptyManagedDocument()
+	}
+	return writeManagedDocument(ManagedConfigPath(), &doc, ConfigVersion{Action: "rollback", RollbackOf: version})
+}
+
+// ListConfigVersions retourne l'historique des versions du fichier géré, de la plus ancienne à la plus récente.
+func ListConfigVersions() ([]ConfigVersion, error) {
+	versions := []ConfigVersion{}
+	file, err := os.Open(filepath.Join(GetConfigDir(), configVersionsDir, configHistoryFile))
+	if os.IsNotExist(err) {
+		return versions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open configuration history: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var version ConfigVersion
+		if err := json.Unmarshal(scanner.Bytes(), &version); err != nil {
+			return nil, fmt.Errorf("failed to decode configuration history: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions, scanner.Err()
+}
+
+// backupLocations retourne, pour chaque backup, le fichier qui le définit (le dernier chargé l'emporte).
+func backupLocations() map[string]string {
+	locations := map[string]string{}
+	paths, err := configFiles()
+	if err != nil {
+		return locations
+	}
+	report := &ValidationReport{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if file := parseConfigFile(path, data, report); file != nil {
+			for _, entry := range file.Backups {
+				locations[entry.Name] = path
+			}
+		}
+	}
+	return locations
+}
+
+// loadManagedDocument charge le fichier géré ou retourne un document vide s'il n'existe pas.
+func loadManagedDocument(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return emptyManagedDocument(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return emptyManagedDocument(), nil
+	}
+	return &doc, nil
+}
+
+func emptyManagedDocument() *yaml.Node {
+	return &yaml.Node{
+		Kind: yaml.DocumentNode,
+		Content: []*yaml.Node{{
+			Kind:        yaml.MappingNode,
+			HeadComment: "Managed by the mini-backup API: edits are versioned in " + configVersionsDir + "/",
+		}},
+	}
+}
+
+// managedBackups retourne le mapping `backups:` du document, en le créant si besoin.
+func managedBackups(doc *yaml.Node) *yaml.Node {
+	root := doc.Content[0]
+	if backups := mappingValue(root, "backups"); backups != nil && backups.Kind == yaml.MappingNode {
+		return backups
+	}
+	backups := &yaml.Node{Kind: yaml.MappingNode}
+	setMappingValue(root, "backups", backups)
+	return backups
+}
+
+// writeManagedDocument valide le document avec le reste de la configuration, l'écrit de façon
+// atomique puis enregistre la nouvelle version.
+func writeManagedDocument(path string, doc *yaml.Node, version ConfigVersion) (*ConfigVersion, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	encoder.Close()
+	data := buf.Bytes()
+
+	report := validateConfigWith(map[string][]byte{path: data})
+	if err := report.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := recordInitialVersion(path); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return nil, err
+	}
+	if err := recordConfigVersion(&version, data); err != nil {
+		return nil, err
+	}
+	getLogger().Info(fmt.Sprintf("Configuration version %d recorded (%s)", version.Version, version.Action), source_utils)
+	return &version, nil
+}
+
+// recordInitialVersion conserve le contenu du fichier géré avant la première modification versionnée.
+func recordInitialVersion(path string) error {
+	versions, err := ListConfigVersions()
+	if err != nil || len(versions) > 0 {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return recordConfigVersion(&ConfigVersion{Action: "initial"}, data)
+}
+
+// recordConfigVersion enregistre le contenu d'une version et l'ajoute à l'historique.
+func recordConfigVersion(version *ConfigVersion, data []byte) error {
+	dir := filepath.Join(GetConfigDir(), configVersionsDir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("failed to create versions directory: %w", err)
+	}
+	versions, err := ListConfigVersions()
+	if err != nil {
+		return err
+	}
+	version.Version = 1
+	if len(versions) > 0 {
+		version.Version = versions[len(versions)-1].Version + 1
+	}
+	version.Timestamp = time.Now()
+	version.File = fmt.Sprintf("%s.%d", ManagedConfigFile, version.Version)
+	if err := os.WriteFile(filepath.Join(dir, version.File), data, 0640); err != nil {
+		return fmt.Errorf("failed to write version %d: %w", version.Version, err)
+	}
+
+	line, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+	history, err := os.OpenFile(filepath.Join(dir, configHistoryFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open configuration history: %w", err)
+	}
+	defer history.Close()
+	_, err = history.Write(append(line, '\n'))
+	return err
+}
+
+// writeFileAtomic écrit un fichier via un fichier temporaire renommé, pour ne jamais laisser
+// un fichier partiellement écrit (le watcher pourrait le recharger).
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// setMappingValue remplace (ou ajoute) la valeur d'une clé dans un mapping YAML.
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// removeMappingKey supprime une clé d'un mapping YAML.
+func removeMappingKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// resetStyle retire le style du document reçu (objets JSON, chaînes entre guillemets) pour
+// écrire du YAML en style bloc. Les tags sont conservés, l'encodeur ajoute les guillemets nécessaires.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
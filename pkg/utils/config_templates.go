@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolvedBackup est la définition effective d'un backup après application
+// des valeurs par défaut et des templates.
+type resolvedBackup struct {
+	Name string
+	File string
+	Key  *yaml.Node
+	Node *yaml.Node
+}
+
+// resolveBackups applique la section `defaults:` puis la chaîne de templates (`extends:`)
+// à chaque backup. La fusion est profonde : les mappings sont fusionnés récursivement,
+// les scalaires et les listes du niveau le plus spécifique remplacent ceux hérités.
+// L'ordre de priorité est : backup > template (le plus proche d'abord) > defaults.
+func resolveBackups(files []*configFile, report *ValidationReport) []resolvedBackup {
+	var defaults *yaml.Node
+	templates := map[string]configEntry{}
+	templateFiles := map[string]string{}
+	for _, file := range files {
+		if file.Defaults != nil {
+			defaults = mergeNodes(defaults, file.Defaults, report)
+		}
+		for _, template := range file.Templates {
+			if previous, exists := templateFiles[template.Name]; exists {
+				report.add(file.Path, template.Key, "", "templates."+template.Name, SeverityWarning, "overrides the template defined in %s", previous)
+			}
+			templates[template.Name] = template
+			templateFiles[template.Name] = fmt.Sprintf("%s:%d", file.Path, template.Key.Line)
+		}
+	}
+
+	resolved := []resolvedBackup{}
+	for _, file := range files {
+		for _, entry := range file.Backups {
+			if entry.Value.Kind != yaml.MappingNode {
+				resolved = append(resolved, resolvedBackup{Name: entry.Name, File: file.Path, Key: entry.Key, Node: entry.Value})
+				continue
+			}
+			chain, ok := templateChain(file.Path, entry, templates, report)
+			if !ok {
+				continue
+			}
+			node := defaults
+			for i := len(chain) - 1; i >= 0; i-- {
+				node = mergeNodes(node, withoutKey(chain[i], "extends"), report)
+			}
+			node = mergeNodes(node, withoutKey(entry.Value, "extends"), report)
+			resolved = append(resolved, resolvedBackup{Name: entry.Name, File: file.Path, Key: entry.Key, Node: node})
+		}
+	}
+	return resolved
+}
+
+// templateChain retourne les templates hérités par un backup, du plus proche au plus lointain.
+func templateChain(path string, entry configEntry, templates map[string]configEntry, report *ValidationReport) ([]*yaml.Node, bool) {
+	chain := []*yaml.Node{}
+	visited := map[string]bool{}
+	current := entry.Value
+	for {
+		extendsNode := mappingValue(current, "extends")
+		if extendsNode == nil {
+			return chain, true
+		}
+		if extendsNode.Kind != yaml.ScalarNode {
+			report.add(path, extendsNode, entry.Name, "extends", SeverityError, "must be the name of a template")
+			return nil, false
+		}
+		if extendsNode.Value == "" {
+			return chain, true
+		}
+		name := extendsNode.Value
+		if visited[name] {
+			report.add(path, extendsNode, entry.Name, "extends", SeverityError, "template inheritance cycle through %q", name)
+			return nil, false
+		}
+		visited[name] = true
+		template, exists := templates[name]
+		if !exists {
+			report.add(path, extendsNode, entry.Name, "extends", SeverityError, "unknown template %q", name)
+			return nil, false
+		}
+		if template.Value.Kind != yaml.MappingNode {
+			report.add(path, template.Value, entry.Name, "templates."+name, SeverityError, "template must be a mapping")
+			return nil, false
+		}
+		chain = append(chain, template.Value)
+		current = template.Value
+	}
+}
+
+// mergeNodes fusionne override dans base sans modifier les noeuds sources.
+// Les positions des noeuds sont conservées pour localiser les erreurs dans le fichier d'origine.
+func mergeNodes(base, override *yaml.Node, report *ValidationReport) *yaml.Node {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	merged := *override
+	merged.Content = nil
+	report.inherit(&merged, override)
+
+	overrideKeys := map[string]int{}
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		overrideKeys[override.Content[i].Value] = i
+	}
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		key, value := base.Content[i], base.Content[i+1]
+		if j, exists := overrideKeys[key.Value]; exists {
+			merged.Content = append(merged.Content, override.Content[j], mergeNodes(value, override.Content[j+1], report))
+			continue
+		}
+		merged.Content = append(merged.Content, key, value)
+	}
+	baseKeys := map[string]bool{}
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		baseKeys[base.Content[i].Value] = true
+	}
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		if !baseKeys[override.Content[i].Value] {
+			merged.Content = append(merged.Content, override.Content[i], override.Content[i+1])
+		}
+	}
+	return &merged
+}
+
+// withoutKey retourne une copie du mapping sans la clé donnée.
+func withoutKey(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode || mappingValue(node, key) == nil {
+		return node
+	}
+	copied := *node
+	copied.Content = nil
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			copied.Content = append(copied.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+	return &copied
+}
+
+// mappingValue retourne la valeur associée à une clé d'un mapping, ou nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// registerOrigins associe chaque noeud d'un document à son fichier, afin que les erreurs
+// portant sur une valeur héritée pointent vers le fichier qui la définit.
+func (r *ValidationReport) registerOrigins(node *yaml.Node, file string) {
+	if r.origins == nil {
+		r.origins = map[*yaml.Node]string{}
+	}
+	r.origins[node] = file
+	for _, child := range node.Content {
+		r.registerOrigins(child, file)
+	}
+}
+
+// inherit attribue à un noeud copié le fichier d'origine du noeud source.
+func (r *ValidationReport) inherit(copied, source *yaml.Node) {
+	if file, ok := r.origins[source]; ok {
+		r.origins[copied] = file
+	}
+}
+
+// originOf retourne le fichier d'origine d'un noeud, ou fallback s'il est inconnu.
+func (r *ValidationReport) originOf(node *yaml.Node, fallback string) string {
+	if file, ok := r.origins[node]; ok && node != nil {
+		return file
+	}
+	return fallback
+}
@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// resolveTestFiles résout les backups de documents YAML chargés dans l'ordre (1.yaml, 2.yaml...).
+func resolveTestFiles(t *testing.T, documents ...string) (map[string]Backup, *ValidationReport) {
+	t.Helper()
+	report := &ValidationReport{}
+	files := []*configFile{}
+	for i, document := range documents {
+		file := parseConfigFile(fmt.Sprintf("%d.yaml", i+1), []byte(document), report)
+		if file == nil {
+			t.Fatalf("failed to parse document %d: %v", i+1, report.Issues)
+		}
+		files = append(files, file)
+	}
+	backups := map[string]Backup{}
+	for _, resolved := range resolveBackups(files, report) {
+		var backup Backup
+		if err := resolved.Node.Decode(&backup); err != nil {
+			t.Fatalf("failed to decode backup %s: %v", resolved.Name, err)
+		}
+		backups[resolved.Name] = backup
+	}
+	return backups, report
+}
+
+func TestResolveBackupsPrecedence(t *testing.T) {
+	backups, report := resolveTestFiles(t, `
+defaults:
+  type: mysql
+  retention:
+    standard:
+      days: 7
+  mysql:
+    port: "3306"
+templates:
+  prod:
+    retention:
+      standard:
+        days: 30
+    mysql:
+      host: db.prod
+backups:
+  plain: {}
+  templated:
+    extends: prod
+  overridden:
+    extends: prod
+    retention:
+      standard:
+        days: 90
+    mysql:
+      user: backup
+`)
+	if len(report.Issues) != 0 {
+		t.Fatalf("expected no issue, got %v", report.Issues)
+	}
+	tests := []struct {
+		name  string
+		days  int
+		mysql Mysql
+	}{
+		{"plain", 7, Mysql{Port: "3306"}},
+		{"templated", 30, Mysql{Port: "3306", Host: "db.prod"}},
+		{"overridden", 90, Mysql{Port: "3306", Host: "db.prod", User: "backup"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup, exists := backups[tt.name]
+			if !exists {
+				t.Fatalf("backup %s not resolved", tt.name)
+			}
+			if backup.Type != "mysql" {
+				t.Errorf("type: got %q, want mysql", backup.Type)
+			}
+			if backup.Retention.Standard.Days != tt.days {
+				t.Errorf("retention: got %d, want %d", backup.Retention.Standard.Days, tt.days)
+			}
+			if backup.Mysql == nil || !reflect.DeepEqual(*backup.Mysql, tt.mysql) {
+				t.Errorf("mysql: got %+v, want %+v", backup.Mysql, tt.mysql)
+			}
+			if backup.Extends != "" {
+				t.Errorf("extends should not be kept, got %q", backup.Extends)
+			}
+		})
+	}
+}
+
+func TestResolveBackupsTemplateChain(t *testing.T) {
+	backups, report := resolveTestFiles(t, `
+templates:
+  base:
+    type: folder
+    schedule:
+      standard: "0 1 * * *"
+    path:
+      local: ./backups
+  nightly:
+    extends: base
+    schedule:
+      standard: "0 3 * * *"
+backups:
+  files:
+    extends: nightly
+    path:
+      s3: backups/files
+`)
+	if len(report.Issues) != 0 {
+		t.Fatalf("expected no issue, got %v", report.Issues)
+	}
+	backup := backups["files"]
+	if backup.Type != "folder" {
+		t.Errorf("type: got %q, want folder", backup.Type)
+	}
+	if backup.Schedule.Standard != "0 3 * * *" {
+		t.Errorf("the nearest template should win, got schedule %q", backup.Schedule.Standard)
+	}
+	if backup.Path != (Path{Local: "./backups", S3: "backups/files"}) {
+		t.Errorf("path: got %+v", backup.Path)
+	}
+}
+
+func TestResolveBackupsListsAreReplaced(t *testing.T) {
+	backups, _ := resolveTestFiles(t, `
+defaults:
+  type: folder
+  folder: [/srv/a, /srv/b]
+backups:
+  files:
+    folder: [/srv/c]
+`)
+	if got := backups["files"].Folder; !reflect.DeepEqual(got, []string{"/srv/c"}) {
+		t.Fatalf("lists should be replaced, got %v", got)
+	}
+}
+
+func TestResolveBackupsAcrossFiles(t *testing.T) {
+	backups, report := resolveTestFiles(t, `
+defaults:
+  type: mysql
+  retention:
+    standard:
+      days: 7
+templates:
+  prod:
+    mysql:
+      host: old.prod
+backups:
+  first:
+    extends: prod
+`, `
+defaults:
+  retention:
+    standard:
+      days: 14
+templates:
+  prod:
+    mysql:
+      host: db.prod
+backups:
+  second:
+    extends: prod
+`)
+	for _, name := range []string{"first", "second"} {
+		backup := backups[name]
+		if backup.Type != "mysql" || backup.Retention.Standard.Days != 14 {
+			t.Errorf("%s: defaults of every file should be merged, got type %q and %d days", name, backup.Type, backup.Retention.Standard.Days)
+		}
+		if backup.Mysql == nil || backup.Mysql.Host != "db.prod" {
+			t.Errorf("%s: the last template definition should win, got %+v", name, backup.Mysql)
+		}
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Severity != SeverityWarning || report.Issues[0].File != "2.yaml" || report.Issues[0].Field != "templates.prod" {
+		t.Fatalf("expected a warning on the overridden template, got %v", report.Issues)
+	}
+}
+
+func TestResolveBackupsDoesNotModifySources(t *testing.T) {
+	backups, _ := resolveTestFiles(t, `
+defaults:
+  mysql:
+    port: "3306"
+backups:
+  first:
+    mysql:
+      host: first
+  second:
+    mysql:
+      host: second
+`)
+	if backups["first"].Mysql.Host != "first" || backups["second"].Mysql.Host != "second" {
+		t.Fatalf("backups should not share merged values, got %+v and %+v", backups["first"].Mysql, backups["second"].Mysql)
+	}
+	if backups["second"].Mysql.Port != "3306" {
+		t.Fatalf("defaults should apply to every backup, got %+v", backups["second"].Mysql)
+	}
+}
+
+func TestResolveBackupsRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		field    string
+		message  string
+	}{
+		{"unknown template", `
+backups:
+  db:
+    extends: missing
+`, "extends", `unknown template "missing"`},
+		{"inheritance cycle", `
+templates:
+  a:
+    extends: b
+  b:
+    extends: a
+backups:
+  db:
+    extends: a
+`, "extends", "template inheritance cycle"},
+		{"extends not a name", `
+backups:
+  db:
+    extends: [base]
+`, "extends", "must be the name of a template"},
+		{"template not a mapping", `
+templates:
+  base: mysql
+backups:
+  db:
+    extends: base
+`, "templates.base", "template must be a mapping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backups, report := resolveTestFiles(t, tt.document)
+			if _, exists := backups["db"]; exists {
+				t.Fatalf("backup db should not be resolved")
+			}
+			found := false
+			for _, issue := range report.Issues {
+				if issue.Severity == SeverityError && issue.Backup == "db" && issue.Field == tt.field && strings.Contains(issue.Message, tt.message) {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("expected an error on %s containing %q, got %v", tt.field, tt.message, report.Issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,695 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mini-backup/pkg/jobs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// SupportedBackupTypes liste les valeurs acceptées pour le champ `type` d'un backup.
+var SupportedBackupTypes = []string{"mysql", "folder", "s3", "mongo", "sqlite", "kubernetes"}
+
+// IsSupportedBackupType indique si le type de backup est pris en charge.
+func IsSupportedBackupType(backupType string) bool {
+	for _, t := range SupportedBackupTypes {
+		if t == backupType {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidationIssue décrit un problème détecté dans un fichier de configuration.
+type ValidationIssue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Backup   string `json:"backup,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// String formate le problème au format fichier:ligne:colonne utilisé par les compilateurs.
+func (i ValidationIssue) String() string {
+	location := i.File
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Column)
+	}
+	subject := ""
+	if i.Backup != "" {
+		subject = fmt.Sprintf("backup %s: ", i.Backup)
+	}
+	if i.Field != "" {
+		subject += i.Field + ": "
+	}
+	return fmt.Sprintf("%s: %s: %s%s", location, i.Severity, subject, i.Message)
+}
+
+// ValidationReport regroupe les problèmes détectés lors de la validation.
+type ValidationReport struct {
+	Files  []string          `json:"files"`
+	Issues []ValidationIssue `json:"issues"`
+
+	origins map[*yaml.Node]string
+}
+
+// HasErrors indique si le rapport contient au moins une erreur bloquante.
+func (r *ValidationReport) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Err retourne une ValidationError si le rapport contient des erreurs, nil sinon.
+func (r *ValidationReport) Err() error {
+	if r.HasErrors() {
+		return &ValidationError{Report: r}
+	}
+	return nil
+}
+
+// ValidationError est retournée lorsqu'une configuration est rejetée par le validateur.
+type ValidationError struct {
+	Report *ValidationReport
+}
+
+func (e *ValidationError) Error() string {
+	messages := []string{}
+	for _, issue := range e.Report.Issues {
+		if issue.Severity == SeverityError {
+			messages = append(messages, issue.String())
+		}
+	}
+	return fmt.Sprintf("invalid configuration (%d errors): %s", len(messages), strings.Join(messages, "; "))
+}
+
+func (r *ValidationReport) add(file string, node *yaml.Node, backup, field, severity, format string, args ...any) {
+	issue := ValidationIssue{
+		File:     r.originOf(node, file),
+		Backup:   backup,
+		Field:    field,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if node != nil {
+		issue.Line = node.Line
+		issue.Column = node.Column
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+// configFile est la représentation brute (avec positions) d'un fichier de configuration.
+type configFile struct {
+	Path      string
+	Defaults  *yaml.Node
+	Templates []configEntry
+	Backups   []configEntry
+}
+
+// configEntry associe le nom d'un backup à ses noeuds YAML.
+type configEntry struct {
+	Name  string
+	Key   *yaml.Node
+	Value *yaml.Node
+}
+
+// configFilesInDir retourne, dans l'ordre de chargement, les fichiers de configuration d'un répertoire.
+func configFilesInDir(configDir string) ([]string, error) {
+	files, err := os.ReadDir(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory: %v", err)
+	}
+	paths := []string{}
+	mainConfigPath := filepath.Join(configDir, "config.yaml")
+	if _, err := os.Stat(mainConfigPath); err == nil {
+		paths = append(paths, mainConfigPath)
+	}
+	for _, file := range files {
+		if !file.IsDir() && (strings.HasSuffix(file.Name(), ".backups.yaml") || strings.HasSuffix(file.Name(), ".backups.yml")) {
+			paths = append(paths, filepath.Join(configDir, file.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// parseConfigFile analyse un fichier de configuration en conservant les positions des noeuds.
+func parseConfigFile(path string, data []byte, report *ValidationReport) *configFile {
+	file := &configFile{Path: path}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		report.add(path, nil, "", "", SeverityError, "invalid YAML: %v", err)
+		return nil
+	}
+	if len(root.Content) == 0 {
+		report.add(path, nil, "", "", SeverityWarning, "file is empty")
+		return file
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		report.add(path, doc, "", "", SeverityError, "top-level document must be a mapping")
+		return nil
+	}
+	report.registerOrigins(doc, path)
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		switch key.Value {
+		case "defaults":
+			if value.Kind != yaml.MappingNode {
+				report.add(path, value, "", "defaults", SeverityError, "must be a mapping")
+				continue
+			}
+			file.Defaults = value
+		case "templates":
+			if value.Kind != yaml.MappingNode {
+				report.add(path, value, "", "templates", SeverityError, "must be a mapping of template names to definitions")
+				continue
+			}
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				file.Templates = append(file.Templates, configEntry{
+					Name:  value.Content[j].Value,
+					Key:   value.Content[j],
+					Value: value.Content[j+1],
+				})
+			}
+		case "backups":
+			if value.Kind != yaml.MappingNode {
+				report.add(path, value, "", "backups", SeverityError, "must be a mapping of backup names to definitions")
+				continue
+			}
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				file.Backups = append(file.Backups, configEntry{
+					Name:  value.Content[j].Value,
+					Key:   value.Content[j],
+					Value: value.Content[j+1],
+				})
+			}
+		default:
+			report.add(path, key, "", key.Value, SeverityWarning, "unknown top-level key (ignored)")
+		}
+	}
+	return file
+}
+
+// ValidateConfigDir valide l'ensemble des fichiers de configuration d'un répertoire.
+func ValidateConfigDir(configDir string) *ValidationReport {
+	return validateConfigEntries([]string{configDir}, nil)
+}
+
+// ValidateConfigFiles valide ensemble les fichiers donnés, dans cet ordre : comme au chargement,
+// un backup peut étendre un template ou hériter des valeurs par défaut d'un autre fichier.
+func ValidateConfigFiles(paths []string) *ValidationReport {
+	report := &ValidationReport{Files: []string{}, Issues: []ValidationIssue{}}
+	files := []*configFile{}
+	for _, path := range paths {
+		report.Files = append(report.Files, path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			report.add(path, nil, "", "", SeverityError, "failed to read file: %v", err)
+			continue
+		}
+		if file := parseConfigFile(path, data, report); file != nil {
+			files = append(files, file)
+		}
+	}
+	validateConfigFiles(files, report)
+	return report
+}
+
+// ValidateConfig valide la configuration telle qu'elle est chargée par le serveur
+// (flag --config-dir, MINI_BACKUP_CONFIG_DIR ou répertoire par défaut).
+func ValidateConfig() *ValidationReport {
+	return validateConfigWith(nil)
+}
+
+// validateConfigWith valide la configuration courante en remplaçant le contenu de certains fichiers
+// (chemin -> contenu). Permet de vérifier une modification avant de l'écrire sur le disque.
+func validateConfigWith(overrides map[string][]byte) *ValidationReport {
+	location, _ := GetConfigLocation()
+	return validateConfigEntries(location.Entries, overrides)
+}
+
+// validateConfigEntries valide les fichiers des répertoires, fichiers ou motifs glob donnés.
+// Les backups définis plusieurs fois sont signalés avec l'emplacement de la définition remplacée.
+func validateConfigEntries(entries []string, overrides map[string][]byte) *ValidationReport {
+	report := &ValidationReport{Files: []string{}, Issues: []ValidationIssue{}}
+	paths, err := configFilesForEntries(entries)
+	if err != nil {
+		report.add(strings.Join(entries, ", "), nil, "", "", SeverityError, "%v", err)
+		return report
+	}
+	for path := range overrides {
+		if !containsString(paths, path) {
+			paths = insertConfigPath(paths, path)
+		}
+	}
+	files := []*configFile{}
+	for _, path := range paths {
+		report.Files = append(report.Files, path)
+		data, overridden := overrides[path]
+		if !overridden {
+			data, err = os.ReadFile(path)
+			if err != nil {
+				report.add(path, nil, "", "", SeverityError, "failed to read file: %v", err)
+				continue
+			}
+		}
+		if file := parseConfigFile(path, data, report); file != nil {
+			files = append(files, file)
+		}
+	}
+	validateConfigFiles(files, report)
+	if len(paths) == 0 {
+		report.add(strings.Join(entries, ", "), nil, "", "", SeverityError, "no backup configuration file found")
+	}
+	return report
+}
+
+// insertConfigPath ajoute un fichier parmi ceux de son répertoire, à sa place dans l'ordre de
+// chargement (config.yaml puis ordre alphabétique), ou à la fin si le répertoire n'est pas chargé.
+func insertConfigPath(paths []string, path string) []string {
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	position := -1
+	for i, existing := range paths {
+		if filepath.Dir(existing) != dir {
+			continue
+		}
+		if existing := filepath.Base(existing); existing == "config.yaml" || existing < name {
+			position = i + 1
+		} else if position == -1 {
+			position = i
+		}
+	}
+	if position == -1 {
+		position = len(paths)
+	}
+	return append(paths[:position], append([]string{path}, paths[position:]...)...)
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateConfigData valide un unique document de configuration fourni en mémoire.
+func ValidateConfigData(name string, data []byte) *ValidationReport {
+	report := &ValidationReport{Files: []string{name}, Issues: []ValidationIssue{}}
+	if file := parseConfigFile(name, data, report); file != nil {
+		validateConfigFiles([]*configFile{file}, report)
+	}
+	return report
+}
+
+func validateConfigFiles(files []*configFile, report *ValidationReport) {
+	// Les champs inconnus sont vérifiés sur les définitions brutes pour n'être signalés qu'une fois.
+	backupType := reflect.TypeOf(Backup{})
+	for _, file := range files {
+		if file.Defaults != nil {
+			checkUnknownFields(file.Path, "", "defaults", file.Defaults, backupType, report)
+		}
+		for _, template := range file.Templates {
+			checkUnknownFields(file.Path, "", "templates."+template.Name, template.Value, backupType, report)
+		}
+		for _, entry := range file.Backups {
+			if entry.Value.Kind == yaml.MappingNode {
+				checkUnknownFields(file.Path, entry.Name, "", entry.Value, backupType, report)
+			}
+		}
+	}
+
+	seen := map[string]string{}
+	resolved := resolveBackups(files, report)
+	for _, backup := range resolved {
+		if previous, exists := seen[backup.Name]; exists {
+			report.add(backup.File, backup.Key, backup.Name, "", SeverityWarning, "overrides the definition from %s", previous)
+		}
+		seen[backup.Name] = fmt.Sprintf("%s:%d", backup.File, backup.Key.Line)
+		validateBackupEntry(backup.File, configEntry{Name: backup.Name, Key: backup.Key, Value: backup.Node}, report)
+	}
+	total := 0
+	for _, file := range files {
+		total += len(file.Backups)
+	}
+	if total == 0 && len(files) > 0 {
+		report.add(files[0].Path, nil, "", "", SeverityError, "no backup defined")
+	}
+}
+
+// validateBackupEntry vérifie la définition d'un backup : champs inconnus, champs requis par type,
+// expressions cron, chemins, rétention, chevauchement, heartbeat et hooks.
+func validateBackupEntry(path string, entry configEntry, report *ValidationReport) {
+	name, node := entry.Name, entry.Value
+	if node.Kind != yaml.MappingNode {
+		report.add(path, node, name, "", SeverityError, "backup definition must be a mapping")
+		return
+	}
+
+	var backup Backup
+	if err := node.Decode(&backup); err != nil {
+		reportDecodeError(path, name, node, err, report)
+		return
+	}
+
+	errorAt := func(field, format string, args ...any) {
+		report.add(path, fieldNode(node, field), name, field, SeverityError, format, args...)
+	}
+	warnAt := func(field, format string, args ...any) {
+		report.add(path, fieldNode(node, field), name, field, SeverityWarning, format, args...)
+	}
+
+	switch {
+	case backup.Type == "":
+		errorAt("type", "is required (one of %s)", strings.Join(SupportedBackupTypes, ", "))
+	case !IsSupportedBackupType(backup.Type):
+		errorAt("type", "unsupported backup type %q (expected one of %s)", backup.Type, strings.Join(SupportedBackupTypes, ", "))
+	}
+
+	switch backup.Type {
+	case "mysql":
+		if backup.Mysql == nil {
+			errorAt("mysql", "block is required for mysql backups")
+			break
+		}
+		if backup.Mysql.Host == "" {
+			errorAt("mysql.host", "is required")
+		}
+		if backup.Mysql.User == "" {
+			errorAt("mysql.user", "is required")
+		}
+		if backup.Mysql.Port != "" {
+			if _, err := strconv.Atoi(backup.Mysql.Port); err != nil {
+				errorAt("mysql.port", "must be a number, got %q", backup.Mysql.Port)
+			}
+		}
+		if !backup.Mysql.All && len(backup.Mysql.Databases) == 0 {
+			errorAt("mysql.databases", "at least one database is required unless `all: true`")
+		}
+	case "mongo":
+		if backup.Mongo == nil {
+			errorAt("mongo", "block is required for mongo backups")
+			break
+		}
+		if backup.Mongo.Host == "" {
+			errorAt("mongo.host", "is required")
+		}
+		if backup.Mongo.User == "" {
+			errorAt("mongo.user", "is required")
+		}
+		if backup.Mongo.Port != "" {
+			if _, err := strconv.Atoi(backup.Mongo.Port); err != nil {
+				errorAt("mongo.port", "must be a number, got %q", backup.Mongo.Port)
+			}
+		}
+	case "folder":
+		if len(backup.Folder) == 0 {
+			errorAt("folder", "at least one folder is required")
+		}
+		for _, folder := range backup.Folder {
+			if _, err := os.Stat(folder); err != nil {
+				warnAt("folder", "folder %s is not accessible from this host: %v", folder, err)
+			}
+		}
+	case "s3":
+		if backup.S3.Endpoint == "" {
+			errorAt("s3.endpoint", "is required")
+		}
+		if !backup.S3.All && len(backup.S3.Bucket) == 0 {
+			errorAt("s3.bucket", "at least one bucket is required unless `all: true`")
+		}
+		if backup.S3.ACCESS_KEY == "" || backup.S3.SECRET_KEY == "" {
+			errorAt("s3", "ACCESS_KEY and SECRET_KEY are required")
+		}
+	case "sqlite":
+		if backup.Sqlite == nil || len(backup.Sqlite.Paths) == 0 {
+			errorAt("sqlite.paths", "at least one database path is required")
+		}
+	case "kubernetes":
+		if backup.Kubernetes == nil {
+			errorAt("kubernetes", "block is required for kubernetes backups")
+			break
+		}
+		if backup.Kubernetes.Cluster.Backup != "" && backup.Kubernetes.Cluster.Backup != "auto" {
+			errorAt("kubernetes.cluster.backup", "unsupported value %q (expected \"auto\" or empty)", backup.Kubernetes.Cluster.Backup)
+		}
+		if !backup.Kubernetes.Volumes.Enabled && backup.Kubernetes.Cluster.Backup != "auto" {
+			warnAt("kubernetes", "neither volumes nor cluster state are enabled, the backup will be empty")
+		}
+	}
+
+	// Planification
+	if backup.Schedule.Standard != "" {
+		if _, err := cron.ParseStandard(backup.Schedule.Standard); err != nil {
+			errorAt("schedule.standard", "invalid cron expression %q: %v", backup.Schedule.Standard, err)
+		}
+	}
+	if backup.Schedule.Glacier != "" {
+		if _, err := cron.ParseStandard(backup.Schedule.Glacier); err != nil {
+			errorAt("schedule.glacier", "invalid cron expression %q: %v", backup.Schedule.Glacier, err)
+		}
+	}
+
+	// Chemins
+	if backup.Path.Local == "" {
+		errorAt("path.local", "is required")
+	}
+	if backup.Path.S3 == "" {
+		errorAt("path.s3", "is required")
+	} else {
+		if strings.HasPrefix(backup.Path.S3, "/") {
+			warnAt("path.s3", "should not start with '/', object keys would begin with an empty segment")
+		}
+		if strings.Contains(backup.Path.S3, "..") {
+			errorAt("path.s3", "must not contain '..'")
+		}
+	}
+
+	// Rétention
+	if backup.Retention.Standard.Days < 0 {
+		errorAt("retention.standard.days", "must be positive, got %d", backup.Retention.Standard.Days)
+	} else if backup.Retention.Standard.Days == 0 {
+		warnAt("retention.standard.days", "is not set, every previous backup under %s will be deleted on each run", backup.Path.S3)
+	}
+	if backup.Retention.Glacier.Days < 0 {
+		errorAt("retention.glacier.days", "must be positive, got %d", backup.Retention.Glacier.Days)
+	}
+	if backup.Retention.Glacier.Days > 0 && backup.Schedule.Glacier == "" {
+		warnAt("retention.glacier.days", "is set but no glacier schedule is defined")
+	}
+
+	if backup.Overlap != "" && !jobs.IsValidOverlap(backup.Overlap) {
+		errorAt("overlap", "unsupported policy %q (expected one of %s)", backup.Overlap, strings.Join(OverlapPolicies, ", "))
+	}
+
+	if backup.Heartbeat != nil {
+		heartbeat := backup.Heartbeat
+		if heartbeat.Style != "" && !containsString(HeartbeatStyles, heartbeat.Style) {
+			errorAt("heartbeat.style", "unsupported style %q (expected one of %s)", heartbeat.Style, strings.Join(HeartbeatStyles, ", "))
+		}
+		if heartbeat.URL == "" && heartbeat.Start == "" && heartbeat.Success == "" && heartbeat.Failure == "" {
+			errorAt("heartbeat", "url (or start, success, failure) is required")
+		}
+		for _, field := range []struct{ name, value string }{
+			{"url", heartbeat.URL}, {"start", heartbeat.Start}, {"success", heartbeat.Success}, {"failure", heartbeat.Failure},
+		} {
+			if field.value == "" {
+				continue
+			}
+			if parsed, err := url.Parse(field.value); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				errorAt("heartbeat."+field.name, "must be an http(s) URL, got %q", field.value)
+			}
+		}
+	}
+
+	for _, stage := range []string{HookPreBackup, HookPostBackup, HookPreRestore, HookPostRestore, HookOnFailure} {
+		for i, hook := range backup.Hooks.Stage(stage) {
+			field := fmt.Sprintf("hooks.%s[%d]", stage, i)
+			if (hook.Command == "") == (hook.URL == "") {
+				errorAt(field, "exactly one of command or url is required")
+			}
+			if parsed, err := url.Parse(hook.URL); hook.URL != "" && (err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "") {
+				errorAt(field+".url", "must be an http(s) URL, got %q", hook.URL)
+			}
+			if timeout, err := time.ParseDuration(hook.Timeout); hook.Timeout != "" && (err != nil || timeout <= 0) {
+				errorAt(field+".timeout", "invalid duration %q (expected e.g. 30s or 5m)", hook.Timeout)
+			}
+			if hook.OnError != "" && !containsString(HookErrorPolicies, hook.OnError) {
+				errorAt(field+".on_error", "unsupported policy %q (expected one of %s)", hook.OnError, strings.Join(HookErrorPolicies, ", "))
+			}
+		}
+	}
+}
+
+// checkUnknownFields signale les clés qui ne correspondent à aucun champ de la structure cible.
+func checkUnknownFields(path, backup, prefix string, node *yaml.Node, t reflect.Type, report *ValidationReport) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := key.Value
+			if prefix != "" {
+				fieldPath = prefix + "." + key.Value
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				report.add(path, key, backup, fieldPath, SeverityWarning, "unknown field (ignored)%s", suggestField(key.Value, fields))
+				continue
+			}
+			checkUnknownFields(path, backup, fieldPath, value, field.Type, report)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, item := range node.Content {
+			checkUnknownFields(path, backup, prefix, item, t.Elem(), report)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkUnknownFields(path, backup, prefix+"."+node.Content[i].Value, node.Content[i+1], t.Elem(), report)
+		}
+	}
+}
+
+// yamlFields retourne les champs exportés d'une structure indexés par leur nom YAML.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// suggestField propose un champ connu lorsque la clé ne diffère que par la casse.
+func suggestField(key string, fields map[string]reflect.StructField) string {
+	for name := range fields {
+		if strings.EqualFold(name, key) {
+			return fmt.Sprintf(", did you mean %q?", name)
+		}
+	}
+	return ""
+}
+
+// fieldNode retourne le noeud correspondant au chemin pointé (ex: "mysql.host"),
+// ou le noeud parent le plus proche si le champ est absent.
+func fieldNode(node *yaml.Node, field string) *yaml.Node {
+	current := node
+	for _, part := range strings.Split(field, ".") {
+		// name[i] désigne le i-ème élément de la liste name
+		index := -1
+		if open := strings.IndexByte(part, '['); open > 0 && strings.HasSuffix(part, "]") {
+			if n, err := strconv.Atoi(part[open+1 : len(part)-1]); err == nil {
+				part, index = part[:open], n
+			}
+		}
+		if current.Kind != yaml.MappingNode {
+			return current
+		}
+		found := false
+		for i := 0; i+1 < len(current.Content); i += 2 {
+			if current.Content[i].Value == part {
+				current = current.Content[i+1]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return current
+		}
+		if index >= 0 {
+			if current.Kind != yaml.SequenceNode || index >= len(current.Content) {
+				return current
+			}
+			current = current.Content[index]
+		}
+	}
+	return current
+}
+
+var decodeLineRegex = regexp.MustCompile(`line (\d+): (.*)`)
+
+// reportDecodeError convertit les erreurs de typage YAML en problèmes localisés.
+func reportDecodeError(path, backup string, node *yaml.Node, err error, report *ValidationReport) {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		report.add(path, node, backup, "", SeverityError, "%v", err)
+		return
+	}
+	for _, message := range typeErr.Errors {
+		issue := ValidationIssue{File: path, Backup: backup, Severity: SeverityError, Message: message}
+		if match := decodeLineRegex.FindStringSubmatch(message); match != nil {
+			issue.Line, _ = strconv.Atoi(match[1])
+			issue.Message = match[2]
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+}
+
+// SortIssues trie les problèmes par fichier puis par position.
+func (r *ValidationReport) SortIssues() {
+	sort.SliceStable(r.Issues, func(i, j int) bool {
+		a, b := r.Issues[i], r.Issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// FormatIssues retourne le rapport sous forme de texte, un problème par ligne.
+func (r *ValidationReport) FormatIssues() string {
+	var buf bytes.Buffer
+	for _, issue := range r.Issues {
+		buf.WriteString(issue.String())
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
@@ -0,0 +1,324 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// testBackup retourne un backup mysql valide, sans aucun avertissement.
+func testBackup() map[string]any {
+	return map[string]any{
+		"type":      "mysql",
+		"mysql":     map[string]any{"host": "localhost", "user": "root", "port": "3306", "databases": []string{"app"}},
+		"path":      map[string]any{"local": "./backups", "s3": "backups/db"},
+		"retention": map[string]any{"standard": map[string]any{"days": 7}},
+		"schedule":  map[string]any{"standard": "0 3 * * *"},
+	}
+}
+
+// setField affecte une valeur au chemin pointé (ex: "mysql.host"), en créant les mappings manquants.
+func setField(backup map[string]any, field string, value any) {
+	parts := strings.Split(field, ".")
+	current := backup
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// deleteField retire le champ pointé s'il existe.
+func deleteField(backup map[string]any, field string) {
+	parts := strings.Split(field, ".")
+	current := backup
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]any)
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, parts[len(parts)-1])
+}
+
+func validateTestBackup(t *testing.T, backup map[string]any) *ValidationReport {
+	t.Helper()
+	data, err := yaml.Marshal(map[string]any{"backups": map[string]any{"db": backup}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ValidateConfigData("test.yaml", data)
+}
+
+func TestValidateBackupValid(t *testing.T) {
+	folder := t.TempDir()
+	tests := []struct {
+		name   string
+		mutate func(b map[string]any)
+	}{
+		{"mysql", func(b map[string]any) {}},
+		{"mysql all databases", func(b map[string]any) {
+			deleteField(b, "mysql.databases")
+			setField(b, "mysql.all", true)
+		}},
+		{"mongo", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "mongo"
+			b["mongo"] = map[string]any{"host": "localhost", "user": "root", "port": "27017"}
+		}},
+		{"folder", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "folder"
+			b["folder"] = []string{folder}
+		}},
+		{"s3", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "s3"
+			b["s3"] = map[string]any{"endpoint": "https://s3.example.com", "bucket": []string{"data"}, "ACCESS_KEY": "key", "SECRET_KEY": "secret"}
+		}},
+		{"sqlite", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "sqlite"
+			b["sqlite"] = map[string]any{"paths": []string{"/var/lib/app.db"}}
+		}},
+		{"kubernetes", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "kubernetes"
+			b["kubernetes"] = map[string]any{"cluster": map[string]any{"backup": "auto"}}
+		}},
+		{"glacier", func(b map[string]any) {
+			setField(b, "schedule.glacier", "0 4 1 * *")
+			setField(b, "retention.glacier.days", 365)
+		}},
+		{"overlap, heartbeat and hooks", func(b map[string]any) {
+			b["overlap"] = "skip"
+			b["heartbeat"] = map[string]any{"url": "https://hc-ping.com/uuid", "style": "healthchecks"}
+			b["hooks"] = map[string]any{
+				"pre_backup":  []any{map[string]any{"command": "sync", "timeout": "30s", "on_error": "abort"}},
+				"post_backup": []any{map[string]any{"url": "https://example.com/done", "on_error": "continue"}},
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := testBackup()
+			tt.mutate(backup)
+			report := validateTestBackup(t, backup)
+			if len(report.Issues) != 0 {
+				t.Fatalf("expected no issue, got %v", report.Issues)
+			}
+			if err := report.Err(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateBackupRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(b map[string]any)
+		field  string
+	}{
+		{"missing type", func(b map[string]any) { delete(b, "type") }, "type"},
+		{"unsupported type", func(b map[string]any) { b["type"] = "github" }, "type"},
+		{"mysql block missing", func(b map[string]any) { delete(b, "mysql") }, "mysql"},
+		{"mysql host missing", func(b map[string]any) { deleteField(b, "mysql.host") }, "mysql.host"},
+		{"mysql user missing", func(b map[string]any) { deleteField(b, "mysql.user") }, "mysql.user"},
+		{"mysql port not a number", func(b map[string]any) { setField(b, "mysql.port", "db") }, "mysql.port"},
+		{"mysql databases missing", func(b map[string]any) { deleteField(b, "mysql.databases") }, "mysql.databases"},
+		{"mongo block missing", func(b map[string]any) { delete(b, "mysql"); b["type"] = "mongo" }, "mongo"},
+		{"mongo host missing", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "mongo"
+			b["mongo"] = map[string]any{"user": "root"}
+		}, "mongo.host"},
+		{"mongo user missing", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "mongo"
+			b["mongo"] = map[string]any{"host": "localhost"}
+		}, "mongo.user"},
+		{"mongo port not a number", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "mongo"
+			b["mongo"] = map[string]any{"host": "localhost", "user": "root", "port": "db"}
+		}, "mongo.port"},
+		{"folder missing", func(b map[string]any) { delete(b, "mysql"); b["type"] = "folder" }, "folder"},
+		{"s3 endpoint missing", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "s3"
+			b["s3"] = map[string]any{"all": true, "ACCESS_KEY": "key", "SECRET_KEY": "secret"}
+		}, "s3.endpoint"},
+		{"s3 bucket missing", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "s3"
+			b["s3"] = map[string]any{"endpoint": "https://s3.example.com", "ACCESS_KEY": "key", "SECRET_KEY": "secret"}
+		}, "s3.bucket"},
+		{"s3 credentials missing", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "s3"
+			b["s3"] = map[string]any{"endpoint": "https://s3.example.com", "all": true, "ACCESS_KEY": "key"}
+		}, "s3"},
+		{"sqlite paths missing", func(b map[string]any) { delete(b, "mysql"); b["type"] = "sqlite" }, "sqlite.paths"},
+		{"kubernetes block missing", func(b map[string]any) { delete(b, "mysql"); b["type"] = "kubernetes" }, "kubernetes"},
+		{"kubernetes cluster backup", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "kubernetes"
+			b["kubernetes"] = map[string]any{"cluster": map[string]any{"backup": "full"}, "volumes": map[string]any{"enabled": true}}
+		}, "kubernetes.cluster.backup"},
+		{"invalid standard schedule", func(b map[string]any) { setField(b, "schedule.standard", "every day") }, "schedule.standard"},
+		{"invalid glacier schedule", func(b map[string]any) { setField(b, "schedule.glacier", "* * *") }, "schedule.glacier"},
+		{"local path missing", func(b map[string]any) { deleteField(b, "path.local") }, "path.local"},
+		{"s3 path missing", func(b map[string]any) { deleteField(b, "path.s3") }, "path.s3"},
+		{"s3 path with parent segment", func(b map[string]any) { setField(b, "path.s3", "backups/../db") }, "path.s3"},
+		{"negative standard retention", func(b map[string]any) { setField(b, "retention.standard.days", -1) }, "retention.standard.days"},
+		{"negative glacier retention", func(b map[string]any) { setField(b, "retention.glacier.days", -1) }, "retention.glacier.days"},
+		{"unsupported overlap", func(b map[string]any) { b["overlap"] = "wait" }, "overlap"},
+		{"unsupported heartbeat style", func(b map[string]any) {
+			b["heartbeat"] = map[string]any{"url": "https://hc-ping.com/uuid", "style": "cronitor"}
+		}, "heartbeat.style"},
+		{"heartbeat without url", func(b map[string]any) { b["heartbeat"] = map[string]any{"style": "healthchecks"} }, "heartbeat"},
+		{"heartbeat url not http", func(b map[string]any) { b["heartbeat"] = map[string]any{"failure": "ftp://example.com"} }, "heartbeat.failure"},
+		{"hook with command and url", func(b map[string]any) {
+			b["hooks"] = map[string]any{"pre_backup": []any{map[string]any{"command": "sync", "url": "https://example.com"}}}
+		}, "hooks.pre_backup[0]"},
+		{"hook without command nor url", func(b map[string]any) {
+			b["hooks"] = map[string]any{"on_failure": []any{map[string]any{"timeout": "30s"}}}
+		}, "hooks.on_failure[0]"},
+		{"hook url not http", func(b map[string]any) {
+			b["hooks"] = map[string]any{"post_backup": []any{map[string]any{"url": "example.com/done"}}}
+		}, "hooks.post_backup[0].url"},
+		{"hook timeout", func(b map[string]any) {
+			b["hooks"] = map[string]any{"pre_restore": []any{map[string]any{"command": "sync", "timeout": "soon"}}}
+		}, "hooks.pre_restore[0].timeout"},
+		{"hook error policy", func(b map[string]any) {
+			b["hooks"] = map[string]any{"post_restore": []any{map[string]any{"command": "sync", "on_error": "ignore"}}}
+		}, "hooks.post_restore[0].on_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := testBackup()
+			tt.mutate(backup)
+			report := validateTestBackup(t, backup)
+			if report.Err() == nil {
+				t.Fatalf("expected an error on %s, got %v", tt.field, report.Issues)
+			}
+			found := false
+			for _, issue := range report.Issues {
+				if issue.Severity == SeverityError && issue.Field == tt.field && issue.Backup == "db" {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("expected an error on %s, got %v", tt.field, report.Issues)
+			}
+		})
+	}
+}
+
+func TestValidateBackupWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(b map[string]any)
+		field  string
+	}{
+		{"retention not set", func(b map[string]any) { delete(b, "retention") }, "retention.standard.days"},
+		{"s3 path starting with a slash", func(b map[string]any) { setField(b, "path.s3", "/backups/db") }, "path.s3"},
+		{"glacier retention without schedule", func(b map[string]any) { setField(b, "retention.glacier.days", 30) }, "retention.glacier.days"},
+		{"inaccessible folder", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "folder"
+			b["folder"] = []string{"/nonexistent/mini-backup"}
+		}, "folder"},
+		{"empty kubernetes backup", func(b map[string]any) {
+			delete(b, "mysql")
+			b["type"] = "kubernetes"
+			b["kubernetes"] = map[string]any{"kubeconfig": "/etc/kubeconfig"}
+		}, "kubernetes"},
+		{"unknown field", func(b map[string]any) { setField(b, "mysql.database", "app") }, "mysql.database"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := testBackup()
+			tt.mutate(backup)
+			report := validateTestBackup(t, backup)
+			if err := report.Err(); err != nil {
+				t.Fatalf("expected only warnings, got %v", err)
+			}
+			if len(report.Issues) != 1 || report.Issues[0].Severity != SeverityWarning || report.Issues[0].Field != tt.field {
+				t.Fatalf("expected one warning on %s, got %v", tt.field, report.Issues)
+			}
+		})
+	}
+}
+
+func TestValidateUnknownFieldPosition(t *testing.T) {
+	data := []byte(`backups:
+  db:
+    type: mysql
+    mysql:
+      Host: localhost
+      user: root
+      databases: [app]
+    path:
+      local: ./backups
+      s3: backups/db
+    retention:
+      standard:
+        days: 7
+`)
+	report := ValidateConfigData("test.yaml", data)
+	if len(report.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %v", report.Issues)
+	}
+	report.SortIssues()
+	want := []string{
+		`test.yaml:5:7: warning: backup db: mysql.Host: unknown field (ignored), did you mean "host"?`,
+		`test.yaml:5:7: error: backup db: mysql.host: is required`,
+	}
+	for i, issue := range report.Issues {
+		if got := issue.String(); got != want[i] {
+			t.Errorf("issue %d: got %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestValidateConfigDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		severity string
+		message  string
+	}{
+		{"invalid YAML", "backups: [", SeverityError, "invalid YAML"},
+		{"not a mapping", "- db", SeverityError, "top-level document must be a mapping"},
+		{"backups not a mapping", "backups: [db]", SeverityError, "must be a mapping of backup names"},
+		{"templates not a mapping", "templates: [base]\nbackups: {}", SeverityError, "must be a mapping of template names"},
+		{"defaults not a mapping", "defaults: [base]\nbackups: {}", SeverityError, "must be a mapping"},
+		{"no backup", "backups: {}", SeverityError, "no backup defined"},
+		{"backup not a mapping", "backups:\n  db: mysql", SeverityError, "backup definition must be a mapping"},
+		{"wrong field type", "backups:\n  db:\n    type: mysql\n    retention:\n      standard:\n        days: week", SeverityError, "cannot unmarshal"},
+		{"unknown top-level key", "backup:\n  db: {}\nbackups: {}", SeverityWarning, "unknown top-level key"},
+		{"empty file", "", SeverityWarning, "file is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := ValidateConfigData("test.yaml", []byte(tt.data))
+			found := false
+			for _, issue := range report.Issues {
+				if issue.Severity == tt.severity && strings.Contains(issue.Message, tt.message) {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("expected %s containing %q, got %v", tt.severity, tt.message, report.Issues)
+			}
+		})
+	}
+}
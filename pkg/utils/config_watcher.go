@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// configWatchDebounce regroupe les évènements rapprochés (éditeurs qui écrivent en plusieurs fois).
+const configWatchDebounce = 500 * time.Millisecond
+
+// ConfigWatcher surveille un répertoire de configuration et notifie les modifications des fichiers YAML.
+type ConfigWatcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// WatchConfigDirs surveille les répertoires de configuration (voir ConfigLocation.Dirs) avec un seul
+// watcher et appelle onChange après chaque série de modifications.
+func WatchConfigDirs(dirs []string, onChange func()) (*ConfigWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config watcher: %w", err)
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch config directory %s: %w", dir, err)
+		}
+		getLogger().Info(fmt.Sprintf("Watching configuration directory %s", dir), source_utils)
+	}
+
+	w := &ConfigWatcher{watcher: watcher, done: make(chan struct{})}
+	go w.loop(onChange)
+	return w, nil
+}
+
+func (w *ConfigWatcher) loop(onChange func()) {
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !isYAMLFile(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			getLogger().Debug(fmt.Sprintf("Config change detected: %s", event), source_utils)
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(configWatchDebounce, onChange)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			getLogger().Error(fmt.Sprintf("Config watcher error: %v", err), source_utils)
+		case <-w.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
+
+// Close arrête la surveillance.
+func (w *ConfigWatcher) Close() error {
+	close(w.done)
+	return w.watcher.Close()
+}
+
+func isYAMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
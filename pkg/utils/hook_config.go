@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"mini-backup/pkg/redact"
+	"time"
+)
+
+// DefaultHookTimeout est la durée maximale d'un hook sans `timeout`.
+const DefaultHookTimeout = 5 * time.Minute
+
+// Hooks liste les commandes ou appels HTTP exécutés autour des backups et restaurations du job.
+type Hooks struct {
+	PreBackup   []Hook `yaml:"pre_backup,omitempty"`
+	PostBackup  []Hook `yaml:"post_backup,omitempty"`
+	PreRestore  []Hook `yaml:"pre_restore,omitempty"`
+	PostRestore []Hook `yaml:"post_restore,omitempty"`
+	OnFailure   []Hook `yaml:"on_failure,omitempty"`
+}
+
+// Hook est une commande shell (command) ou un appel HTTP (url).
+type Hook struct {
+	Command string `yaml:"command,omitempty"`
+	// URL peut contenir un jeton (?token=) : seul son hôte est affiché.
+	URL     string            `yaml:"url,omitempty" redact:"endpoint"`
+	Method  string            `yaml:"method,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Timeout est une durée Go (30s, 5m), DefaultHookTimeout par défaut.
+	Timeout string `yaml:"timeout,omitempty"`
+	// OnError vaut abort (l'opération échoue) ou continue. Par défaut, l'échec d'un hook pre_*
+	// interrompt l'opération et celui des autres hooks est seulement journalisé.
+	OnError string `yaml:"on_error,omitempty"`
+}
+
+// Étapes des hooks et politiques d'échec.
+const (
+	HookPreBackup   = "pre_backup"
+	HookPostBackup  = "post_backup"
+	HookPreRestore  = "pre_restore"
+	HookPostRestore = "post_restore"
+	HookOnFailure   = "on_failure"
+
+	HookAbort    = "abort"
+	HookContinue = "continue"
+)
+
+var HookErrorPolicies = []string{HookAbort, HookContinue}
+
+// Stage retourne les hooks d'une étape.
+func (h *Hooks) Stage(stage string) []Hook {
+	if h == nil {
+		return nil
+	}
+	switch stage {
+	case HookPreBackup:
+		return h.PreBackup
+	case HookPostBackup:
+		return h.PostBackup
+	case HookPreRestore:
+		return h.PreRestore
+	case HookPostRestore:
+		return h.PostRestore
+	case HookOnFailure:
+		return h.OnFailure
+	}
+	return nil
+}
+
+// TimeoutDuration retourne la durée maximale du hook.
+func (h Hook) TimeoutDuration() time.Duration {
+	if timeout, err := time.ParseDuration(h.Timeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return DefaultHookTimeout
+}
+
+// Aborts indique si l'échec du hook fait échouer l'opération.
+func (h Hook) Aborts(stage string) bool {
+	if h.OnError != "" {
+		return h.OnError == HookAbort
+	}
+	return stage == HookPreBackup || stage == HookPreRestore
+}
+
+// String décrit le hook dans les journaux, sans le mot de passe ni les jetons de son URL.
+func (h Hook) String() string {
+	if h.Command != "" {
+		return h.Command
+	}
+	method := h.Method
+	if method == "" {
+		method = "POST"
+	}
+	return fmt.Sprintf("%s %s", method, redact.URL(h.URL))
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"mini-backup/pkg/jobs"
 	"os"
 	"os/exec"
 
@@ -63,7 +65,7 @@ func CopyPVCData(ctx context.Context, kubeConfig, namespace, podName, mountPath,
 
 	var stdErr bytes.Buffer
 	cmd.Stdout = file
-	cmd.Stderr = &stdErr
+	cmd.Stderr = io.MultiWriter(&stdErr, jobs.ToolWriter(ctx, "kubectl"))
 
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to execute kubectl command: %w. StdErr: %s", err, stdErr.String())
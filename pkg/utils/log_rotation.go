@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatingFile est un fichier de log qui change de fichier lorsqu'il dépasse une taille ou
+// qu'une période (heure, jour) est écoulée. Les anciens fichiers sont renommés avec la date
+// de rotation, compressés en gzip si demandé puis supprimés selon max_backups et max_age_days.
+type rotatingFile struct {
+	path     string
+	rotation LogRotation
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	period time.Time
+	closed bool
+
+	// maintenance sérialise la compression et le nettoyage, faits en arrière-plan
+	maintenance sync.Mutex
+}
+
+func openRotatingFile(path string, rotation LogRotation) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	r := &rotatingFile{path: path, rotation: rotation}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	// Un fichier d'une période précédente (redémarrage le lendemain) est tourné à la première écriture
+	if info, err := r.file.Stat(); err == nil && info.Size() > 0 {
+		r.period = r.periodOf(info.ModTime())
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	r.period = r.periodOf(time.Now())
+	return nil
+}
+
+// periodOf retourne le début de la période de rotation contenant t (zéro sans rotation périodique).
+func (r *rotatingFile) periodOf(t time.Time) time.Time {
+	switch r.rotation.Interval {
+	case "hourly":
+		return t.Truncate(time.Hour)
+	case "daily":
+		year, month, day := t.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.file == nil {
+		// Le fichier n'a pas pu être rouvert après une rotation : nouvel essai, sinon les logs vont
+		// sur stderr plutôt que d'être perdus (et d'interrompre les autres sorties de io.MultiWriter)
+		if err := r.open(); err != nil {
+			return os.Stderr.Write(p)
+		}
+	}
+	maxSize := int64(r.rotation.MaxSizeMB) * 1024 * 1024
+	if r.size > 0 && ((maxSize > 0 && r.size+int64(len(p)) > maxSize) || !r.periodOf(time.Now()).Equal(r.period)) {
+		if err := r.rotateLocked(); err != nil {
+			// La rotation a échoué : on continue d'écrire dans le fichier courant, ou sur stderr s'il n'a pas pu être rouvert
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+		if r.file == nil {
+			return os.Stderr.Write(p)
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotateLocked renomme le fichier courant puis en ouvre un nouveau.
+func (r *rotatingFile) rotateLocked() error {
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext)
+	rotated := fmt.Sprintf("%s-%s%s", base, time.Now().Format("20060102-150405"), ext)
+	for i := 1; fileExistsAny(rotated, rotated+".gz"); i++ {
+		rotated = fmt.Sprintf("%s-%s.%d%s", base, time.Now().Format("20060102-150405"), i, ext)
+	}
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(r.path, rotated); err != nil {
+		if openErr := r.open(); openErr != nil {
+			r.file = nil
+		}
+		return err
+	}
+	if err := r.open(); err != nil {
+		r.file = nil
+		return fmt.Errorf("%w (writing to stderr until the file can be reopened)", err)
+	}
+	go r.maintain(rotated)
+	return nil
+}
+
+// maintain compresse le fichier tourné puis supprime les fichiers en trop ou trop anciens.
+func (r *rotatingFile) maintain(rotated string) {
+	r.maintenance.Lock()
+	defer r.maintenance.Unlock()
+	if r.rotation.Compress {
+		if err := gzipFile(rotated); err != nil {
+			fmt.Fprintf(os.Stderr, "log compression failed: %v\n", err)
+		}
+	}
+
+	ext := filepath.Ext(r.path)
+	matches, _ := filepath.Glob(strings.TrimSuffix(r.path, ext) + "-[0-9]*")
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+	backups := []backup{}
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			backups = append(backups, backup{match, info.ModTime()})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].modTime.After(backups[j].modTime) })
+	limit := time.Now().AddDate(0, 0, -r.rotation.MaxAgeDays)
+	for i, b := range backups {
+		if (r.rotation.MaxBackups > 0 && i >= r.rotation.MaxBackups) || (r.rotation.MaxAgeDays > 0 && b.modTime.Before(limit)) {
+			os.Remove(b.path)
+		}
+	}
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// gzipFile remplace path par path.gz.
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
+
+func fileExistsAny(paths ...string) bool {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
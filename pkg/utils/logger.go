@@ -1,108 +1,210 @@
 package utils
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/redact"
 	"os"
-	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
+// DefaultLogFile est le fichier de log utilisé sans `logging.file` ni `server.log`.
+const DefaultLogFile = "logs/mini-backup.log"
+
+// Logger écrit dans le journal unique du processus (slog, texte ou JSON). Les loggers retournés
+// par LoggerFunc partagent la même destination, ouverte une seule fois à la première écriture.
 type Logger struct {
-	infoLog  *log.Logger
-	errorLog *log.Logger
-	debugLog *log.Logger
-	file     *os.File
+	// attrs sont les champs de corrélation ajoutés à chaque message (run_id, job...), voir With.
+	attrs []any
+	// output reçoit aussi chaque message (journal d'une exécution), voir WithOutput.
+	output io.Writer
 }
 
-// NewLogger creates a new logger that writes to a specified file.
-func newLogger() (*Logger, error) {
-	configServer, err := GetConfigServer()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load server configuration: %v", err)
-	}
-	// Déterminer le chemin du fichier de log
-	var logFilePath string
-	if GetEnv[string]("LOG_FILE") == "" {
-		if configServer.Server.Log == "" {
-			logFilePath = "logs/mini-backup.log"
-		} else {
-			logFilePath = configServer.Server.Log
-		}
-	} else {
-		logFilePath = GetEnv[string]("LOG_FILE")
-	}
+var (
+	logMu      sync.Mutex
+	logBackend *slog.Logger
+	logCloser  io.Closer
+	// defaultLogOutput est la sortie sans `logging.output` ni LOG_OUTPUT, voir SetDefaultLogOutput.
+	defaultLogOutput = "file"
+)
 
-	// Créer le dossier de logs si nécessaire
-	logDir := filepath.Dir(logFilePath)
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create log directory: %v", err)
-	}
+// SetDefaultLogOutput change la sortie des logs sans `logging.output` ni LOG_OUTPUT. Le serveur
+// écrit sur la sortie standard (stdout), la CLI réserve la sienne à ses résultats (file) ; les deux
+// sorties à la fois (both) ne sont utilisées que si elles sont demandées.
+// Elle doit être appelée avant la première écriture.
+func SetDefaultLogOutput(output string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	defaultLogOutput = output
+}
 
-	// Ouvrir ou créer le fichier de log
-	file, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %v", err)
-	}
-	var logLevel string
-	if GetEnv[string]("LOG_LEVEL") == "" {
-		if configServer.Server.Debug {
-			logLevel = "debug"
-		}
-	} else {
-		logLevel = GetEnv[string]("LOG_LEVEL")
-	}
+// LoggerFunc retourne un logger vers le journal du processus.
+func LoggerFunc() *Logger {
+	return &Logger{}
+}
 
-	if logLevel == "debug" {
-		return &Logger{
-			infoLog:  log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile),
-			errorLog: log.New(os.Stdout, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile),
-			debugLog: log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile),
-			file:     os.Stdout,
-		}, nil
-	} else {
-		return &Logger{
-			infoLog:  log.New(file, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile),
-			errorLog: log.New(file, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile),
-			debugLog: log.New(file, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile),
-			file:     file,
-		}, nil
+// With retourne une copie du logger qui ajoute les paires clé/valeur à chaque message.
+func (l *Logger) With(args ...any) *Logger {
+	scoped := *l
+	scoped.attrs = append(append([]any{}, l.attrs...), args...)
+	return &scoped
+}
+
+// WithOutput retourne une copie du logger qui écrit aussi chaque message dans w.
+func (l *Logger) WithOutput(w io.Writer) *Logger {
+	if l == nil || w == nil || w == io.Discard {
+		return l
 	}
+	scoped := *l
+	scoped.output = w
+	return &scoped
 }
-func LoggerFunc() *Logger {
-	logger, err := newLogger()
-	if err != nil {
-		// En cas d'erreur, créer un logger de secours qui écrit sur stdout
-		fmt.Printf("Failed to initialize file logger: %v\n", err)
+
+// ForJob retourne une copie du logger liée au job du contexte : les messages portent les champs
+// run_id, job et kind et sont aussi écrits dans le journal de l'exécution.
+func (l *Logger) ForJob(ctx context.Context) *Logger {
+	job, ok := jobs.FromContext(ctx)
+	if !ok {
+		return l
 	}
-	return logger
+	return l.With("run_id", job.ID, "job", job.Name, "kind", job.Kind).WithOutput(jobs.LogWriter(ctx))
 }
 
 // Info logs informational messages with an optional source.
 func (l *Logger) Info(msg string, source ...string) {
-	message := formatLogMessage(msg, source)
-	fmt.Println("🚀 MINIBACKUP CONSOLE 🚀 -- " + message)
-	l.infoLog.Println(message)
+	l.log(slog.LevelInfo, msg, source)
+}
+
+// Warn logs warning messages with an optional source.
+func (l *Logger) Warn(msg string, source ...string) {
+	l.log(slog.LevelWarn, msg, source)
 }
 
 // Error logs error messages with an optional source.
 func (l *Logger) Error(msg string, source ...string) {
-	message := formatLogMessage(msg, source)
-	fmt.Println("🚀 MINIBACKUP CONSOLE 🚀 -- " + message)
-	l.errorLog.Println(message)
+	l.log(slog.LevelError, msg, source)
 }
 
 // Debug logs debug messages with an optional source.
 func (l *Logger) Debug(msg string, source ...string) {
-	if GetEnv[string]("LOG_LEVEL") == "debug" {
-		message := formatLogMessage(msg, source)
-		fmt.Println("🚀 MINIBACKUP CONSOLE 🚀 -- " + message)
-		l.debugLog.Println(message)
-	}
+	l.log(slog.LevelDebug, msg, source)
 }
 
-// Close closes the log file when the logger is no longer needed.
+// Close ferme le fichier de log du processus ; il est rouvert à la prochaine écriture.
 func (l *Logger) Close() error {
-	return l.file.Close()
+	logMu.Lock()
+	defer logMu.Unlock()
+	logBackend = nil
+	if logCloser == nil {
+		return nil
+	}
+	err := logCloser.Close()
+	logCloser = nil
+	return err
+}
+
+func (l *Logger) log(level slog.Level, msg string, source []string) {
+	backend := processLogger()
+	ctx := context.Background()
+	if !backend.Enabled(ctx, level) {
+		return
+	}
+	// Un secret chargé depuis la configuration n'est jamais écrit en clair
+	msg = redact.String(msg)
+	args := l.attrs
+	if len(source) > 0 {
+		args = append(append([]any{}, args...), "component", source[0])
+	}
+	backend.Log(ctx, level, msg, args...)
+	if l.output != nil {
+		fmt.Fprintf(l.output, "%s %s %s\n", time.Now().Format(time.RFC3339), level, formatLogMessage(msg, source))
+	}
+}
+
+// processLogger retourne le journal du processus, construit depuis server.yaml au premier appel.
+func processLogger() *slog.Logger {
+	logMu.Lock()
+	defer logMu.Unlock()
+	if logBackend != nil {
+		return logBackend
+	}
+	config, err := GetConfigServer()
+	if err != nil {
+		// Sans server.yaml (CLI), les valeurs par défaut s'appliquent
+		config = &ServerConfig{}
+	}
+	settings := resolveLogSettings(config)
+
+	var writers []io.Writer
+	if settings.output != "stdout" {
+		file, err := openRotatingFile(settings.file, settings.rotation)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to initialize file logger, logging to stdout: %v\n", err)
+			settings.output = "stdout"
+		} else {
+			logCloser = file
+			writers = append(writers, file)
+		}
+	}
+	if settings.output == "stdout" || settings.output == "both" {
+		writers = append(writers, os.Stdout)
+	}
+
+	options := &slog.HandlerOptions{Level: settings.level}
+	if settings.format == "json" {
+		logBackend = slog.New(slog.NewJSONHandler(io.MultiWriter(writers...), options))
+	} else {
+		logBackend = slog.New(slog.NewTextHandler(io.MultiWriter(writers...), options))
+	}
+	return logBackend
+}
+
+type logSettings struct {
+	level    slog.Level
+	format   string
+	output   string
+	file     string
+	rotation LogRotation
+}
+
+// resolveLogSettings applique les priorités : variables d'environnement, section logging,
+// puis les anciens réglages `server.debug` et `server.log`.
+func resolveLogSettings(config *ServerConfig) logSettings {
+	settings := logSettings{level: slog.LevelInfo, format: "text", output: defaultLogOutput, file: DefaultLogFile, rotation: config.Logging.Rotation}
+
+	level := firstNonEmpty(GetEnv[string]("LOG_LEVEL"), config.Logging.Level)
+	if level == "" && config.Server.Debug {
+		level = "debug"
+	}
+	if level != "" {
+		if err := settings.level.UnmarshalText([]byte(level)); err != nil {
+			settings.level = slog.LevelInfo
+		}
+	}
+	if format := strings.ToLower(firstNonEmpty(GetEnv[string]("LOG_FORMAT"), config.Logging.Format)); format == "json" {
+		settings.format = format
+	}
+	if output := strings.ToLower(firstNonEmpty(GetEnv[string]("LOG_OUTPUT"), config.Logging.Output)); output == "file" || output == "stdout" || output == "both" {
+		settings.output = output
+	}
+	if file := firstNonEmpty(GetEnv[string]("LOG_FILE"), config.Logging.File, config.Server.Log); file != "" {
+		settings.file = file
+	}
+	return settings
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
 }
 
 // formatLogMessage formats a log message with an optional source.
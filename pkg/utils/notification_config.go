@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NotificationsConfig décrit les canaux de notification et les règles qui choisissent
+// quels événements (échec, rétablissement, démarrage d'une restauration...) y sont envoyés.
+type NotificationsConfig struct {
+	Channels  map[string]NotificationChannel `yaml:"channels,omitempty"`
+	Rules     []NotificationRule             `yaml:"rules,omitempty"`
+	Templates NotificationTemplates          `yaml:"templates,omitempty"`
+}
+
+// NotificationChannel est une destination : webhook JSON générique, email SMTP,
+// ou webhook compatible Slack, Teams ou Discord. L'URL d'un webhook contient souvent son jeton :
+// seul son hôte est affiché par l'API.
+type NotificationChannel struct {
+	Type    string            `yaml:"type"`
+	URL     string            `yaml:"url,omitempty" redact:"endpoint"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	SMTP    *SMTPConfig       `yaml:"smtp,omitempty"`
+	// Templates remplace les modèles globaux pour ce canal.
+	Templates NotificationTemplates `yaml:"templates,omitempty"`
+}
+
+// SMTPConfig configure l'envoi des emails. Sans tls, STARTTLS est utilisé si le serveur le propose.
+type SMTPConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	TLS      bool     `yaml:"tls,omitempty"`
+}
+
+// NotificationRule envoie vers channels les événements listés, éventuellement limités
+// à certains backups (jobs) ou à un type d'exécution (kinds : backup, restore).
+type NotificationRule struct {
+	Channels []string `yaml:"channels"`
+	Events   []string `yaml:"events"`
+	Jobs     []string `yaml:"jobs,omitempty"`
+	Kinds    []string `yaml:"kinds,omitempty"`
+}
+
+// NotificationTemplates sont des modèles text/template du titre et du corps des messages.
+type NotificationTemplates struct {
+	Title string `yaml:"title,omitempty"`
+	Body  string `yaml:"body,omitempty"`
+}
+
+// Types de canaux et événements de notification.
+var (
+	NotificationChannelTypes = []string{"webhook", "email", "slack", "teams", "discord"}
+	NotificationEvents       = []string{"started", "success", "failure", "recovery", "cancelled", "skipped"}
+)
+
+// GetNotificationsConfig retourne la section notifications de server.yaml (vide si absente).
+func GetNotificationsConfig() NotificationsConfig {
+	serverConfig, err := GetConfigServer()
+	if err != nil {
+		return NotificationsConfig{}
+	}
+	return serverConfig.Notifications
+}
+
+// Validate vérifie les canaux et les règles de notification.
+func (c NotificationsConfig) Validate() error {
+	for name, channel := range c.Channels {
+		if !containsString(NotificationChannelTypes, channel.Type) {
+			return fmt.Errorf("notifications.channels.%s: unsupported type %q (expected one of %s)", name, channel.Type, strings.Join(NotificationChannelTypes, ", "))
+		}
+		if channel.Type == "email" {
+			if channel.SMTP == nil || channel.SMTP.Host == "" || channel.SMTP.From == "" || len(channel.SMTP.To) == 0 {
+				return fmt.Errorf("notifications.channels.%s: smtp.host, smtp.from and smtp.to are required", name)
+			}
+		} else if channel.URL == "" {
+			return fmt.Errorf("notifications.channels.%s: url is required", name)
+		}
+	}
+	for i, rule := range c.Rules {
+		if len(rule.Channels) == 0 || len(rule.Events) == 0 {
+			return fmt.Errorf("notifications.rules[%d]: channels and events are required", i)
+		}
+		for _, channel := range rule.Channels {
+			if _, exists := c.Channels[channel]; !exists {
+				return fmt.Errorf("notifications.rules[%d]: unknown channel %q", i, channel)
+			}
+		}
+		for _, event := range rule.Events {
+			if !containsString(NotificationEvents, event) {
+				return fmt.Errorf("notifications.rules[%d]: unsupported event %q (expected one of %s)", i, event, strings.Join(NotificationEvents, ", "))
+			}
+		}
+		for _, kind := range rule.Kinds {
+			if kind != "backup" && kind != "restore" {
+				return fmt.Errorf("notifications.rules[%d]: unsupported kind %q (expected backup or restore)", i, kind)
+			}
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mini-backup/pkg/jobs"
 	"time"
 
 	"os"
@@ -204,6 +205,12 @@ func (m *S3Manager) ListBuckets() ([]string, error) {
 
 // DownloadFileFromS3 télécharge un fichier depuis S3 vers un chemin local
 func (m *S3Manager) Download(s3Path, localPath string) error {
+	return m.DownloadContext(context.TODO(), s3Path, localPath)
+}
+
+// DownloadContext télécharge un fichier depuis S3 vers un chemin local. L'avancement est signalé
+// au job du contexte, dont l'annulation interrompt le téléchargement.
+func (m *S3Manager) DownloadContext(ctx context.Context, s3Path, localPath string) error {
 	// Préparer la requête pour télécharger l'objet
 	getInput := &s3.GetObjectInput{
 		Bucket: &m.Bucket,
@@ -211,7 +218,7 @@ func (m *S3Manager) Download(s3Path, localPath string) error {
 	}
 
 	// Obtenir l'objet depuis S3
-	objectOutput, err := m.Client.GetObject(context.TODO(), getInput)
+	objectOutput, err := m.Client.GetObject(ctx, getInput)
 	if err != nil {
 		getLogger().Error(fmt.Sprintf("Erreur lors du téléchargement de %s depuis S3 : %v", s3Path, err))
 		return fmt.Errorf("erreur lors du téléchargement de %s : %v", s3Path, err)
@@ -232,7 +239,7 @@ func (m *S3Manager) Download(s3Path, localPath string) error {
 	defer localFile.Close()
 
 	// Copier le contenu de l'objet dans le fichier local
-	_, err = io.Copy(localFile, objectOutput.Body)
+	_, err = io.Copy(localFile, jobs.ProgressReader(ctx, objectOutput.Body, aws.ToInt64(objectOutput.ContentLength)))
 	if err != nil {
 		getLogger().Error(fmt.Sprintf("Erreur lors de la copie du contenu de %s vers %s : %v", s3Path, localPath, err))
 		return err
@@ -244,6 +251,12 @@ func (m *S3Manager) Download(s3Path, localPath string) error {
 
 // UploadFileToS3 téléverse un fichier local vers un chemin S3
 func (m *S3Manager) Upload(localPath, s3Path string, useGlacier bool) error {
+	return m.UploadContext(context.TODO(), localPath, s3Path, useGlacier)
+}
+
+// UploadContext téléverse un fichier local vers un chemin S3. L'avancement est signalé au job
+// du contexte, dont l'annulation interrompt l'envoi.
+func (m *S3Manager) UploadContext(ctx context.Context, localPath, s3Path string, useGlacier bool) error {
 	// Ouvrir le fichier local
 	file, err := os.Open(localPath)
 	if err != nil {
@@ -266,14 +279,14 @@ func (m *S3Manager) Upload(localPath, s3Path string, useGlacier bool) error {
 	input := &s3.PutObjectInput{
 		Bucket:        &m.Bucket,
 		Key:           &s3Path,
-		Body:          file,
+		Body:          jobs.ProgressReader(ctx, file, stat.Size()),
 		ContentLength: Int64Ptr(stat.Size()), // Convertir en *int64
 		ContentType:   aws.String("application/octet-stream"),
 		StorageClass:  storageClass,
 	}
 
 	// Téléverser le fichier
-	_, err = m.Client.PutObject(context.TODO(), input)
+	_, err = m.Client.PutObject(ctx, input)
 	if err != nil {
 		getLogger().Error(fmt.Sprintf("Erreur lors de la téléversement du fichier %s vers %s : %v", localPath, s3Path, err))
 		return fmt.Errorf("erreur lors de l'upload vers S3 (local: %s, s3: %s) : %v", localPath, s3Path, err)
@@ -282,7 +295,8 @@ func (m *S3Manager) Upload(localPath, s3Path string, useGlacier bool) error {
 	getLogger().Info(fmt.Sprintf("Fichier %s téléversé avec succès vers %s", localPath, s3Path))
 	return nil
 }
-func (m *S3Manager) ManageRetention(s3Path string, retentionDays int, useGlacier bool) error {
+// ManageRetention supprime les objets plus anciens que la rétention et retourne leur nombre.
+func (m *S3Manager) ManageRetention(s3Path string, retentionDays int, useGlacier bool) (int, error) {
 	// Calculer la date limite
 	cutoffDate := time.Now().AddDate(0, 0, -retentionDays)
 
@@ -294,9 +308,11 @@ func (m *S3Manager) ManageRetention(s3Path string, retentionDays int, useGlacier
 	result, err := m.Client.ListObjectsV2(context.TODO(), input)
 	if err != nil {
 		getLogger().Error(fmt.Sprintf("Erreur lors de la liste des objets dans %s : %v", s3Path, err))
-		return fmt.Errorf("erreur lors de la liste des objets dans %s : %v", s3Path, err)
+		return 0, fmt.Errorf("erreur lors de la liste des objets dans %s : %v", s3Path, err)
 	}
 
+	deleted := 0
+
 	// Parcourir les objets et vérifier leur date de modification
 	for _, obj := range result.Contents {
 		if strings.HasSuffix(*obj.Key, "/") {
@@ -338,10 +354,11 @@ func (m *S3Manager) ManageRetention(s3Path string, retentionDays int, useGlacier
 				getLogger().Error(fmt.Sprintf("Erreur lors de la suppression de %s : %v", *obj.Key, err))
 			} else {
 				getLogger().Info(fmt.Sprintf("Fichier %s supprimé pour respect de la rétention.", *obj.Key))
+				deleted++
 			}
 		}
 	}
-	return nil
+	return deleted, nil
 }
 
 
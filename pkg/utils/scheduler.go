@@ -1,17 +1,30 @@
 package utils
 
 import (
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/robfig/cron/v3"
 )
 
 type Scheduler struct {
 	cron *cron.Cron
+	mu   sync.Mutex
+	jobs map[string][]cron.EntryID
+}
+
+// ScheduledJob décrit une entrée cron rattachée à un job nommé.
+type ScheduledJob struct {
+	Spec string
+	Run  func()
 }
 
 // NewScheduler creates a new instance of the scheduler.
 func NewScheduler() *Scheduler {
 	return &Scheduler{
 		cron: cron.New(), // Removed WithSeconds()
+		jobs: make(map[string][]cron.EntryID),
 	}
 }
 
@@ -24,6 +37,63 @@ func (s *Scheduler) AddJob(schedule string, job func()) error {
 	return nil
 }
 
+// ReplaceJobs retire les entrées des jobs listés dans remove puis ajoute celles de add.
+// Toutes les expressions cron sont vérifiées avant la moindre modification : en cas d'erreur,
+// le scheduler reste dans son état précédent.
+func (s *Scheduler) ReplaceJobs(remove []string, add map[string][]ScheduledJob) error {
+	type parsedJob struct {
+		schedule cron.Schedule
+		run      func()
+	}
+	parsed := make(map[string][]parsedJob, len(add))
+	for name, entries := range add {
+		for _, entry := range entries {
+			schedule, err := cron.ParseStandard(entry.Spec)
+			if err != nil {
+				return fmt.Errorf("invalid schedule %q for %s: %w", entry.Spec, name, err)
+			}
+			parsed[name] = append(parsed[name], parsedJob{schedule: schedule, run: entry.Run})
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range remove {
+		for _, id := range s.jobs[name] {
+			s.cron.Remove(id)
+		}
+		delete(s.jobs, name)
+	}
+	for name, entries := range parsed {
+		for _, entry := range entries {
+			id := s.cron.Schedule(entry.schedule, cron.FuncJob(entry.run))
+			s.jobs[name] = append(s.jobs[name], id)
+		}
+	}
+	return nil
+}
+
+// NextRuns retourne, pour chaque job planifié, la prochaine exécution de ses entrées cron.
+func (s *Scheduler) NextRuns() map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := make(map[string]time.Time, len(s.jobs))
+	for name, ids := range s.jobs {
+		for _, id := range ids {
+			entry := s.cron.Entry(id)
+			// Avant le démarrage du scheduler, la prochaine exécution est calculée depuis maintenant
+			at := entry.Next
+			if at.IsZero() && entry.Schedule != nil {
+				at = entry.Schedule.Next(time.Now())
+			}
+			if !at.IsZero() && (next[name].IsZero() || at.Before(next[name])) {
+				next[name] = at
+			}
+		}
+	}
+	return next
+}
+
 // Start starts the scheduler.
 func (s *Scheduler) Start() {
 	s.cron.Start()
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// SchemaNames liste les schémas disponibles.
+var SchemaNames = []string{"config", "server"}
+
+// schemaHint complète le schéma généré pour un champ donné ("Type.champ_yaml").
+type schemaHint struct {
+	Description string
+	Enum        []string
+	Types       []string
+}
+
+// schemaHints ajoute les informations qui ne peuvent pas être déduites des structures Go.
+var schemaHints = map[string]schemaHint{
+	"Backup.type":                    {Description: "Type of backup", Enum: SupportedBackupTypes},
+	"Backup.extends":                 {Description: "Name of the template this backup inherits from"},
+	"BackupTemplate.type":            {Description: "Type of backup", Enum: SupportedBackupTypes},
+	"BackupTemplate.extends":         {Description: "Name of the template this template inherits from"},
+	"BackupConfig.defaults":          {Description: "Values deep-merged into every backup"},
+	"BackupConfig.templates":         {Description: "Named partial definitions that backups can extend"},
+	"Backup.overlap":                 {Description: "What to do when the backup is triggered while it is still running (default: concurrency.overlap of server.yaml)", Enum: OverlapPolicies},
+	"BackupTemplate.overlap":         {Description: "What to do when the backup is triggered while it is still running", Enum: OverlapPolicies},
+	"Backup.heartbeat":               {Description: "URLs pinged when each run starts, succeeds and fails (dead-man's switch)"},
+	"BackupTemplate.heartbeat":       {Description: "URLs pinged when each run starts, succeeds and fails (dead-man's switch)"},
+	"Heartbeat.url":                  {Description: "Base ping URL: <url>/start, <url> and <url>/fail (healthchecks) or <url>?status=up|down (uptime-kuma)"},
+	"Heartbeat.style":                {Description: "Ping style (default: healthchecks)", Enum: HeartbeatStyles},
+	"Heartbeat.start":                {Description: "URL pinged when a run starts (overrides the one derived from url)"},
+	"Heartbeat.success":              {Description: "URL pinged when a run succeeds (overrides the one derived from url)"},
+	"Heartbeat.failure":              {Description: "URL pinged when a run fails or is cancelled (overrides the one derived from url)"},
+	"Backup.hooks":                   {Description: "Shell commands or HTTP calls run before and after the backups and restores of this job"},
+	"BackupTemplate.hooks":           {Description: "Shell commands or HTTP calls run before and after the backups and restores of this job"},
+	"Hooks.on_failure":               {Description: "Hooks run when a backup or restore fails"},
+	"Hook.command":                   {Description: "Shell command run with sh -c (MINIBACKUP_* variables describe the run)"},
+	"Hook.url":                       {Description: "URL called with a JSON description of the run"},
+	"Hook.method":                    {Description: "HTTP method (default: POST)"},
+	"Hook.timeout":                   {Description: "Maximum duration as a Go duration (default: 5m)"},
+	"Hook.on_error":                  {Description: "Whether a failure of the hook aborts the operation (default: abort for pre_* hooks, continue otherwise)", Enum: HookErrorPolicies},
+	"Backup.folder":                  {Description: "Folders to back up (type: folder)"},
+	"Schedule.standard":              {Description: "Cron expression (5 fields) for standard backups"},
+	"Schedule.glacier":               {Description: "Cron expression (5 fields) for glacier backups"},
+	"RetentionConfig.days":           {Description: "Number of days backups are kept"},
+	"Path.local":                     {Description: "Local working directory used before upload"},
+	"Path.s3":                        {Description: "Prefix of the objects in the remote storage"},
+	"Mysql.port":                     {Types: []string{"string", "integer"}},
+	"Mongo.port":                     {Types: []string{"string", "integer"}},
+	"ServerSettings.port":            {Description: "Port of the API server", Types: []string{"string", "integer"}},
+	"Cluster.backup":                 {Description: "Set to \"auto\" to back up the cluster state", Enum: []string{"", "auto"}},
+	"ServerSettings.debug":           {Description: "Enable debug logs on stdout"},
+	"ServerSettings.data_dir":        {Description: "Directory of the persistent server data such as the run history (default: ./data)"},
+	"ServerSettings.log":             {Description: "Path of the log file"},
+	"ServerSettings.run_log_days":    {Description: "Number of days the log of each run is kept (default: 30)"},
+	"ServerSettings.history_days":    {Description: "Number of days finished runs are kept in the run history (default: 365)"},
+	"ServerSettings.tls":             {Description: "Serve the API over HTTPS, optionally requiring client certificates (mTLS)"},
+	"TLSConfig.cert_file":            {Description: "PEM certificate (with its chain), reloaded when it changes"},
+	"TLSConfig.key_file":             {Description: "PEM private key of the certificate, reloaded when it changes"},
+	"TLSConfig.min_version":          {Description: "Minimum TLS version (default: 1.2)", Enum: TLSVersions},
+	"TLSConfig.client_ca_file":       {Description: "PEM CA bundle verifying client certificates (enables mTLS)"},
+	"TLSConfig.client_auth":          {Description: "Client certificate policy (default: require when client_ca_file is set)", Enum: ClientAuthModes},
+	"TLSConfig.hsts_max_age":         {Description: "max-age of the Strict-Transport-Security header as a Go duration (default: 8760h, 0: disabled)"},
+	"TLSConfig.hsts_subdomains":      {Description: "Add includeSubDomains to the Strict-Transport-Security header"},
+	"RStorageConfig.pathStyle":       {Description: "Use path-style addressing (required by MinIO)"},
+	"ServerConfig.config_source":     {Description: "Load backup definitions from a git repository instead of the local config directory"},
+	"ServerConfig.concurrency":       {Description: "Limits on simultaneous backups and restores"},
+	"ConcurrencyConfig.max_parallel": {Description: "Maximum number of jobs running at once (0: unlimited)"},
+	"ConcurrencyConfig.max_per_host": {Description: "Maximum number of jobs running at once against the same MySQL or MongoDB host (default: 1)"},
+	"ConcurrencyConfig.overlap":      {Description: "Default overlap policy of the backups (default: skip)", Enum: OverlapPolicies},
+	"ServerConfig.logging":           {Description: "Process log: level, format, destination and rotation"},
+	"LoggingConfig.level":            {Description: "Minimum level (default: info, debug when server.debug is set)", Enum: LogLevels},
+	"LoggingConfig.format":           {Description: "Log line format (default: text)", Enum: LogFormats},
+	"LoggingConfig.output":           {Description: "Where logs are written (default: stdout for the server, file for the CLI)", Enum: LogOutputs},
+	"LoggingConfig.file":             {Description: "Path of the log file (default: server.log, then logs/mini-backup.log)"},
+	"LogRotation.max_size_mb":        {Description: "Rotate the log file when it exceeds this size (0: no size limit)"},
+	"LogRotation.interval":           {Description: "Rotate the log file every hour or every day", Enum: LogIntervals},
+	"LogRotation.max_backups":        {Description: "Number of rotated files to keep (0: unlimited)"},
+	"LogRotation.max_age_days":       {Description: "Delete rotated files older than this number of days (0: never)"},
+	"LogRotation.compress":           {Description: "Compress rotated files with gzip"},
+	"ServerConfig.notifications":     {Description: "Channels and routing rules of the backup and restore notifications"},
+	"NotificationChannel.type":       {Description: "Kind of channel", Enum: NotificationChannelTypes},
+	"NotificationChannel.url":        {Description: "Webhook URL (all types except email)"},
+	"NotificationChannel.headers":    {Description: "Extra HTTP headers sent to the webhook"},
+	"SMTPConfig.tls":                 {Description: "Use implicit TLS (port 465) instead of STARTTLS"},
+	"NotificationRule.events":        {Description: "Events sent to the channels (success also receives recovery)", Enum: NotificationEvents},
+	"NotificationRule.jobs":          {Description: "Only notify for these backups (default: all)"},
+	"NotificationRule.kinds":         {Description: "Only notify for these kinds of runs (default: all)", Enum: []string{"backup", "restore"}},
+	"NotificationTemplates.title":    {Description: "text/template of the message title (fields: Event, Kind, Job, Type, Status, Duration, Size, Error, Storages...)"},
+	"NotificationTemplates.body":     {Description: "text/template of the message body"},
+	"ServerConfig.auth":              {Description: "API authentication with API keys (backup-cli apikey) and OIDC login"},
+	"AuthConfig.enabled":             {Description: "Require an API key or an OIDC session on every route (default: true; false leaves the API open)"},
+	"AuthConfig.keys_file":           {Description: "File of the API keys (default: <data_dir>/api_keys.json)"},
+	"AuthConfig.oidc":                {Description: "Single sign-on with an OpenID Connect provider (web UI and API)"},
+	"OIDCConfig.issuer":              {Description: "URL of the provider, serving /.well-known/openid-configuration"},
+	"OIDCConfig.client_secret":       {Description: "Client secret (supports ${{ENV_VAR}}); empty for a public client"},
+	"OIDCConfig.redirect_url":        {Description: "Public URL of /auth/callback registered with the provider"},
+	"OIDCConfig.scopes":              {Description: "Requested scopes (default: openid, profile, email)"},
+	"OIDCConfig.groups_claim":        {Description: "Claim holding the groups, dotted for nested claims such as realm_access.roles (default: groups)"},
+	"OIDCConfig.username_claim":      {Description: "Claim used as the user name (default: preferred_username, then email, then sub)"},
+	"OIDCConfig.roles":               {Description: "Role granted to each group (viewer, operator or admin); the highest role wins"},
+	"OIDCConfig.default_role":        {Description: "Role of the users without a mapped group (default: login refused)", Enum: []string{"viewer", "operator", "admin"}},
+	"OIDCConfig.session_ttl":         {Description: "Lifetime of a login session as a Go duration (default: 8h)"},
+	"OIDCConfig.session_secret":      {Description: "Secret signing the session cookies (default: generated in <data_dir>/session.key)"},
+	"OIDCConfig.login_redirect":      {Description: "Page opened after login, usually the web UI (default: /)"},
+	"ServerConfig.cors":              {Description: "Origins allowed to call the API from a browser"},
+	"CORSConfig.allow_origins":       {Description: "Allowed origins such as https://backup.example.com (default: *)"},
+	"ServerConfig.audit":             {Description: "Hash-chained audit log of restores, downloads, configuration changes and key operations"},
+	"AuditConfig.file":               {Description: "Path of the audit log (default: <data_dir>/audit.log)"},
+	"AuditConfig.syslog":             {Description: "Also send each audit entry to syslog"},
+	"AuditSyslogConfig.network":      {Description: "Transport to the syslog server (default: local syslog)", Enum: SyslogNetworks},
+	"AuditSyslogConfig.address":      {Description: "Address of the syslog server, e.g. syslog.example.com:514"},
+	"AuditSyslogConfig.tag":          {Description: "Syslog tag (default: mini-backup-audit)"},
+	"AuditSyslogConfig.facility":     {Description: "Syslog facility (default: auth)", Enum: SyslogFacilities},
+	"ConfigSourceConfig.type":        {Description: "Kind of configuration source", Enum: []string{"git"}},
+	"ConfigSourceConfig.repository":  {Description: "URL or local path of the git repository"},
+	"ConfigSourceConfig.branch":      {Description: "Branch to follow (default: main)"},
+	"ConfigSourceConfig.path":        {Description: "Directory of the repository containing the *.backups.yaml files"},
+	"ConfigSourceConfig.interval":    {Description: "Poll interval as a Go duration (default: 1m)"},
+	"ConfigSourceConfig.checkout":    {Description: "Local directory of the clone (default: <config dir>/.source)"},
+}
+
+// schemaRequired liste les champs obligatoires par structure.
+var schemaRequired = map[string][]string{
+	"ConfigSourceConfig": {"type", "repository"},
+	"TLSConfig":          {"cert_file", "key_file"},
+	"OIDCConfig":         {"issuer", "client_id", "redirect_url"},
+}
+
+// GenerateSchema retourne le JSON Schema correspondant au fichier demandé ("config" ou "server").
+// Le schéma est généré à partir des structures Go afin de ne jamais diverger du chargement réel.
+func GenerateSchema(name string) (map[string]any, error) {
+	switch strings.TrimSuffix(name, ".json") {
+	case "config":
+		return buildSchema("mini-backup backups configuration", reflect.TypeOf(BackupConfig{})), nil
+	case "server":
+		return buildSchema("mini-backup server configuration", reflect.TypeOf(ServerConfig{})), nil
+	default:
+		return nil, fmt.Errorf("unknown schema %q (expected one of %s)", name, strings.Join(SchemaNames, ", "))
+	}
+}
+
+// GenerateSchemaJSON retourne le schéma demandé sérialisé et indenté.
+func GenerateSchemaJSON(name string) ([]byte, error) {
+	schema, err := GenerateSchema(name)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func buildSchema(title string, t reflect.Type) map[string]any {
+	defs := map[string]any{}
+	root := schemaForStruct(t, defs)
+	root["$schema"] = schemaDraft
+	root["title"] = title
+	if len(defs) > 0 {
+		root["$defs"] = defs
+	}
+	return root
+}
+
+// schemaForType retourne le schéma d'un type Go. Les structures sont référencées via $defs.
+func schemaForType(t reflect.Type, defs map[string]any) map[string]any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaForType(t.Elem(), defs)}
+	case reflect.Struct:
+		if _, exists := defs[t.Name()]; !exists {
+			defs[t.Name()] = true // évite la récursion infinie
+			defs[t.Name()] = schemaForStruct(t, defs)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+func schemaForStruct(t reflect.Type, defs map[string]any) map[string]any {
+	properties := map[string]any{}
+	for name, field := range yamlFields(t) {
+		property := schemaForType(field.Type, defs)
+		if hint, ok := schemaHints[t.Name()+"."+name]; ok {
+			if len(hint.Types) > 0 {
+				property = map[string]any{"type": hint.Types}
+			}
+			if hint.Description != "" {
+				property["description"] = hint.Description
+			}
+			if len(hint.Enum) > 0 {
+				// Pour une liste, les valeurs autorisées s'appliquent à chaque élément
+				if items, isArray := property["items"].(map[string]any); isArray {
+					items["enum"] = hint.Enum
+				} else {
+					property["enum"] = hint.Enum
+				}
+			}
+		}
+		properties[name] = property
+	}
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required, ok := schemaRequired[t.Name()]; ok {
+		schema["required"] = required
+	}
+	return schema
+}
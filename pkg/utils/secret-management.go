@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 	"fmt"
+	"mini-backup/pkg/redact"
 
 	infisical "github.com/infisical/go-sdk"
 )
@@ -26,6 +27,7 @@ func GetSecret(secretName string, environment string) (string, error) {
 		getLogger().Error("L'environnement INFISICAL_API_KEY est manquant")
 		return "", fmt.Errorf("l'environnement INFISICAL_API_KEY est manquant")
 	}
+	redact.Register(accessToken)
 	getLogger().Info(fmt.Sprintf("Connexion à l'API Infisical : %s", infisicalURL))
 	// Initialisation du client Infisical
 	client := infisical.NewInfisicalClient(context.Background(), infisical.Config{
@@ -43,11 +45,12 @@ func GetSecret(secretName string, environment string) (string, error) {
 		ProjectID:   INFISICAL_PROJECT_ID,
 		SecretPath:  "/",
 	})
-	getLogger().Debug(fmt.Sprintf("Valeur du secret %s : %s", secretName, secret.SecretValue))
 	if err != nil {
 		getLogger().Error(fmt.Sprintf("Erreur lors de la récupération du secret %s: %v", secretName, err))
 		return "", fmt.Errorf("échec de la récupération du secret: %v", err)
 	}
-	getLogger().Info("Secret récupéré avec succès")
+	// La valeur n'apparaît jamais dans les journaux
+	redact.Register(secret.SecretValue)
+	getLogger().Info(fmt.Sprintf("Secret %s récupéré avec succès", secretName))
 	return secret.SecretValue, nil
 }
@@ -2,8 +2,12 @@ package utils
 
 import (
 	"fmt"
+	"mini-backup/pkg/jobs"
+	"mini-backup/pkg/redact"
 	"os"
 	"regexp"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,13 +17,62 @@ type ServerConfig struct {
 	Server        ServerSettings            `yaml:"server"`
 	SecretManager map[string]SecretManager  `yaml:"secret_manager"`
 	RStorage      map[string]RStorageConfig `yaml:"rstorage"`
+	ConfigSource  *ConfigSourceConfig       `yaml:"config_source,omitempty"`
+	Concurrency   ConcurrencyConfig         `yaml:"concurrency,omitempty"`
+	Logging       LoggingConfig             `yaml:"logging,omitempty"`
+	Notifications NotificationsConfig       `yaml:"notifications,omitempty"`
+	Auth          AuthConfig                `yaml:"auth,omitempty"`
+	CORS          CORSConfig                `yaml:"cors,omitempty"`
+	Audit         AuditConfig               `yaml:"audit,omitempty"`
 }
 
 type ServerSettings struct {
-	Env   string `yaml:"env"`
-	Port  string `yaml:"port"`
-	Debug bool   `yaml:"debug"`
-	Log   string `yaml:"log"`
+	Env     string `yaml:"env"`
+	Port    string `yaml:"port"`
+	Debug   bool   `yaml:"debug"`
+	Log     string `yaml:"log"`
+	DataDir string `yaml:"data_dir,omitempty"`
+	// RunLogDays est la durée de conservation (en jours) des journaux d'exécution.
+	RunLogDays int `yaml:"run_log_days,omitempty"`
+	// HistoryDays est la durée de conservation (en jours) des exécutions terminées dans l'historique.
+	HistoryDays int `yaml:"history_days,omitempty"`
+	// TLS active HTTPS (et éventuellement le mTLS) ; sans cette section, le serveur écoute en HTTP.
+	TLS *TLSConfig `yaml:"tls,omitempty"`
+}
+
+// DefaultDataDir est le répertoire des données du serveur (historique des exécutions...) sans `data_dir`.
+const DefaultDataDir = "./data"
+
+// DataDir retourne le répertoire des données persistantes du serveur.
+func DataDir() string {
+	if serverConfig, err := GetConfigServer(); err == nil && serverConfig.Server.DataDir != "" {
+		return serverConfig.Server.DataDir
+	}
+	return DefaultDataDir
+}
+
+// DefaultRunLogDays est la durée de conservation des journaux d'exécution sans `run_log_days`.
+const DefaultRunLogDays = 30
+
+// RunLogRetention retourne la durée de conservation des journaux d'exécution.
+func (s ServerSettings) RunLogRetention() time.Duration {
+	days := s.RunLogDays
+	if days <= 0 {
+		days = DefaultRunLogDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// DefaultHistoryDays est la durée de conservation de l'historique des exécutions sans `history_days`.
+const DefaultHistoryDays = 365
+
+// HistoryRetention retourne la durée de conservation de l'historique des exécutions.
+func (s ServerSettings) HistoryRetention() time.Duration {
+	days := s.HistoryDays
+	if days <= 0 {
+		days = DefaultHistoryDays
+	}
+	return time.Duration(days) * 24 * time.Hour
 }
 
 type SecretManager struct {
@@ -38,12 +91,156 @@ type RStorageConfig struct {
 	Region     string `yaml:"region"`
 }
 
-func GetConfigServer() (*ServerConfig, error) {
-	// Définir le chemin par défaut
+// ConfigSourceConfig décrit d'où proviennent les définitions des backups.
+// Sans cette section, elles sont lues dans le répertoire de configuration local.
+type ConfigSourceConfig struct {
+	Type       string `yaml:"type"`
+	Repository string `yaml:"repository"`
+	Branch     string `yaml:"branch,omitempty"`
+	Path       string `yaml:"path,omitempty"`
+	Interval   string `yaml:"interval,omitempty"`
+	Checkout   string `yaml:"checkout,omitempty"`
+}
+
+// ConcurrencyConfig limite l'exécution simultanée des backups et des restaurations.
+type ConcurrencyConfig struct {
+	// MaxParallel est le nombre maximal de jobs exécutés en même temps (0 : sans limite).
+	MaxParallel int `yaml:"max_parallel,omitempty"`
+	// MaxPerHost est le nombre maximal de jobs simultanés sur un même hôte MySQL ou MongoDB (défaut : 1).
+	MaxPerHost int `yaml:"max_per_host,omitempty"`
+	// Overlap est la politique par défaut des backups qui ne définissent pas `overlap` (défaut : skip).
+	Overlap string `yaml:"overlap,omitempty"`
+}
+
+// DefaultOverlap est la politique appliquée lorsqu'un backup est déclenché pendant qu'il s'exécute encore.
+const DefaultOverlap = jobs.OverlapSkip
+
+// OverlapPolicies liste les valeurs acceptées pour `overlap`.
+var OverlapPolicies = []string{jobs.OverlapSkip, jobs.OverlapQueue, jobs.OverlapAllow}
+
+// HostLimit retourne le nombre de jobs autorisés simultanément sur un même hôte.
+func (c ConcurrencyConfig) HostLimit() int {
+	if c.MaxPerHost <= 0 {
+		return 1
+	}
+	return c.MaxPerHost
+}
+
+// OverlapFor retourne la politique de chevauchement d'un backup.
+func (c ConcurrencyConfig) OverlapFor(backup Backup) string {
+	if backup.Overlap != "" {
+		return backup.Overlap
+	}
+	if c.Overlap != "" {
+		return c.Overlap
+	}
+	return DefaultOverlap
+}
+
+// Locks retourne les verrous d'hôte d'un backup : ses jobs ne s'exécutent pas en même temps
+// que plus de MaxPerHost autres jobs sur le même serveur MySQL ou MongoDB.
+func (c ConcurrencyConfig) Locks(backup Backup) []jobs.Lock {
+	host := ""
+	switch {
+	case backup.Type == "mysql" && backup.Mysql != nil:
+		host = "mysql://" + backup.Mysql.Host + ":" + backup.Mysql.Port
+	case backup.Type == "mongo" && backup.Mongo != nil:
+		host = "mongo://" + backup.Mongo.Host + ":" + backup.Mongo.Port
+	}
+	if host == "" {
+		return nil
+	}
+	return []jobs.Lock{{Key: "host:" + host, Shared: true, Limit: c.HostLimit()}}
+}
+
+// GetConcurrencyConfig retourne la section concurrency de server.yaml (valeurs par défaut si illisible).
+func GetConcurrencyConfig() ConcurrencyConfig {
+	serverConfig, err := GetConfigServer()
+	if err != nil {
+		return ConcurrencyConfig{}
+	}
+	return serverConfig.Concurrency
+}
+
+// Validate vérifie les valeurs de la section concurrency.
+func (c ConcurrencyConfig) Validate() error {
+	if c.MaxParallel < 0 {
+		return fmt.Errorf("concurrency.max_parallel must be positive, got %d", c.MaxParallel)
+	}
+	if c.MaxPerHost < 0 {
+		return fmt.Errorf("concurrency.max_per_host must be positive, got %d", c.MaxPerHost)
+	}
+	if c.Overlap != "" && !jobs.IsValidOverlap(c.Overlap) {
+		return fmt.Errorf("concurrency.overlap: unsupported policy %q (expected one of %s)", c.Overlap, strings.Join(OverlapPolicies, ", "))
+	}
+	return nil
+}
+
+// LoggingConfig configure le journal du processus. Sans cette section, les logs sont écrits
+// en texte dans `server.log` (logs/mini-backup.log par défaut), en debug si `server.debug` est activé.
+type LoggingConfig struct {
+	// Level vaut debug, info, warn ou error (LOG_LEVEL est prioritaire).
+	Level string `yaml:"level,omitempty"`
+	// Format vaut text ou json (LOG_FORMAT est prioritaire).
+	Format string `yaml:"format,omitempty"`
+	// Output vaut file, stdout ou both (LOG_OUTPUT est prioritaire).
+	Output string `yaml:"output,omitempty"`
+	// File remplace `server.log` (LOG_FILE est prioritaire).
+	File     string      `yaml:"file,omitempty"`
+	Rotation LogRotation `yaml:"rotation,omitempty"`
+}
+
+// LogRotation décrit la rotation du fichier de log : par taille, par période ou les deux.
+type LogRotation struct {
+	MaxSizeMB  int    `yaml:"max_size_mb,omitempty"`
+	Interval   string `yaml:"interval,omitempty"`
+	MaxBackups int    `yaml:"max_backups,omitempty"`
+	MaxAgeDays int    `yaml:"max_age_days,omitempty"`
+	Compress   bool   `yaml:"compress,omitempty"`
+}
+
+var (
+	LogLevels    = []string{"debug", "info", "warn", "error"}
+	LogFormats   = []string{"text", "json"}
+	LogOutputs   = []string{"file", "stdout", "both"}
+	LogIntervals = []string{"hourly", "daily"}
+)
+
+// Validate vérifie les valeurs de la section logging.
+func (c LoggingConfig) Validate() error {
+	checks := []struct {
+		field, value string
+		allowed      []string
+	}{
+		{"logging.level", c.Level, LogLevels},
+		{"logging.format", c.Format, LogFormats},
+		{"logging.output", c.Output, LogOutputs},
+		{"logging.rotation.interval", c.Rotation.Interval, LogIntervals},
+	}
+	for _, check := range checks {
+		if check.value != "" && !containsString(check.allowed, check.value) {
+			return fmt.Errorf("%s: unsupported value %q (expected one of %s)", check.field, check.value, strings.Join(check.allowed, ", "))
+		}
+	}
+	if c.Rotation.MaxSizeMB < 0 || c.Rotation.MaxBackups < 0 || c.Rotation.MaxAgeDays < 0 {
+		return fmt.Errorf("logging.rotation: max_size_mb, max_backups and max_age_days must be positive")
+	}
+	return nil
+}
+
+// serverConfigPath retourne le chemin du fichier server.yaml : SERVER_CONFIG_PATH, sinon
+// config/server.yaml. Il ne dépend pas de l'emplacement de la configuration des backups.
+func serverConfigPath() string {
 	configPath := os.Getenv("SERVER_CONFIG_PATH")
 	if configPath == "" {
 		configPath = "config/server.yaml"
 	}
+	return configPath
+}
+
+func GetConfigServer() (*ServerConfig, error) {
+	// Définir le chemin par défaut
+	configPath := serverConfigPath()
 	// logger.Info(fmt.Sprintf("Loading config file: %s", configPath), source_utils)
 	// Charger le fichier YAML
 	file, err := os.Open(configPath)
@@ -66,6 +263,8 @@ func GetConfigServer() (*ServerConfig, error) {
 		return nil, fmt.Errorf("failed to resolve environment variables: %w", err)
 	}
 
+	// Les secrets chargés sont masqués dans les journaux et les erreurs
+	redact.Register(redact.Secrets(&config)...)
 	return &config, nil
 }
 
@@ -102,5 +301,31 @@ func resolveEnvVariables(config *ServerConfig) error {
 	config.Server.Env = resolve(config.Server.Env)
 	config.Server.Port = resolve(config.Server.Port)
 	config.Server.Log = resolve(config.Server.Log)
+	config.Server.DataDir = resolve(config.Server.DataDir)
+	config.Logging.File = resolve(config.Logging.File)
+	config.Audit.File = resolve(config.Audit.File)
+	for key, channel := range config.Notifications.Channels {
+		channel.URL = resolve(channel.URL)
+		for header, value := range channel.Headers {
+			channel.Headers[header] = resolve(value)
+		}
+		if channel.SMTP != nil {
+			smtpConfig := *channel.SMTP
+			smtpConfig.Username = resolve(smtpConfig.Username)
+			smtpConfig.Password = resolve(smtpConfig.Password)
+			channel.SMTP = &smtpConfig
+		}
+		config.Notifications.Channels[key] = channel
+	}
+	if config.Auth.OIDC != nil {
+		oidc := *config.Auth.OIDC
+		oidc.ClientID = resolve(oidc.ClientID)
+		oidc.ClientSecret = resolve(oidc.ClientSecret)
+		oidc.SessionSecret = resolve(oidc.SessionSecret)
+		config.Auth.OIDC = &oidc
+	}
+	if config.ConfigSource != nil {
+		config.ConfigSource.Repository = resolve(config.ConfigSource.Repository)
+	}
 	return nil
 }
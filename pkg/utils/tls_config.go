@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TLSConfig active HTTPS sur le serveur d'API. Le certificat et la clé sont relus lorsqu'ils
+// changent sur le disque, ce qui permet leur renouvellement sans redémarrage.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// MinVersion vaut 1.2 (défaut) ou 1.3.
+	MinVersion string `yaml:"min_version,omitempty"`
+	// ClientCAFile active le mTLS : les certificats clients doivent être signés par cette autorité.
+	ClientCAFile string `yaml:"client_ca_file,omitempty"`
+	// ClientAuth vaut require (défaut avec client_ca_file) ou optional, pour accepter aussi
+	// les clients sans certificat (navigateurs de l'interface web).
+	ClientAuth string `yaml:"client_auth,omitempty"`
+	// HSTSMaxAge est la durée de l'en-tête Strict-Transport-Security (défaut : 8760h, 0 : désactivé).
+	HSTSMaxAge     string `yaml:"hsts_max_age,omitempty"`
+	HSTSSubdomains bool   `yaml:"hsts_subdomains,omitempty"`
+}
+
+const (
+	ClientAuthRequire  = "require"
+	ClientAuthOptional = "optional"
+	// DefaultHSTSMaxAge est la durée de l'en-tête HSTS sans `hsts_max_age` (un an).
+	DefaultHSTSMaxAge = 365 * 24 * time.Hour
+)
+
+var (
+	TLSVersions     = []string{"1.2", "1.3"}
+	ClientAuthModes = []string{ClientAuthRequire, ClientAuthOptional}
+)
+
+// ClientAuthMode retourne la politique des certificats clients ("" sans mTLS).
+func (c TLSConfig) ClientAuthMode() string {
+	if c.ClientCAFile == "" {
+		return ""
+	}
+	if c.ClientAuth == "" {
+		return ClientAuthRequire
+	}
+	return c.ClientAuth
+}
+
+// HSTSDuration retourne la durée de l'en-tête HSTS (0 : en-tête désactivé).
+func (c TLSConfig) HSTSDuration() time.Duration {
+	if c.HSTSMaxAge == "" {
+		return DefaultHSTSMaxAge
+	}
+	duration, _ := time.ParseDuration(c.HSTSMaxAge)
+	return duration
+}
+
+// Validate vérifie la section server.tls. Les fichiers sont chargés au démarrage du serveur.
+func (c TLSConfig) Validate() error {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("server.tls: cert_file and key_file are required")
+	}
+	if c.MinVersion != "" && !containsString(TLSVersions, c.MinVersion) {
+		return fmt.Errorf("server.tls.min_version: unsupported value %q (expected one of %s)", c.MinVersion, strings.Join(TLSVersions, ", "))
+	}
+	if c.ClientAuth != "" {
+		if !containsString(ClientAuthModes, c.ClientAuth) {
+			return fmt.Errorf("server.tls.client_auth: unsupported value %q (expected one of %s)", c.ClientAuth, strings.Join(ClientAuthModes, ", "))
+		}
+		if c.ClientCAFile == "" {
+			return fmt.Errorf("server.tls.client_auth requires client_ca_file")
+		}
+	}
+	if c.HSTSMaxAge != "" && c.HSTSMaxAge != "0" {
+		if duration, err := time.ParseDuration(c.HSTSMaxAge); err != nil || duration < 0 {
+			return fmt.Errorf("server.tls.hsts_max_age: invalid duration %q (expected e.g. 8760h, or 0 to disable)", c.HSTSMaxAge)
+		}
+	}
+	return nil
+}